@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/smartcontractkit/branch-out/golang"
+	"github.com/smartcontractkit/branch-out/trunk"
+)
+
+// Formats of the inventory command's output.
+const (
+	inventoryFormatJSON     = "json"
+	inventoryFormatCSV      = "csv"
+	inventoryFormatMarkdown = "markdown"
+)
+
+var (
+	inventoryRepoPath   string
+	inventoryFormat     string
+	inventoryRepoURL    string
+	inventoryBranch     string
+	inventoryBuildFlags []string
+)
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "List every quarantined test in a local checkout of a repository",
+	Long: `List every quarantined test in a local checkout of a repository, with its package, name, file, line and ticket.
+
+Tests quarantined in their source, packages quarantined in their TestMain, and tests of the quarantine manifest are all listed.
+The inventory is written as JSON, CSV, or a Markdown table good for a report or an issue.`,
+	Example: `# List the quarantined tests of the current repository as JSON
+branch-out inventory
+
+# Write a Markdown table of the quarantined tests, linking to them on GitHub
+branch-out inventory --repo-path ../repo --format markdown --repo-url https://github.com/org/repo --branch main
+
+# List the quarantined tests of packages with build tags as CSV
+branch-out inventory --format csv --build-flags=-tags=integration > quarantined.csv`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		repoPath, err := filepath.Abs(inventoryRepoPath)
+		if err != nil {
+			return fmt.Errorf("failed to resolve repository path: %w", err)
+		}
+		l := logger.With().
+			Str("command", "inventory").
+			Str("repo_path", repoPath).
+			Str("format", inventoryFormat).
+			Logger()
+
+		var owner, repo string
+		if inventoryRepoURL != "" {
+			if _, owner, repo, err = trunk.ParseRepoURL(inventoryRepoURL); err != nil {
+				return err
+			}
+		}
+
+		inventory, err := golang.FindQuarantinedTests(l, repoPath, golang.WithBuildFlags(inventoryBuildFlags))
+		if err != nil {
+			return fmt.Errorf("failed to find quarantined tests: %w", err)
+		}
+
+		switch inventoryFormat {
+		case inventoryFormatJSON:
+			return inventory.WriteJSON(cmd.OutOrStdout())
+		case inventoryFormatCSV:
+			return inventory.WriteCSV(cmd.OutOrStdout())
+		case inventoryFormatMarkdown:
+			_, err := fmt.Fprint(cmd.OutOrStdout(), inventory.Markdown(owner, repo, inventoryBranch))
+			return err
+		default:
+			return fmt.Errorf("unknown format %q, must be one of %s, %s or %s",
+				inventoryFormat, inventoryFormatJSON, inventoryFormatCSV, inventoryFormatMarkdown)
+		}
+	},
+}
+
+func init() {
+	root.AddCommand(inventoryCmd)
+
+	inventoryCmd.Flags().StringVar(&inventoryRepoPath, "repo-path", ".", "Path of the local checkout of the repository")
+	inventoryCmd.Flags().
+		StringVarP(&inventoryFormat, "format", "o", inventoryFormatJSON, "Output format: json, csv or markdown")
+	inventoryCmd.Flags().
+		StringVar(&inventoryRepoURL, "repo-url", "", "Repository URL to link the Markdown table to (e.g. https://github.com/org/repo)")
+	inventoryCmd.Flags().StringVar(&inventoryBranch, "branch", "main", "Branch to link the Markdown table to")
+	inventoryCmd.Flags().
+		StringArrayVar(&inventoryBuildFlags, "build-flags", nil, "Build flag to load packages with (e.g. -tags=e2e), can be repeated")
+}
@@ -1,8 +1,17 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
@@ -11,11 +20,55 @@ import (
 )
 
 var (
-	testPackage string
-	testName    string
-	repoURL     string
+	testPackage  string
+	testName     string
+	repoURL      string
+	markFromFile string
 )
 
+// markStatuses are the statuses tests can be marked with.
+var markStatuses = []string{trunk.TestCaseStatusFlaky, trunk.TestCaseStatusHealthy, trunk.TestCaseStatusBroken}
+
+// markFileColumns are the columns of the CSV files that mark --from-file reads, in their default order.
+var markFileColumns = []string{"status", "package", "test", "repo"}
+
+// markRow is a test status change to mark, read from a file by mark --from-file.
+type markRow struct {
+	Status  string `json:"status"`
+	Package string `json:"package"`
+	Test    string `json:"test"`
+	Repo    string `json:"repo"`
+}
+
+// statusChange returns the status change as if Trunk.io had sent a webhook for it.
+func (r markRow) statusChange() trunk.TestCaseStatusChange {
+	return trunk.TestCaseStatusChange{
+		TestCase: trunk.TestCase{
+			TestSuite: r.Package,
+			Name:      r.Test,
+			Repository: trunk.Repository{
+				HTMLURL: r.Repo,
+			},
+		},
+		StatusChange: trunk.StatusChange{
+			CurrentStatus: trunk.Status{
+				Value: r.Status,
+			},
+		},
+	}
+}
+
+// validate checks that the row has every field, and a status that can be marked.
+func (r markRow) validate() error {
+	if r.Status == "" || r.Package == "" || r.Test == "" || r.Repo == "" {
+		return fmt.Errorf("status, package, test and repo are required, got %+v", r)
+	}
+	if !slices.Contains(markStatuses, r.Status) {
+		return fmt.Errorf("unknown status %q, expected one of %s", r.Status, strings.Join(markStatuses, ", "))
+	}
+	return nil
+}
+
 var markCmd = &cobra.Command{
 	Use:   "mark <status>",
 	Short: "Mark a test with a specific status",
@@ -23,38 +76,53 @@ var markCmd = &cobra.Command{
 
 This is useful for testing or for manually triggering a test status change that a running server wasn't able to receive.
 
-The status can be one of: flaky, healthy, or broken.`,
+The status can be one of: flaky, healthy, or broken.
+
+With --from-file, many status changes are marked at once, e.g. to replay the webhooks missed during an outage.
+The file has a row per test, either as JSON lines with status, package, test and repo fields,
+or as CSV with status, package, test and repo columns, in that order unless the file has a header row.
+Rows are grouped by repository and status, and each group is quarantined or un-quarantined with a single PR.
+The outcome of every row is printed at the end.`,
 	Example: `# Mark a test as flaky
 branch-out mark flaky --package github.com/smartcontractkit/branch-out/package --name TestName --repo https://github.com/smartcontractkit/branch-out
 
 # Mark a test as healthy
-branch-out mark healthy --package github.com/smartcontractkit/branch-out/package --name TestName --repo https://github.com/smartcontractkit/branch-out`,
-	Args:      cobra.ExactArgs(1),
-	ValidArgs: []string{trunk.TestCaseStatusFlaky, trunk.TestCaseStatusHealthy, trunk.TestCaseStatusBroken},
-	RunE: func(_ *cobra.Command, args []string) error {
-		testStatus := args[0]
+branch-out mark healthy --package github.com/smartcontractkit/branch-out/package --name TestName --repo https://github.com/smartcontractkit/branch-out
+
+# Mark the tests of a file, e.g. status-changes.csv:
+#   status,package,test,repo
+#   flaky,github.com/smartcontractkit/branch-out/package,TestName,https://github.com/smartcontractkit/branch-out
+branch-out mark --from-file status-changes.csv`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: markStatuses,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if markFromFile != "" {
+			if len(args) > 0 {
+				return errors.New("the status is read from the file with --from-file, not from the arguments")
+			}
+			return markFile(cmd, markFromFile)
+		}
+		if len(args) == 0 {
+			return errors.New("a status is required, or --from-file")
+		}
+		for _, flag := range []string{"package", "name", "repo"} {
+			if !cmd.Flags().Changed(flag) {
+				return fmt.Errorf("--%s is required without --from-file", flag)
+			}
+		}
+		row := markRow{Status: args[0], Package: testPackage, Test: testName, Repo: repoURL}
+		if err := row.validate(); err != nil {
+			return err
+		}
 
 		l := logger.With().
 			Str("command", "mark").
 			Str("test_package", testPackage).
 			Str("test_name", testName).
-			Str("test_status", testStatus).
+			Str("test_status", row.Status).
 			Logger()
 
-		statusChange := trunk.TestCaseStatusChange{
-			TestCase: trunk.TestCase{
-				TestSuite: testPackage,
-				Name:      testName,
-				Repository: trunk.Repository{
-					HTMLURL: repoURL,
-				},
-			},
-			StatusChange: trunk.StatusChange{
-				CurrentStatus: trunk.Status{
-					Value: testStatus,
-				},
-			},
-		}
+		statusChange := row.statusChange()
 
 		jiraClient, trunkClient, githubClient, _, err := processing.CreateClients(l, appConfig, nil)
 		if err != nil {
@@ -67,7 +135,14 @@ branch-out mark healthy --package github.com/smartcontractkit/branch-out/package
 			return fmt.Errorf("failed to marshal status change: %w", err)
 		}
 
-		err = processing.ProcessWebhookPayload(l, jiraClient, trunkClient, githubClient, string(payload))
+		err = processing.ProcessWebhookPayload(
+			l,
+			jiraClient,
+			trunkClient,
+			githubClient,
+			string(payload),
+			processing.WithQuarantineConfig(appConfig),
+		)
 		if err != nil {
 			return fmt.Errorf("failed to handle test status changed: %w", err)
 		}
@@ -77,25 +152,163 @@ branch-out mark healthy --package github.com/smartcontractkit/branch-out/package
 	},
 }
 
-func init() {
-	root.AddCommand(markCmd)
+// markFile marks the status changes of the file, see readMarkFile, and prints the outcome of each of them.
+func markFile(cmd *cobra.Command, path string) error {
+	l := logger.With().Str("command", "mark").Str("from_file", path).Logger()
 
-	markCmd.Flags().
-		StringVarP(&testPackage, "package", "p", "", "The test package (e.g. github.com/smartcontractkit/branch-out/package)")
-	markCmd.Flags().StringVarP(&testName, "name", "n", "", "The test name (e.g. TestName)")
-	markCmd.Flags().
-		StringVarP(&repoURL, "repo", "r", "", "The repository URL (e.g. https://github.com/smartcontractkit/branch-out)")
+	rows, err := readMarkFile(path)
+	if err != nil {
+		return err
+	}
+	statusChanges := make([]trunk.TestCaseStatusChange, 0, len(rows))
+	for _, row := range rows {
+		statusChanges = append(statusChanges, row.statusChange())
+	}
 
-	err := markCmd.MarkFlagRequired("package")
+	jiraClient, trunkClient, githubClient, _, err := processing.CreateClients(l, appConfig, nil)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to create clients: %w", err)
 	}
-	err = markCmd.MarkFlagRequired("name")
+	outcomes, err := processing.ProcessStatusChanges(
+		cmd.Context(),
+		l,
+		jiraClient,
+		trunkClient,
+		githubClient,
+		statusChanges,
+		processing.WithQuarantineConfig(appConfig),
+	)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to handle test status changes: %w", err)
+	}
+
+	failed := 0
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROW\tSTATUS\tREPO\tPACKAGE\tTEST\tTICKET\tOUTCOME\tERROR")
+	for index, outcome := range outcomes {
+		row := rows[index]
+		errMessage := ""
+		if outcome.Err != nil {
+			failed++
+			errMessage = outcome.Err.Error()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			index+1, row.Status, row.Repo, row.Package, row.Test, outcome.JiraTicket, outcome.Outcome, errMessage)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	l.Info().Int("rows", len(rows)).Int("failed", failed).Msg("Marked tests from file")
+	if failed > 0 {
+		return fmt.Errorf("failed to mark %d of %d tests", failed, len(rows))
+	}
+	return nil
+}
+
+// readMarkFile reads the status changes to mark from a file of JSON lines, or of CSV rows if it doesn't start with a
+// JSON object.
+func readMarkFile(path string) ([]markRow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status changes: %w", err)
+	}
+
+	var rows []markRow
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		rows, err = parseMarkJSONL(bytes.NewReader(data))
+	} else {
+		rows, err = parseMarkCSV(bytes.NewReader(data))
 	}
-	err = markCmd.MarkFlagRequired("repo")
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s: no status changes to mark", path)
+	}
+	return rows, nil
+}
+
+// parseMarkJSONL parses status changes from JSON lines, e.g. {"status": "flaky", "package": ..., "test": ...,
+// "repo": ...}. Blank lines are ignored.
+func parseMarkJSONL(r io.Reader) ([]markRow, error) {
+	var (
+		rows    []markRow
+		scanner = bufio.NewScanner(r)
+		line    = 0
+	)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.DisallowUnknownFields()
+		var row markRow
+		if err := decoder.Decode(&row); err != nil {
+			return nil, fmt.Errorf("failed to parse line %d: %w", line, err)
+		}
+		if err := row.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read status changes: %w", err)
+	}
+	return rows, nil
+}
+
+// parseMarkCSV parses status changes from CSV rows, with the columns of markFileColumns.
+// A header row naming the columns is optional, and lets them come in any order.
+func parseMarkCSV(r io.Reader) ([]markRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(markFileColumns)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
 	}
+
+	columns, firstLine := markFileColumns, 1
+	header := make([]string, 0, len(records[0]))
+	for _, field := range records[0] {
+		header = append(header, strings.ToLower(strings.TrimSpace(field)))
+	}
+	if slices.Equal(slices.Sorted(slices.Values(header)), slices.Sorted(slices.Values(markFileColumns))) {
+		columns, records, firstLine = header, records[1:], 2
+	}
+
+	rows := make([]markRow, 0, len(records))
+	for index, record := range records {
+		fields := make(map[string]string, len(columns))
+		for column, name := range columns {
+			fields[name] = strings.TrimSpace(record[column])
+		}
+		row := markRow{Status: fields["status"], Package: fields["package"], Test: fields["test"], Repo: fields["repo"]}
+		if err := row.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", firstLine+index, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func init() {
+	root.AddCommand(markCmd)
+
+	markCmd.Flags().
+		StringVarP(&testPackage, "package", "p", "", "The test package (e.g. github.com/smartcontractkit/branch-out/package)")
+	markCmd.Flags().StringVarP(&testName, "name", "n", "", "The test name (e.g. TestName)")
+	markCmd.Flags().
+		StringVarP(&repoURL, "repo", "r", "", "The repository URL (e.g. https://github.com/smartcontractkit/branch-out)")
+	markCmd.Flags().
+		StringVarP(&markFromFile, "from-file", "f", "", "JSON lines or CSV file of the status changes to mark")
+	markCmd.MarkFlagsMutuallyExclusive("from-file", "package")
+	markCmd.MarkFlagsMutuallyExclusive("from-file", "name")
+	markCmd.MarkFlagsMutuallyExclusive("from-file", "repo")
 }
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMarkFile(t *testing.T) {
+	t.Parallel()
+
+	var (
+		flakyRow = markRow{
+			Status:  "flaky",
+			Package: "github.com/org/repo/pkg",
+			Test:    "TestFoo",
+			Repo:    "https://github.com/org/repo",
+		}
+		healthyRow = markRow{
+			Status:  "healthy",
+			Package: "github.com/org/repo/other",
+			Test:    "TestBar/sub",
+			Repo:    "https://github.com/org/repo",
+		}
+	)
+
+	tests := []struct {
+		name          string
+		file          string
+		expectedRows  []markRow
+		expectedError string
+	}{
+		{
+			name: "json lines",
+			file: `{"status": "flaky", "package": "github.com/org/repo/pkg", "test": "TestFoo", "repo": "https://github.com/org/repo"}
+
+{"status": "healthy", "package": "github.com/org/repo/other", "test": "TestBar/sub", "repo": "https://github.com/org/repo"}
+`,
+			expectedRows: []markRow{flakyRow, healthyRow},
+		},
+		{
+			name: "csv",
+			file: `flaky,github.com/org/repo/pkg,TestFoo,https://github.com/org/repo
+healthy, github.com/org/repo/other, TestBar/sub, https://github.com/org/repo
+`,
+			expectedRows: []markRow{flakyRow, healthyRow},
+		},
+		{
+			name: "csv with header",
+			file: `Repo,Test,Package,Status
+https://github.com/org/repo,TestFoo,github.com/org/repo/pkg,flaky
+`,
+			expectedRows: []markRow{flakyRow},
+		},
+		{
+			name:          "unknown status",
+			file:          "status,package,test,repo\nfixed,github.com/org/repo/pkg,TestFoo,https://github.com/org/repo\n",
+			expectedError: `line 2: unknown status "fixed"`,
+		},
+		{
+			name:          "missing field",
+			file:          `{"status": "flaky", "package": "github.com/org/repo/pkg", "repo": "https://github.com/org/repo"}`,
+			expectedError: "line 1: status, package, test and repo are required",
+		},
+		{
+			name:          "unknown field",
+			file:          `{"status": "flaky", "pkg": "github.com/org/repo/pkg"}`,
+			expectedError: "failed to parse line 1",
+		},
+		{
+			name:          "wrong number of columns",
+			file:          "flaky,github.com/org/repo/pkg,TestFoo\n",
+			expectedError: "failed to parse CSV",
+		},
+		{
+			name:          "empty",
+			file:          "\n",
+			expectedError: "no status changes to mark",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "status-changes")
+			require.NoError(t, os.WriteFile(path, []byte(test.file), 0600))
+
+			rows, err := readMarkFile(path)
+			if test.expectedError != "" {
+				require.ErrorContains(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedRows, rows)
+		})
+	}
+}
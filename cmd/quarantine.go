@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/smartcontractkit/branch-out/golang"
+)
+
+// localTestsFlags are the flags of the commands updating tests in a local checkout of a repository.
+type localTestsFlags struct {
+	repoPath   string
+	pkg        string
+	tests      []string
+	ticket     string
+	dryRun     bool
+	buildFlags []string
+}
+
+var (
+	quarantineFlags   localTestsFlags
+	unquarantineFlags localTestsFlags
+)
+
+var quarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "Quarantine tests in a local checkout of a repository",
+	Long: `Quarantine tests in a local checkout of a repository, without any Jira, Trunk.io or GitHub credentials.
+
+The tests are quarantined the same way the server does it, then written to the repository for you to review and commit.
+Repositories with a quarantine manifest have their tests quarantined in it instead of in their source.
+
+Use TestMain as the test name to quarantine a whole package.`,
+	Example: `# Quarantine a test
+branch-out quarantine --package github.com/org/repo/package --test TestFoo --ticket JIRA-123
+
+# Quarantine a subtest and a test of a repository checked out elsewhere, with build tags
+branch-out quarantine --repo-path ../repo --package github.com/org/repo/package --test TestFoo/subtest --test TestBar \
+  --ticket JIRA-123 --build-flags=-tags=integration
+
+# Print the changes that quarantining a test would make, without making them
+branch-out quarantine --package github.com/org/repo/package --test TestFoo --ticket JIRA-123 --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return updateLocalTests(cmd, quarantineFlags, false)
+	},
+}
+
+var unquarantineCmd = &cobra.Command{
+	Use:   "unquarantine",
+	Short: "Un-quarantine tests in a local checkout of a repository",
+	Long: `Un-quarantine tests in a local checkout of a repository, without any Jira, Trunk.io or GitHub credentials.
+
+The quarantine of the tests is removed the same way the server does it, then written to the repository for you to review
+and commit.
+Repositories with a quarantine manifest have their tests removed from it instead.`,
+	Example: `# Un-quarantine a test
+branch-out unquarantine --package github.com/org/repo/package --test TestFoo
+
+# Print the changes that un-quarantining a test would make, without making them
+branch-out unquarantine --package github.com/org/repo/package --test TestFoo --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return updateLocalTests(cmd, unquarantineFlags, true)
+	},
+}
+
+// updateLocalTests quarantines or un-quarantines the tests of the flags in the local repository,
+// and writes the changes to it, or prints them for dry runs.
+func updateLocalTests(cmd *cobra.Command, flags localTestsFlags, unquarantine bool) error {
+	action := "quarantine"
+	if unquarantine {
+		action = "unquarantine"
+	}
+	repoPath, err := filepath.Abs(flags.repoPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve repository path: %w", err)
+	}
+
+	l := logger.With().
+		Str("command", action).
+		Str("repo_path", repoPath).
+		Str("test_package", flags.pkg).
+		Strs("test_names", flags.tests).
+		Bool("dry_run", flags.dryRun).
+		Logger()
+
+	target := golang.QuarantineTarget{Package: flags.pkg}
+	for _, test := range flags.tests {
+		target.Tests = append(target.Tests, golang.TestToQuarantine{Name: test, JiraTicket: flags.ticket})
+	}
+	targets := []golang.QuarantineTarget{target}
+
+	generator, err := golang.ParseGenerator(appConfig.QuarantineGenerator)
+	if err != nil {
+		return err
+	}
+
+	var (
+		results  golang.QuarantineResults
+		manifest = golang.HasManifest(repoPath)
+		options  = []golang.QuarantineOption{
+			golang.WithBuildFlags(flags.buildFlags),
+			golang.WithGenerator(generator),
+			golang.WithPackageQuarantine(true),
+			golang.WithQuarantineModuleVersion(appConfig.QuarantineModuleVersion),
+		}
+	)
+	switch {
+	case manifest && unquarantine:
+		results, err = golang.UnquarantineManifest(l, repoPath, targets)
+	case manifest:
+		results, err = golang.QuarantineManifest(l, repoPath, targets)
+	case unquarantine:
+		results, err = golang.UnquarantineTests(l, repoPath, targets, options...)
+	default:
+		results, err = golang.QuarantineTests(l, repoPath, targets, options...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to %s tests: %w", action, err)
+	}
+
+	if flags.dryRun {
+		diff, err := results.Diff()
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), diff)
+	} else {
+		if err := golang.WriteQuarantineResultsToFiles(l, results); err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), results.String())
+	}
+
+	l.Info().
+		Int("successful", results.SuccessfulTestsCount()).
+		Int("already_quarantined", results.AlreadyQuarantinedTestsCount()).
+		Int("failed", results.FailedTestsCount()).
+		Bool("manifest", manifest).
+		Msgf("Finished %s", action)
+	if failed := results.FailedTestsCount(); failed > 0 {
+		return fmt.Errorf("failed to %s %d tests", action, failed)
+	}
+	return nil
+}
+
+func init() {
+	for _, local := range []struct {
+		command *cobra.Command
+		flags   *localTestsFlags
+	}{
+		{quarantineCmd, &quarantineFlags},
+		{unquarantineCmd, &unquarantineFlags},
+	} {
+		command, flags := local.command, local.flags
+		root.AddCommand(command)
+
+		command.Flags().StringVar(&flags.repoPath, "repo-path", ".", "Path of the local checkout of the repository")
+		command.Flags().
+			StringVarP(&flags.pkg, "package", "p", "", "Import path of the test package (e.g. github.com/org/repo/package)")
+		command.Flags().
+			StringArrayVarP(&flags.tests, "test", "t", nil, "Name of a test (e.g. TestFoo or TestFoo/subtest), can be repeated")
+		command.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print the changes as a diff instead of writing them")
+		command.Flags().
+			StringArrayVar(&flags.buildFlags, "build-flags", nil, "Build flag to load packages with (e.g. -tags=e2e), can be repeated")
+
+		for _, flag := range []string{"package", "test"} {
+			if err := command.MarkFlagRequired(flag); err != nil {
+				panic(err)
+			}
+		}
+	}
+	quarantineCmd.Flags().StringVar(&quarantineFlags.ticket, "ticket", "", "Ticket tracking the flaky tests (e.g. JIRA-123)")
+	if err := quarantineCmd.MarkFlagRequired("ticket"); err != nil {
+		panic(err)
+	}
+}
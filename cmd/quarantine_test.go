@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/branch-out/internal/testhelpers"
+)
+
+const exampleProjectPackage = "github.com/smartcontractkit/branch-out-example-project"
+
+func TestLocalTestsFlags(t *testing.T) {
+	err := quarantineCmd.ParseFlags([]string{
+		"--repo-path", "../repo",
+		"--package", exampleProjectPackage,
+		"--test", "TestStandard1",
+		"--test", "TestStandard2",
+		"--ticket", "JIRA-123",
+		"--dry-run",
+		"--build-flags=-tags=example_project",
+		"--build-flags=-race",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, localTestsFlags{
+		repoPath:   "../repo",
+		pkg:        exampleProjectPackage,
+		tests:      []string{"TestStandard1", "TestStandard2"},
+		ticket:     "JIRA-123",
+		dryRun:     true,
+		buildFlags: []string{"-tags=example_project", "-race"},
+	}, quarantineFlags)
+	assert.Equal(t, localTestsFlags{repoPath: "."}, unquarantineFlags, "other commands' flags should be untouched")
+	assert.Empty(t, inventoryBuildFlags, "other commands' flags should be untouched")
+	assert.Empty(t, reconcileBuildFlags, "other commands' flags should be untouched")
+	assert.Empty(t, syncBuildFlags, "other commands' flags should be untouched")
+}
+
+func TestUpdateLocalTests(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	// Copied next to the example project's own directory, for its replace of the quarantine module to keep working
+	repoPath := strings.ReplaceAll(t.Name(), "/", "_") + "-copied-code"
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Logf("leaving dir %s for debugging", repoPath)
+		} else {
+			assert.NoError(t, os.RemoveAll(repoPath), "failed to remove copied code")
+		}
+	})
+	require.NoError(t, testhelpers.CopyDir(t, filepath.Join("..", "golang", "example_project"), repoPath))
+
+	testFile := filepath.Join(repoPath, "standard_test.go")
+	originalSource, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+
+	flags := localTestsFlags{
+		repoPath:   repoPath,
+		pkg:        exampleProjectPackage,
+		tests:      []string{"TestStandard1"},
+		ticket:     "JIRA-123",
+		buildFlags: []string{"-tags=example_project"},
+	}
+	run := func(flags localTestsFlags, unquarantine bool) (string, error) {
+		var out bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetOut(&out)
+		err := updateLocalTests(cmd, flags, unquarantine)
+		return out.String(), err
+	}
+	source := func() string {
+		source, err := os.ReadFile(testFile)
+		require.NoError(t, err)
+		return string(source)
+	}
+
+	// Build flags are passed to the go command, which rejects unknown ones
+	invalidFlags := flags
+	invalidFlags.buildFlags = []string{"-not-a-build-flag"}
+	_, err = run(invalidFlags, false)
+	require.Error(t, err, "build flags should be passed to the go command")
+
+	// Dry runs print the changes without making them
+	dryRunFlags := flags
+	dryRunFlags.dryRun = true
+	out, err := run(dryRunFlags, false)
+	require.NoError(t, err)
+	assert.Contains(t, out, "--- a/standard_test.go")
+	assert.Contains(t, out, `+	quarantine.Flaky(t, "JIRA-123")`)
+	assert.Equal(t, string(originalSource), source(), "dry runs shouldn't write the changes")
+
+	out, err = run(flags, false)
+	require.NoError(t, err)
+	assert.Contains(t, out, exampleProjectPackage)
+	assert.Contains(t, source(), `quarantine.Flaky(t, "JIRA-123")`)
+	quarantinedSource := source()
+
+	dryRunFlags.ticket = ""
+	out, err = run(dryRunFlags, true)
+	require.NoError(t, err)
+	assert.Contains(t, out, `-	quarantine.Flaky(t, "JIRA-123")`)
+	assert.Equal(t, quarantinedSource, source(), "dry runs shouldn't write the changes")
+
+	flags.ticket = ""
+	_, err = run(flags, true)
+	require.NoError(t, err)
+	assert.Equal(t, string(originalSource), source())
+}
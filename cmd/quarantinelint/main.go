@@ -0,0 +1,47 @@
+// Package main runs the quarantine hygiene analyzer of branch-out, see golang.NewAnalyzer.
+//
+// Run it on its own, or with go vet:
+//
+//	go install github.com/smartcontractkit/branch-out/cmd/quarantinelint@latest
+//	quarantinelint ./...
+//	go vet -vettool=$(which quarantinelint) -quarantinelint.ticket-statuses=tickets.json ./...
+//
+// It's also a golangci-lint Go plugin, built with:
+//
+//	go build -buildmode=plugin -o quarantinelint.so github.com/smartcontractkit/branch-out/cmd/quarantinelint
+//
+// and configured as a custom linter, with any of the analyzer's flags as settings:
+//
+//	linters:
+//	  settings:
+//	    custom:
+//	      quarantinelint:
+//	        path: quarantinelint.so
+//	        settings:
+//	          ticket-pattern: "^PROJ-[0-9]+$"
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/smartcontractkit/branch-out/golang"
+)
+
+func main() {
+	singlechecker.Main(golang.NewAnalyzer())
+}
+
+// New returns the analyzer for golangci-lint, with the flags of the analyzer set from the linter's settings.
+func New(conf any) ([]*analysis.Analyzer, error) {
+	analyzer := golang.NewAnalyzer()
+	settings, _ := conf.(map[string]any)
+	for name, value := range settings {
+		if err := analyzer.Flags.Set(name, fmt.Sprint(value)); err != nil {
+			return nil, fmt.Errorf("invalid %s setting %q: %w", golang.AnalyzerName, name, err)
+		}
+	}
+	return []*analysis.Analyzer{analyzer}, nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/smartcontractkit/branch-out/processing"
+)
+
+var (
+	reconcileRepoURL    string
+	reconcileOrgURLSlug string
+	reconcileFix        bool
+	reconcileBuildFlags []string
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Report drift between the quarantines in code, Trunk.io and Jira",
+	Long: `Compare three sources of quarantined tests: the tests quarantined in the code of the repository's default branch,
+Trunk.io's quarantined tests, and the open flaky test tickets of the Jira project. Their drift is reported:
+
+- closed ticket: a test quarantined in code for a ticket that isn't open anymore
+- deleted test: an open ticket for a test that isn't in the repository anymore
+- not quarantined: a test quarantined by Trunk.io that isn't quarantined in code
+- not quarantined by Trunk.io: a test quarantined in code that Trunk.io doesn't quarantine, only reported
+
+With --fix, tests quarantined for closed tickets are un-quarantined in a single PR, tests quarantined by Trunk.io are
+quarantined in another with their tickets created if needed, and the tickets of deleted tests are closed.`,
+	Example: `# Report the drift of a repository
+branch-out reconcile --repo https://github.com/smartcontractkit/branch-out
+
+# Fix the drift of a repository whose Trunk.io organization isn't named after its owner
+branch-out reconcile --repo https://github.com/smartcontractkit/branch-out --org-url-slug smartcontract --fix`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		l := logger.With().
+			Str("command", "reconcile").
+			Str("repo_url", reconcileRepoURL).
+			Bool("fix", reconcileFix).
+			Logger()
+
+		jiraClient, trunkClient, githubClient, _, err := processing.CreateClients(l, appConfig, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create clients: %w", err)
+		}
+		webhookProcessor := processing.NewWebhookProcessor(
+			l,
+			jiraClient,
+			trunkClient,
+			githubClient,
+			nil,
+			processing.WithQuarantineConfig(appConfig),
+		)
+		drifts, err := webhookProcessor.Reconcile(
+			cmd.Context(),
+			reconcileRepoURL,
+			reconcileOrgURLSlug,
+			reconcileFix,
+			processing.WithBuildFlags(reconcileBuildFlags),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile quarantined tests: %w", err)
+		}
+
+		failed := 0
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DRIFT\tPACKAGE\tTEST\tVARIANT\tTICKET\tLOCATION\tOUTCOME\tERROR")
+		for _, drift := range drifts {
+			errMessage := ""
+			if drift.Err != nil {
+				failed++
+				errMessage = drift.Err.Error()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				drift.Kind,
+				drift.Package,
+				drift.Test,
+				drift.Variant,
+				drift.Ticket,
+				drift.Location,
+				drift.Outcome,
+				errMessage,
+			)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		l.Info().Int("drifts", len(drifts)).Int("failed", failed).Msg("Reconciled quarantined tests")
+		if failed > 0 {
+			return fmt.Errorf("failed to fix the drift of %d tests", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	root.AddCommand(reconcileCmd)
+
+	reconcileCmd.Flags().
+		StringVarP(&reconcileRepoURL, "repo", "r", "", "The repository URL (e.g. https://github.com/smartcontractkit/branch-out)")
+	reconcileCmd.Flags().
+		StringVar(&reconcileOrgURLSlug, "org-url-slug", "", "Trunk.io organization of the repository, its owner by default")
+	reconcileCmd.Flags().BoolVar(&reconcileFix, "fix", false, "Fix the drift with PRs and ticket updates")
+	reconcileCmd.Flags().
+		StringArrayVar(&reconcileBuildFlags, "build-flags", nil, "Build flag to load packages with (e.g. -tags=e2e), can be repeated")
+	if err := reconcileCmd.MarkFlagRequired("repo"); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+var minPasses int
+
+var reportCmd = &cobra.Command{
+	Use:   "report <report-file>...",
+	Short: "Summarize reports of quarantined test runs",
+	Long: `Summarize the reports quarantined tests write when the QUARANTINE_REPORT_PATH environment variable is set.
+
+Reports of multiple runs are combined, and every quarantined test is listed with how many times it passed, failed and was skipped.
+Tests that passed at least --min-passes times in a row since they last failed are marked as stable, and are good candidates to un-quarantine.`,
+	Example: `# Run quarantined tests and report their results
+RUN_QUARANTINED_TESTS=true QUARANTINE_REPORT_PATH=$PWD/quarantine-report.jsonl go test ./...
+
+# Summarize the reports of multiple runs
+branch-out report run-1.jsonl run-2.jsonl --min-passes 20`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var records []quarantine.ReportRecord
+		for _, path := range args {
+			fileRecords, err := readReport(path)
+			if err != nil {
+				return err
+			}
+			records = append(records, fileRecords...)
+		}
+
+		summaries := quarantine.SummarizeReport(records)
+		logger.Debug().
+			Strs("reports", args).
+			Int("records", len(records)).
+			Int("tests", len(summaries)).
+			Msg("Summarized quarantine reports")
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PACKAGE\tTEST\tTICKET\tPASSED\tFAILED\tSKIPPED\tPASSES IN A ROW\tSTABLE")
+		for _, summary := range summaries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%t\n",
+				summary.Package,
+				summary.Test,
+				summary.Ticket,
+				summary.Passes,
+				summary.Failures,
+				summary.Skips,
+				summary.ConsecutivePasses,
+				summary.Stable(minPasses),
+			)
+		}
+		return w.Flush()
+	},
+}
+
+// readReport reads the records of a quarantine report file.
+func readReport(path string) ([]quarantine.ReportRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open quarantine report: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	records, err := quarantine.ParseReport(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return records, nil
+}
+
+func init() {
+	root.AddCommand(reportCmd)
+
+	reportCmd.Flags().
+		IntVar(&minPasses, "min-passes", 10, "Passes in a row since the last failure for a test to be considered stable")
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/smartcontractkit/branch-out/processing"
+)
+
+var (
+	syncRepoURL    string
+	syncOrgURLSlug string
+	syncBuildFlags []string
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Quarantine the tests that Trunk.io quarantines in a repository",
+	Long: `Backfill the quarantines of a repository from Trunk.io's quarantined tests, e.g. when onboarding a repository
+whose flaky tests Trunk.io quarantined before branch-out ever got a webhook for them.
+
+Every test that Trunk.io quarantines gets a Jira ticket if it doesn't have an open one already,
+and the tests that aren't quarantined in code yet are quarantined at once, in a single PR.
+Syncing again doesn't make a PR if nothing changed. The outcome of every test is printed at the end.
+
+The server can also sync repositories on a schedule, see SYNC_REPOS and SYNC_INTERVAL_HOURS.`,
+	Example: `# Quarantine the tests that Trunk.io quarantines
+branch-out sync --repo https://github.com/smartcontractkit/branch-out
+
+# Sync a repository whose Trunk.io organization isn't named after its owner
+branch-out sync --repo https://github.com/smartcontractkit/branch-out --org-url-slug smartcontract`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		l := logger.With().
+			Str("command", "sync").
+			Str("repo_url", syncRepoURL).
+			Logger()
+
+		jiraClient, trunkClient, githubClient, _, err := processing.CreateClients(l, appConfig, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create clients: %w", err)
+		}
+		webhookProcessor := processing.NewWebhookProcessor(
+			l,
+			jiraClient,
+			trunkClient,
+			githubClient,
+			nil,
+			processing.WithQuarantineConfig(appConfig),
+		)
+		outcomes, err := webhookProcessor.Sync(
+			cmd.Context(),
+			syncRepoURL,
+			syncOrgURLSlug,
+			processing.WithBuildFlags(syncBuildFlags),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to sync quarantined tests: %w", err)
+		}
+
+		failed := 0
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PACKAGE\tTEST\tVARIANT\tTICKET\tOUTCOME\tERROR")
+		for _, outcome := range outcomes {
+			testCase := outcome.StatusChange.TestCase
+			errMessage := ""
+			if outcome.Err != nil {
+				failed++
+				errMessage = outcome.Err.Error()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				testCase.TestSuite, testCase.Name, testCase.Variant, outcome.JiraTicket, outcome.Outcome, errMessage)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		l.Info().Int("tests", len(outcomes)).Int("failed", failed).Msg("Synced quarantined tests")
+		if failed > 0 {
+			return fmt.Errorf("failed to sync %d of %d tests", failed, len(outcomes))
+		}
+		return nil
+	},
+}
+
+func init() {
+	root.AddCommand(syncCmd)
+
+	syncCmd.Flags().
+		StringVarP(&syncRepoURL, "repo", "r", "", "The repository URL (e.g. https://github.com/smartcontractkit/branch-out)")
+	syncCmd.Flags().
+		StringVar(&syncOrgURLSlug, "org-url-slug", "", "Trunk.io organization of the repository, its owner by default")
+	syncCmd.Flags().
+		StringArrayVar(&syncBuildFlags, "build-flags", nil, "Build flag to load packages with (e.g. -tags=e2e), can be repeated")
+	if err := syncCmd.MarkFlagRequired("repo"); err != nil {
+		panic(err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"runtime"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -41,6 +42,13 @@ type Config struct {
 	LogPath  string `mapstructure:"LOG_PATH"`
 	Port     int    `mapstructure:"PORT"`
 
+	QuarantineExpiryDays    int    `mapstructure:"QUARANTINE_EXPIRY_DAYS"`
+	QuarantineModuleVersion string `mapstructure:"QUARANTINE_MODULE_VERSION"`
+	QuarantineGenerator     string `mapstructure:"QUARANTINE_GENERATOR"`
+
+	SyncRepos         string `mapstructure:"SYNC_REPOS"`
+	SyncIntervalHours int    `mapstructure:"SYNC_INTERVAL_HOURS"`
+
 	// Secrets
 	GitHub    GitHub    `mapstructure:",squash"`
 	Trunk     Trunk     `mapstructure:",squash"`
@@ -176,6 +184,17 @@ func (c Config) GetSecrets() []string {
 	return secrets
 }
 
+// SyncRepoURLs returns the URLs of the repositories to sync quarantined tests from Trunk.io for, see SYNC_REPOS.
+func (c Config) SyncRepoURLs() []string {
+	var repoURLs []string
+	for _, repoURL := range strings.Split(c.SyncRepos, ",") {
+		if repoURL = strings.TrimSpace(repoURL); repoURL != "" {
+			repoURLs = append(repoURLs, repoURL)
+		}
+	}
+	return repoURLs
+}
+
 // MustLoad is Load but panics if there is an error.
 func MustLoad(options ...Option) Config {
 	cfg, err := Load(options...)
@@ -142,3 +142,13 @@ func TestLoad_Defaults(t *testing.T) {
 
 	assert.Equal(t, portField.Default, cfg.Port)
 }
+
+func TestConfig_SyncRepoURLs(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, Config{}.SyncRepoURLs())
+	assert.Equal(t,
+		[]string{"https://github.com/org/repo", "https://github.com/org/other-repo"},
+		Config{SyncRepos: " https://github.com/org/repo,, https://github.com/org/other-repo "}.SyncRepoURLs(),
+	)
+}
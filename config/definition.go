@@ -106,6 +106,50 @@ var (
 			Type:        reflect.TypeOf(""),
 			Persistent:  true,
 		},
+		{
+			EnvVar:      "QUARANTINE_EXPIRY_DAYS",
+			Description: "Number of days after which new quarantines expire and the tests run again, 0 to never expire",
+			Example:     30,
+			Flag:        "quarantine-expiry-days",
+			Type:        reflect.TypeOf(0),
+			Default:     0,
+			Persistent:  true,
+		},
+		{
+			EnvVar:      "QUARANTINE_MODULE_VERSION",
+			Description: "Quarantine module version that repositories are updated to require, branch-out's own by default",
+			Example:     "v0.1.0",
+			Flag:        "quarantine-module-version",
+			Type:        reflect.TypeOf(""),
+			Default:     "",
+			Persistent:  true,
+		},
+		{
+			EnvVar: "QUARANTINE_GENERATOR",
+			Description: "Code that quarantines tests: flaky for quarantine.Flaky, inline-skip for t.Skip guarded by " +
+				"RUN_QUARANTINED_TESTS, or the import path and name of a function with quarantine.Flaky's signature",
+			Example:    "github.com/org/repo/testutil.Quarantine",
+			Flag:       "quarantine-generator",
+			Type:       reflect.TypeOf(""),
+			Default:    "flaky",
+			Persistent: true,
+		},
+		{
+			EnvVar:      "SYNC_REPOS",
+			Description: "Comma-separated URLs of the repositories to sync quarantined tests from Trunk.io for on a schedule",
+			Example:     "https://github.com/org/repo,https://github.com/org/other-repo",
+			Flag:        "sync-repos",
+			Type:        reflect.TypeOf(""),
+			Default:     "",
+		},
+		{
+			EnvVar:      "SYNC_INTERVAL_HOURS",
+			Description: "Number of hours between syncs of the SYNC_REPOS repositories, 0 to never sync them",
+			Example:     24,
+			Flag:        "sync-interval-hours",
+			Type:        reflect.TypeOf(0),
+			Default:     0,
+		},
 	}
 
 	githubFields = []Field{
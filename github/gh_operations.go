@@ -18,13 +18,18 @@ import (
 )
 
 // GetBranchNames retrieves the default branch and a deterministic PR branch name based on the current date.
-func (c *Client) GetBranchNames(ctx context.Context, owner, repo string) (string, string, error) {
+// Un-quarantining tests uses a separate PR branch so that it doesn't get mixed up with quarantining tests.
+func (c *Client) GetBranchNames(ctx context.Context, owner, repo string, unquarantine bool) (string, string, error) {
 	defaultBranch, err := c.getDefaultBranch(ctx, owner, repo)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get default branch: %w", err)
 	}
 	// Use deterministic branch name based on date
-	prBranch := fmt.Sprintf("branch-out/quarantine-tests-%s", time.Now().Format("2006-01-02"))
+	action := "quarantine"
+	if unquarantine {
+		action = "unquarantine"
+	}
+	prBranch := fmt.Sprintf("branch-out/%s-tests-%s", action, time.Now().Format("2006-01-02"))
 
 	return defaultBranch, prBranch, nil
 }
@@ -56,21 +61,55 @@ func (c *Client) GetOrCreateRemoteBranch(ctx context.Context, owner, repo, branc
 	return branchHeadSHA, nil
 }
 
-// GenerateCommitAndPush creates a commit with the quarantined tests and pushes it to the PR branch
+// GenerateCommitAndPush creates a commit with the quarantined (or un-quarantined) tests and pushes it to the PR branch
 func (c *Client) GenerateCommitAndPush(
 	ctx context.Context,
 	owner, repo, prBranch, branchHeadSHA string,
 	results *golang.QuarantineResults) (string, error) {
 	var commitMessage = strings.Builder{}
-	commitMessage.WriteString("branch-out quarantine tests\n")
+	if results.Unquarantine() {
+		commitMessage.WriteString("branch-out un-quarantine tests\n")
+	} else {
+		commitMessage.WriteString("branch-out quarantine tests\n")
+	}
 
-	allFileUpdates := make(map[string]string)
+	var (
+		allFileUpdates     = make(map[string]string)
+		allFileDeletions   []string
+		alreadyQuarantined []string
+	)
 	for _, result := range *results {
-		// Process successes
+		// Process successes, only committing files that actually changed
 		for _, file := range result.Successes {
-			commitMessage.WriteString(fmt.Sprintf("%s: %s\n", file.File, strings.Join(file.TestNames(), ", ")))
+			for _, test := range file.AlreadyQuarantinedTests() {
+				alreadyQuarantined = append(alreadyQuarantined, fmt.Sprintf("%s: %s", file.File, test.Name))
+			}
+			if !file.Modified() {
+				continue
+			}
+
+			var testNames []string
+			for _, test := range file.ModifiedTests() {
+				testNames = append(testNames, test.Name)
+			}
+			commitMessage.WriteString(fmt.Sprintf("%s: %s\n", file.File, strings.Join(testNames, ", ")))
+			if file.Deleted {
+				allFileDeletions = append(allFileDeletions, file.File)
+				continue
+			}
 			allFileUpdates[file.File] = file.ModifiedSourceCode
 		}
+		// Commit go.mod and go.sum updates too, the quarantined tests don't build without them
+		for _, file := range result.ModuleFiles {
+			if file.Modified() {
+				allFileUpdates[file.File] = file.ModifiedSourceCode
+			}
+		}
+	}
+	if len(alreadyQuarantined) > 0 {
+		commitMessage.WriteString("\nAlready quarantined:\n")
+		commitMessage.WriteString(strings.Join(alreadyQuarantined, "\n"))
+		commitMessage.WriteString("\n")
 	}
 
 	// Update files on the branch
@@ -82,6 +121,7 @@ func (c *Client) GenerateCommitAndPush(
 		commitMessage.String(),
 		branchHeadSHA,
 		allFileUpdates,
+		allFileDeletions,
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to create commit: %w", err)
@@ -90,13 +130,16 @@ func (c *Client) GenerateCommitAndPush(
 	return sha, nil
 }
 
-// CreateOrUpdatePullRequest creates a new pull request or updates an existing one with the quarantined tests
+// CreateOrUpdatePullRequest creates a new pull request or updates an existing one with the quarantined (or un-quarantined) tests
 func (c *Client) CreateOrUpdatePullRequest(
 	ctx context.Context, l zerolog.Logger,
 	owner, repo, prBranch, defaultBranch string,
 	results *golang.QuarantineResults,
 ) (string, error) {
 	title := fmt.Sprintf("[Auto] [branch-out] Quarantine Flaky Tests: %s", time.Now().Format("2006-01-02"))
+	if results.Unquarantine() {
+		title = fmt.Sprintf("[Auto] [branch-out] Un-quarantine Recovered Tests: %s", time.Now().Format("2006-01-02"))
+	}
 	prBody := results.Markdown(owner, repo, prBranch)
 
 	existingPR, err := c.findExistingPR(ctx, owner, repo, prBranch, defaultBranch)
@@ -156,11 +199,12 @@ func (c *Client) getBranchHeadSHA(ctx context.Context, owner, repo, branchName s
 	return sha, true, nil
 }
 
-// createCommitOnBranch creates a commit on a specific branch with the given files.
+// createCommitOnBranch creates a commit on a specific branch with the given files, and without the deleted ones.
 func (c *Client) createCommitOnBranch(
 	ctx context.Context,
 	owner, repo, branchName, commitMessage, expectedHeadOid string,
 	files map[string]string,
+	deletions []string,
 ) (string, error) {
 	// process added / modified files:
 	additions := make([]gh_graphql.FileAddition, 0, len(files))
@@ -174,6 +218,11 @@ func (c *Client) createCommitOnBranch(
 			Contents: gh_graphql.Base64String(enc),
 		})
 	}
+	// process deleted files:
+	fileDeletions := make([]gh_graphql.FileDeletion, 0, len(deletions))
+	for _, file := range deletions {
+		fileDeletions = append(fileDeletions, gh_graphql.FileDeletion{Path: gh_graphql.String(file)})
+	}
 	// the actual mutation request
 	var m struct {
 		CreateCommitOnBranch struct {
@@ -200,6 +249,9 @@ func (c *Client) createCommitOnBranch(
 		},
 		ExpectedHeadOid: gh_graphql.GitObjectID(expectedHeadOid),
 	}
+	if len(fileDeletions) > 0 {
+		input.FileChanges.Deletions = &fileDeletions
+	}
 
 	err := c.GraphQL.Mutate(ctx, &m, input, nil)
 	if err != nil {
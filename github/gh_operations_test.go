@@ -33,12 +33,14 @@ func TestGetBranchNames(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name          string
-		owner         string
-		repo          string
-		defaultBranch string
-		mockOptions   []mock.MockBackendOption
-		expectedError string
+		name             string
+		owner            string
+		repo             string
+		unquarantine     bool
+		defaultBranch    string
+		mockOptions      []mock.MockBackendOption
+		expectedPRPrefix string
+		expectedError    string
 	}{
 		{
 			name:          "successful branch names retrieval",
@@ -53,6 +55,23 @@ func TestGetBranchNames(t *testing.T) {
 					},
 				),
 			},
+			expectedPRPrefix: "branch-out/quarantine-tests",
+		},
+		{
+			name:          "unquarantine branch names retrieval",
+			owner:         "testowner",
+			repo:          "testrepo",
+			unquarantine:  true,
+			defaultBranch: "main",
+			mockOptions: []mock.MockBackendOption{
+				mock.WithRequestMatch(
+					mock.GetReposByOwnerByRepo,
+					github.Repository{
+						DefaultBranch: github.Ptr("main"),
+					},
+				),
+			},
+			expectedPRPrefix: "branch-out/unquarantine-tests",
 		},
 		{
 			name:  "repository not found",
@@ -81,7 +100,7 @@ func TestGetBranchNames(t *testing.T) {
 			client := createTestClient(tt.mockOptions...)
 
 			ctx := context.Background()
-			defaultBranch, prBranch, err := client.GetBranchNames(ctx, tt.owner, tt.repo)
+			defaultBranch, prBranch, err := client.GetBranchNames(ctx, tt.owner, tt.repo, tt.unquarantine)
 
 			if tt.expectedError != "" {
 				require.Error(t, err)
@@ -93,7 +112,7 @@ func TestGetBranchNames(t *testing.T) {
 			assert.Equal(t, tt.defaultBranch, defaultBranch)
 
 			// Check that the PR branch follows the expected format
-			expectedPRName := fmt.Sprintf("branch-out/quarantine-tests-%s", time.Now().Format("2006-01-02"))
+			expectedPRName := fmt.Sprintf("%s-%s", tt.expectedPRPrefix, time.Now().Format("2006-01-02"))
 			assert.Equal(t, expectedPRName, prBranch)
 		})
 	}
@@ -0,0 +1,277 @@
+package golang
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+const (
+	// AnalyzerName is the name of the analyzer returned by NewAnalyzer.
+	AnalyzerName = "quarantinelint"
+
+	// defaultTicketPattern matches ticket keys, e.g. JIRA-123, like the quarantine package does when selecting tests.
+	defaultTicketPattern = quarantine.TicketPattern
+	// defaultClosedStatuses are the statuses of tickets whose tests should no longer be quarantined.
+	defaultClosedStatuses = "Closed,Done,Resolved"
+)
+
+// AnalyzerOption configures the analyzer returned by NewAnalyzer. Every option can also be set with a flag of the
+// analyzer, e.g. -ticket-pattern when running it with go vet -vettool.
+type AnalyzerOption func(*analyzerOptions)
+
+// analyzerOptions describes the options of the analyzer.
+type analyzerOptions struct {
+	ticketPattern      string // Regex tickets have to match
+	ticketStatusesPath string // Path of the ticket status cache, see WithTicketStatuses
+	closedStatuses     string // Comma-separated statuses of closed tickets
+}
+
+// WithTicketPattern sets the regex the tickets of quarantined tests have to match.
+// Defaults to Jira issue keys, e.g. JIRA-123.
+func WithTicketPattern(pattern string) AnalyzerOption {
+	return func(o *analyzerOptions) {
+		o.ticketPattern = pattern
+	}
+}
+
+// WithTicketStatuses sets the path of a JSON file caching the status of tickets, e.g. {"JIRA-123": "Done"}.
+// Tests quarantined for tickets that are closed are reported, see WithClosedStatuses.
+// Ticket statuses aren't checked by default.
+func WithTicketStatuses(path string) AnalyzerOption {
+	return func(o *analyzerOptions) {
+		o.ticketStatusesPath = path
+	}
+}
+
+// WithClosedStatuses sets the statuses of closed tickets, compared regardless of case.
+// Defaults to Closed, Done and Resolved.
+func WithClosedStatuses(statuses ...string) AnalyzerOption {
+	return func(o *analyzerOptions) {
+		o.closedStatuses = strings.Join(statuses, ",")
+	}
+}
+
+// NewAnalyzer returns a go/analysis analyzer checking that tests are quarantined in a way branch-out understands.
+// It recognizes quarantine.Flaky and quarantine.FlakySuite calls the same way quarantining and un-quarantining does,
+// and reports calls:
+//
+//   - with an empty ticket, or one that doesn't match the ticket pattern, see WithTicketPattern.
+//   - with a ticket that isn't a string literal, which branch-out can't update.
+//   - that quarantine the same test, subtest and variant more than once.
+//   - that aren't at the top of the test, before anything but t.Parallel() and other quarantines.
+//   - with a closed ticket, if a ticket status cache is set, see WithTicketStatuses.
+//
+// Run it with go vet -vettool, or as a golangci-lint plugin, see cmd/quarantinelint.
+func NewAnalyzer(options ...AnalyzerOption) *analysis.Analyzer {
+	opts := &analyzerOptions{
+		ticketPattern:  defaultTicketPattern,
+		closedStatuses: defaultClosedStatuses,
+	}
+	for _, option := range options {
+		option(opts)
+	}
+
+	analyzer := &analysis.Analyzer{
+		Name: AnalyzerName,
+		Doc:  "check that tests are quarantined with quarantine.Flaky in a way branch-out can maintain",
+		URL:  "https://github.com/smartcontractkit/branch-out",
+		Run: func(pass *analysis.Pass) (any, error) {
+			return nil, opts.run(pass)
+		},
+	}
+	analyzer.Flags.StringVar(&opts.ticketPattern, "ticket-pattern", opts.ticketPattern,
+		"regex the tickets of quarantined tests have to match")
+	analyzer.Flags.StringVar(&opts.ticketStatusesPath, "ticket-statuses", opts.ticketStatusesPath,
+		`path of a JSON file with the status of tickets, e.g. {"JIRA-123": "Done"}, to report closed tickets`)
+	analyzer.Flags.StringVar(&opts.closedStatuses, "closed-statuses", opts.closedStatuses,
+		"comma-separated statuses of closed tickets")
+	return analyzer
+}
+
+// quarantineCheck holds what a single run of the analyzer checks quarantine calls against.
+type quarantineCheck struct {
+	pass           *analysis.Pass
+	ticketPattern  *regexp.Regexp
+	ticketStatuses map[string]string
+	closedStatuses []string
+}
+
+// run checks the quarantine calls of every file of the package.
+func (o *analyzerOptions) run(pass *analysis.Pass) error {
+	ticketPattern, err := regexp.Compile(o.ticketPattern)
+	if err != nil {
+		return fmt.Errorf("invalid ticket pattern: %w", err)
+	}
+	check := quarantineCheck{
+		pass:           pass,
+		ticketPattern:  ticketPattern,
+		closedStatuses: strings.Split(o.closedStatuses, ","),
+	}
+	if o.ticketStatusesPath != "" {
+		check.ticketStatuses, err = readTicketStatuses(o.ticketStatusesPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, file := range pass.Files {
+		importName := importLocalName(file, quarantineImportPath)
+		if importName == "" || importName == "_" || importName == "." {
+			continue
+		}
+		check.file(file, importName)
+	}
+	return nil
+}
+
+// readTicketStatuses reads the ticket status cache, see WithTicketStatuses.
+func readTicketStatuses(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ticket statuses: %w", err)
+	}
+	var statuses map[string]string
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil, fmt.Errorf("failed to parse ticket statuses %s: %w", path, err)
+	}
+	return statuses, nil
+}
+
+// file checks the quarantine calls of a file. Calls at the top of a function body are checked with the rest of the
+// body, see body, and any other call is misplaced.
+func (c quarantineCheck) file(file *ast.File, importName string) {
+	checked := make(map[*ast.CallExpr]bool)
+	ast.Inspect(file, func(node ast.Node) bool {
+		var body *ast.BlockStmt
+		switch fn := node.(type) {
+		case *ast.FuncDecl:
+			body = fn.Body
+		case *ast.FuncLit:
+			body = fn.Body
+		}
+		if body != nil {
+			c.body(body, importName, checked)
+		}
+		return true
+	})
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		stmt, ok := node.(ast.Stmt)
+		if !ok {
+			return true
+		}
+		if call, ok := quarantineCall(stmt, importName); ok && !checked[call] {
+			c.ticket(call)
+			c.misplaced(call)
+		}
+		return true
+	})
+}
+
+// body checks the quarantine calls among the top level statements of a function body, adding them to checked.
+// Quarantines may be guarded by a single if statement, like quarantines of table test entries are,
+// e.g. if tc.name == "subtest 1" { quarantine.Flaky(t, "JIRA-123") }.
+func (c quarantineCheck) body(body *ast.BlockStmt, importName string, checked map[*ast.CallExpr]bool) {
+	var (
+		atTop = true
+		lines = make(map[string]int) // Line of the first quarantine of each test, subtest and variant
+	)
+	for _, stmt := range body.List {
+		call, guard, ok := guardedQuarantineCall(stmt, importName)
+		if !ok {
+			atTop = atTop && isParallelCall(stmt)
+			continue
+		}
+		checked[call] = true
+		c.ticket(call)
+
+		if !atTop {
+			c.misplaced(call)
+		}
+		key := strings.Join([]string{
+			guard,
+			types.ExprString(call.Args[0]),
+			ifNamedArg(call, importName),
+			optionArg(call, importName, "Variant"),
+		}, "\x00")
+		line := c.pass.Fset.Position(call.Pos()).Line
+		if firstLine, ok := lines[key]; ok {
+			c.pass.Reportf(call.Pos(),
+				"%s quarantines a test that is already quarantined on line %d", types.ExprString(call.Fun), firstLine)
+			continue
+		}
+		lines[key] = line
+	}
+}
+
+// ticket checks the ticket a quarantine call quarantines the test for.
+func (c quarantineCheck) ticket(call *ast.CallExpr) {
+	name := types.ExprString(call.Fun)
+	ticket, ticketExpr := quarantineTicket(call)
+	switch {
+	case ticketExpr == nil:
+		return // Doesn't compile
+	case ticket == "":
+		if _, ok := stringLiteral(ticketExpr); ok {
+			c.pass.Reportf(ticketExpr.Pos(), "%s is called with an empty ticket", name)
+			return
+		}
+		c.pass.Reportf(ticketExpr.Pos(), "ticket of %s should be a string literal, so that branch-out can update it", name)
+	case !c.ticketPattern.MatchString(ticket):
+		c.pass.Reportf(ticketExpr.Pos(), "ticket %q of %s doesn't match %s", ticket, name, c.ticketPattern)
+	default:
+		status, ok := c.ticketStatuses[ticket]
+		if !ok {
+			return
+		}
+		for _, closed := range c.closedStatuses {
+			if strings.EqualFold(strings.TrimSpace(closed), status) {
+				c.pass.Reportf(ticketExpr.Pos(), "ticket %s of %s is %s, un-quarantine the test", ticket, name, status)
+				return
+			}
+		}
+	}
+}
+
+// misplaced reports a quarantine call that isn't at the top of the test, where branch-out doesn't look for it.
+func (c quarantineCheck) misplaced(call *ast.CallExpr) {
+	c.pass.Reportf(call.Pos(),
+		"%s should be called at the top of the test, before anything but t.Parallel() and other quarantines",
+		types.ExprString(call.Fun))
+}
+
+// guardedQuarantineCall returns the quarantine.Flaky() or quarantine.FlakySuite() call if the statement is one,
+// or a plain if statement with only one in its body. guard is the condition of the if statement, if any.
+func guardedQuarantineCall(stmt ast.Stmt, importName string) (call *ast.CallExpr, guard string, ok bool) {
+	if ifStmt, isIf := singleStmtIf(stmt); isIf {
+		stmt, guard = ifStmt.Body.List[0], types.ExprString(ifStmt.Cond)
+	}
+	call, ok = quarantineCall(stmt, importName)
+	if !ok || len(call.Args) == 0 {
+		return nil, "", false
+	}
+	return call, guard, true
+}
+
+// isParallelCall checks if the statement is a call to Parallel(), e.g. t.Parallel().
+func isParallelCall(stmt ast.Stmt) bool {
+	exprStmt, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return false
+	}
+	call, ok := exprStmt.X.(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && selector.Sel.Name == "Parallel"
+}
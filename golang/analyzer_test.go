@@ -0,0 +1,21 @@
+package golang
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	t.Parallel()
+
+	analysistest.Run(t, analysistest.TestData(), NewAnalyzer(), "quarantinelint")
+}
+
+func TestAnalyzer_TicketStatuses(t *testing.T) {
+	t.Parallel()
+
+	analyzer := NewAnalyzer(WithTicketStatuses(filepath.Join(analysistest.TestData(), "ticket_statuses.json")))
+	analysistest.Run(t, analysistest.TestData(), analyzer, "closedtickets")
+}
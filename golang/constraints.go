@@ -0,0 +1,356 @@
+package golang
+
+import (
+	"cmp"
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// maxConstraintTags is the most custom build tags in a single constraint we try every combination of.
+// Past that, we only try setting none or all of them.
+const maxConstraintTags = 8
+
+var (
+	// knownOS are the valid GOOS values, mirroring go/build.
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true,
+		"illumos": true, "ios": true, "js": true, "linux": true, "nacl": true, "netbsd": true, "openbsd": true,
+		"plan9": true, "solaris": true, "wasip1": true, "windows": true, "zos": true,
+	}
+	// unixOS are the GOOS values that satisfy the "unix" build tag.
+	unixOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true,
+		"illumos": true, "ios": true, "linux": true, "netbsd": true, "openbsd": true, "solaris": true,
+	}
+	// knownArch are the valid GOARCH values, mirroring go/build.
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true, "arm64be": true,
+		"loong64": true, "mips": true, "mipsle": true, "mips64": true, "mips64le": true, "mips64p32": true,
+		"mips64p32le": true, "ppc": true, "ppc64": true, "ppc64le": true, "riscv": true, "riscv64": true,
+		"s390": true, "s390x": true, "sparc": true, "sparc64": true, "wasm": true,
+	}
+	// osDefaultArch is the GOARCH to use for operating systems that don't support the host's GOARCH.
+	osDefaultArch = map[string]string{
+		"aix":     "ppc64",
+		"android": "arm64",
+		"ios":     "arm64",
+		"js":      "wasm",
+		"wasip1":  "wasm",
+		"zos":     "s390x",
+	}
+)
+
+// buildConfig is a combination of GOOS, GOARCH and build tags to load packages with.
+type buildConfig struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string // Build tags to set on top of the ones in the build flags
+}
+
+// String returns a short description of the build config for logging, e.g. "linux/amd64 [integration]".
+func (b buildConfig) String() string {
+	return b.GOOS + "/" + b.GOARCH + " [" + strings.Join(b.Tags, ",") + "]"
+}
+
+// buildFlags returns the build flags to load packages with, merging the config's tags into any -tags flag.
+func (b buildConfig) buildFlags(baseFlags []string) []string {
+	if len(b.Tags) == 0 {
+		return baseFlags
+	}
+	flags, tags := splitTagsFlag(baseFlags)
+	return append(flags, "-tags="+strings.Join(appendUnique(tags, b.Tags), ","))
+}
+
+// env returns the environment to load packages with.
+func (b buildConfig) env() []string {
+	return append(os.Environ(), "GOOS="+b.GOOS, "GOARCH="+b.GOARCH)
+}
+
+// splitTagsFlag separates the build tags set by -tags flags from the rest of the build flags.
+func splitTagsFlag(buildFlags []string) (flags, tags []string) {
+	flags = make([]string, 0, len(buildFlags))
+	for i := 0; i < len(buildFlags); i++ {
+		flag := strings.TrimPrefix(buildFlags[i], "-")
+		var value string
+		switch {
+		case flag == "-tags" || flag == "tags":
+			if i+1 < len(buildFlags) {
+				i++
+				value = buildFlags[i]
+			}
+		case strings.HasPrefix(flag, "-tags=") || strings.HasPrefix(flag, "tags="):
+			_, value, _ = strings.Cut(flag, "=")
+		default:
+			flags = append(flags, buildFlags[i])
+			continue
+		}
+		tags = appendUnique(tags, strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }))
+	}
+	return flags, tags
+}
+
+// extraBuildConfigs scans the test files of a module for build constraints, and returns the combinations of GOOS,
+// GOARCH and build tags that the packages need to be loaded with, on top of the default one, to see every test file.
+// Test files that are already visible with the host's GOOS and GOARCH and the given build flags don't need any.
+func extraBuildConfigs(l zerolog.Logger, moduleDir string, buildFlags []string) []buildConfig {
+	var testFiles []string
+	err := filepath.WalkDir(moduleDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			name := entry.Name()
+			if path != moduleDir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+				name == "vendor" || name == "testdata" || isModuleRoot(path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, "_test.go") {
+			testFiles = append(testFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		l.Warn().Err(err).Str("module_dir", moduleDir).Msg("Failed to scan test files for build constraints")
+	}
+	return testFileBuildConfigs(l, testFiles, buildFlags)
+}
+
+// packageBuildConfigs is like extraBuildConfigs, but only scans the test files directly inside the package directories.
+func packageBuildConfigs(l zerolog.Logger, packageDirs []string, buildFlags []string) []buildConfig {
+	var testFiles []string
+	for _, dir := range packageDirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			l.Warn().Err(err).Str("package_dir", dir).Msg("Failed to scan test files for build constraints")
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), "_test.go") {
+				testFiles = append(testFiles, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	return testFileBuildConfigs(l, testFiles, buildFlags)
+}
+
+// testFileBuildConfigs returns the build configs, other than the default one, needed to see the test files.
+func testFileBuildConfigs(l zerolog.Logger, testFiles []string, buildFlags []string) []buildConfig {
+	_, flagTags := splitTagsFlag(buildFlags)
+	var (
+		host    = buildConfig{GOOS: build.Default.GOOS, GOARCH: build.Default.GOARCH}
+		seen    = make(map[string]bool)
+		configs []buildConfig
+	)
+
+	for _, path := range testFiles {
+		config, ok := fileBuildConfig(path, host, flagTags)
+		if !ok {
+			l.Debug().Str("file", path).Msg("No combination of build tags, GOOS and GOARCH satisfies the test file")
+			continue
+		}
+		if config.GOOS == host.GOOS && config.GOARCH == host.GOARCH && len(config.Tags) == 0 {
+			continue
+		}
+		if key := config.String(); !seen[key] {
+			seen[key] = true
+			configs = append(configs, config)
+		}
+	}
+
+	// Sort the configs so that loading is deterministic
+	slices.SortFunc(configs, func(a, b buildConfig) int { return cmp.Compare(a.String(), b.String()) })
+	return configs
+}
+
+// isModuleRoot checks if the directory contains a go.mod file, i.e. is the root of a nested module.
+func isModuleRoot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "go.mod"))
+	return err == nil
+}
+
+// fileBuildConfig finds a build config that includes the file, based on its //go:build constraint and its name,
+// e.g. foo_linux_test.go. The host's GOOS and GOARCH and the fewest possible tags are preferred.
+// setTags are the tags that are always set by the build flags.
+func fileBuildConfig(path string, host buildConfig, setTags []string) (buildConfig, bool) {
+	expr, err := fileConstraint(path)
+	if err != nil {
+		return buildConfig{}, false
+	}
+
+	var (
+		fileOS, fileArch = fileNameOSArch(filepath.Base(path))
+		candidateOS      = []string{host.GOOS}
+		candidateArch    = []string{host.GOARCH}
+		customTags       []string
+	)
+	if fileOS != "" {
+		candidateOS = []string{fileOS}
+	}
+	if fileArch != "" {
+		candidateArch = []string{fileArch}
+	}
+	if expr != nil {
+		for _, tag := range constraintTags(expr) {
+			switch {
+			case knownOS[tag]:
+				if fileOS == "" && !slices.Contains(candidateOS, tag) {
+					candidateOS = append(candidateOS, tag)
+				}
+			case knownArch[tag]:
+				if fileArch == "" && !slices.Contains(candidateArch, tag) {
+					candidateArch = append(candidateArch, tag)
+				}
+			case tag == "unix":
+				if fileOS == "" && !slices.Contains(candidateOS, "linux") {
+					candidateOS = append(candidateOS, "linux")
+				}
+			case isPredefinedTag(tag) || slices.Contains(setTags, tag):
+			default:
+				customTags = append(customTags, tag)
+			}
+		}
+	}
+
+	tagSets := tagCombinations(customTags)
+	for _, goos := range candidateOS {
+		for _, goarch := range candidateArch {
+			if fileArch == "" && goarch == host.GOARCH && goos != host.GOOS && osDefaultArch[goos] != "" {
+				goarch = osDefaultArch[goos]
+			}
+			for _, tags := range tagSets {
+				if expr != nil && !expr.Eval(func(tag string) bool {
+					return tag == goos || tag == goarch || (tag == "unix" && unixOS[goos]) ||
+						isPredefinedTag(tag) || slices.Contains(setTags, tag) || slices.Contains(tags, tag)
+				}) {
+					continue
+				}
+				return buildConfig{GOOS: goos, GOARCH: goarch, Tags: tags}, true
+			}
+		}
+	}
+	return buildConfig{}, false
+}
+
+// fileConstraint returns the build constraint of a Go file, or nil if it has none.
+func fileConstraint(path string) (constraint.Expr, error) {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, path, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	return nodeConstraint(node)
+}
+
+// nodeConstraint returns the build constraint of a parsed Go file, or nil if it has none.
+func nodeConstraint(node *ast.File) (constraint.Expr, error) {
+	var plusBuild constraint.Expr
+	for _, group := range node.Comments {
+		if group.Pos() > node.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if constraint.IsGoBuild(comment.Text) {
+				return constraint.Parse(comment.Text) // //go:build takes precedence over // +build
+			}
+			if !constraint.IsPlusBuild(comment.Text) {
+				continue
+			}
+			expr, err := constraint.Parse(comment.Text)
+			if err != nil {
+				return nil, err
+			}
+			if plusBuild == nil {
+				plusBuild = expr
+			} else {
+				plusBuild = &constraint.AndExpr{X: plusBuild, Y: expr}
+			}
+		}
+	}
+	return plusBuild, nil
+}
+
+// isConstrained checks if the test file is only built in some configurations, because of its build constraint or
+// a GOOS/GOARCH suffix in its name, e.g. foo_windows_test.go.
+func isConstrained(testFile parsedTestFile) bool {
+	if goos, goarch := fileNameOSArch(filepath.Base(testFile.Path)); goos != "" || goarch != "" {
+		return true
+	}
+	expr, err := nodeConstraint(testFile.Node)
+	return err != nil || expr != nil
+}
+
+// constraintTags returns all the tags used in a build constraint.
+func constraintTags(expr constraint.Expr) []string {
+	switch e := expr.(type) {
+	case *constraint.TagExpr:
+		return []string{e.Tag}
+	case *constraint.NotExpr:
+		return constraintTags(e.X)
+	case *constraint.AndExpr:
+		return appendUnique(constraintTags(e.X), constraintTags(e.Y))
+	case *constraint.OrExpr:
+		return appendUnique(constraintTags(e.X), constraintTags(e.Y))
+	default:
+		return nil
+	}
+}
+
+// isPredefinedTag checks if the tag is set by the go tool itself, e.g. go1.21, gc or cgo.
+func isPredefinedTag(tag string) bool {
+	return slices.Contains(build.Default.ReleaseTags, tag) || slices.Contains(build.Default.ToolTags, tag) ||
+		tag == build.Default.Compiler || (tag == "cgo" && build.Default.CgoEnabled)
+}
+
+// tagCombinations returns the combinations of the tags to try, with the fewest tags first.
+func tagCombinations(tags []string) [][]string {
+	if len(tags) > maxConstraintTags {
+		return [][]string{nil, tags}
+	}
+	combinations := make([][]string, 0, 1<<len(tags))
+	for mask := range 1 << len(tags) {
+		var combination []string
+		for i, tag := range tags {
+			if mask&(1<<i) != 0 {
+				combination = append(combination, tag)
+			}
+		}
+		slices.Sort(combination)
+		combinations = append(combinations, combination)
+	}
+	slices.SortStableFunc(combinations, func(a, b []string) int { return len(a) - len(b) })
+	return combinations
+}
+
+// fileNameOSArch returns the GOOS and GOARCH a file is restricted to by its name, e.g. foo_linux_amd64_test.go,
+// following the same rules as the go tool.
+func fileNameOSArch(name string) (goos, goarch string) {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".go"), "_test")
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return "", ""
+	}
+	elements := strings.Split(name[i:], "_")
+	n := len(elements)
+	if n >= 2 && knownOS[elements[n-2]] && knownArch[elements[n-1]] {
+		return elements[n-2], elements[n-1]
+	}
+	if knownOS[elements[n-1]] {
+		return elements[n-1], ""
+	}
+	if knownArch[elements[n-1]] {
+		return "", elements[n-1]
+	}
+	return "", ""
+}
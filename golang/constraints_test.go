@@ -0,0 +1,143 @@
+package golang
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBuildConfig(t *testing.T) {
+	t.Parallel()
+
+	host := buildConfig{GOOS: "linux", GOARCH: "amd64"}
+	tests := []struct {
+		name     string
+		fileName string
+		header   string
+		setTags  []string
+		expected buildConfig
+		ok       bool
+	}{
+		{
+			name:     "no constraints",
+			fileName: "a_test.go",
+			expected: host,
+			ok:       true,
+		},
+		{
+			name:     "custom tag",
+			fileName: "a_test.go",
+			header:   "//go:build integration",
+			expected: buildConfig{GOOS: "linux", GOARCH: "amd64", Tags: []string{"integration"}},
+			ok:       true,
+		},
+		{
+			name:     "custom tag set by build flags",
+			fileName: "a_test.go",
+			header:   "//go:build integration",
+			setTags:  []string{"integration"},
+			expected: host,
+			ok:       true,
+		},
+		{
+			name:     "negated tag",
+			fileName: "a_test.go",
+			header:   "//go:build !integration",
+			expected: host,
+			ok:       true,
+		},
+		{
+			name:     "fewest tags",
+			fileName: "a_test.go",
+			header:   "//go:build (a && b) || c",
+			expected: buildConfig{GOOS: "linux", GOARCH: "amd64", Tags: []string{"c"}},
+			ok:       true,
+		},
+		{
+			name:     "file name GOOS",
+			fileName: "a_windows_test.go",
+			expected: buildConfig{GOOS: "windows", GOARCH: "amd64"},
+			ok:       true,
+		},
+		{
+			name:     "file name GOOS and GOARCH",
+			fileName: "a_darwin_arm64_test.go",
+			expected: buildConfig{GOOS: "darwin", GOARCH: "arm64"},
+			ok:       true,
+		},
+		{
+			name:     "GOOS only in name",
+			fileName: "windows_test.go",
+			expected: host,
+			ok:       true,
+		},
+		{
+			name:     "GOOS constraint",
+			fileName: "a_test.go",
+			header:   "//go:build windows && e2e",
+			expected: buildConfig{GOOS: "windows", GOARCH: "amd64", Tags: []string{"e2e"}},
+			ok:       true,
+		},
+		{
+			name:     "GOOS without host GOARCH",
+			fileName: "a_test.go",
+			header:   "//go:build js",
+			expected: buildConfig{GOOS: "js", GOARCH: "wasm"},
+			ok:       true,
+		},
+		{
+			name:     "unix",
+			fileName: "a_test.go",
+			header:   "//go:build unix",
+			expected: host,
+			ok:       true,
+		},
+		{
+			name:     "old style constraint",
+			fileName: "a_test.go",
+			header:   "// +build legacy",
+			expected: buildConfig{GOOS: "linux", GOARCH: "amd64", Tags: []string{"legacy"}},
+			ok:       true,
+		},
+		{
+			name:     "impossible",
+			fileName: "a_windows_test.go",
+			header:   "//go:build linux",
+			ok:       false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), test.fileName)
+			source := "package example\n"
+			if test.header != "" {
+				source = test.header + "\n\n" + source
+			}
+			require.NoError(t, os.WriteFile(path, []byte(source), 0600))
+
+			config, ok := fileBuildConfig(path, host, test.setTags)
+			require.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, config)
+		})
+	}
+}
+
+func TestBuildConfig_BuildFlags(t *testing.T) {
+	t.Parallel()
+
+	config := buildConfig{GOOS: "linux", GOARCH: "amd64", Tags: []string{"integration", "example"}}
+	assert.Equal(t, []string{"-race"}, buildConfig{}.buildFlags([]string{"-race"}))
+	assert.Equal(t, []string{"-tags=integration,example"}, config.buildFlags(nil))
+	assert.Equal(
+		t,
+		[]string{"-race", "-tags=example,other,integration"},
+		config.buildFlags([]string{"-tags", "example,other", "-race"}),
+	)
+	assert.Equal(t, []string{"-tags=a,b,integration,example"}, config.buildFlags([]string{"--tags=a b"}))
+}
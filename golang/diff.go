@@ -0,0 +1,115 @@
+package golang
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	// devNull is the name diffs give the missing side of a created or deleted file.
+	devNull = "/dev/null"
+	// noNewlineMarker follows the last line of a side of a diff that doesn't end with a newline.
+	noNewlineMarker = "\\ No newline at end of file\n"
+	// diffContextLines is the number of unchanged lines shown around each change in a diff.
+	diffContextLines = 3
+	// maxMarkdownDiffLength caps the size of the diff in the Markdown output, as GitHub limits PR descriptions to
+	// 65536 characters.
+	maxMarkdownDiffLength = 40_000
+)
+
+// Diff returns a unified diff of the changes made to the file, against its original source code.
+// Created and deleted files are diffed against /dev/null.
+// Returns the empty string if the file was not modified.
+func (q QuarantinedFile) Diff() (string, error) {
+	if !q.Modified() {
+		return "", nil
+	}
+
+	fromFile, toFile := "a/"+q.File, "b/"+q.File
+	if q.Created {
+		fromFile = devNull
+	}
+	if q.Deleted {
+		toFile = devNull
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(q.OriginalSourceCode),
+		B:        splitLines(q.ModifiedSourceCode),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  diffContextLines,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to diff %s: %w", q.File, err)
+	}
+	return diff, nil
+}
+
+// splitLines splits source code into lines, keeping their line endings.
+// If the source doesn't end with a newline, its last line is followed by the marker git expects, so that the line
+// differs from the same line with a newline, and the diff applies as is.
+func splitLines(source string) []string {
+	if source == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(source, "\n")
+	if last := lines[len(lines)-1]; last != "" {
+		lines[len(lines)-1] = last + "\n" + noNewlineMarker
+		return lines
+	}
+	return lines[:len(lines)-1]
+}
+
+// Diff returns a unified diff of all the files modified across all packages, that can be applied as a patch with
+// `git apply` from the root of the repository.
+// Packages are sorted by import path so that the patch is stable.
+func (q QuarantineResults) Diff() (string, error) {
+	packages := make([]string, 0, len(q))
+	for pkg := range q {
+		packages = append(packages, pkg)
+	}
+	slices.Sort(packages)
+
+	var patch strings.Builder
+	for _, pkg := range packages {
+		for _, file := range slices.Concat(q[pkg].Successes, q[pkg].ModuleFiles) {
+			diff, err := file.Diff()
+			if err != nil {
+				return "", err
+			}
+			patch.WriteString(diff)
+		}
+	}
+	return patch.String(), nil
+}
+
+// writeChangesSection writes a collapsible Markdown section showing the combined diff of the results.
+// Nothing is written if there are no changes.
+func writeChangesSection(md *strings.Builder, results QuarantineResults) {
+	diff, err := results.Diff()
+	if err != nil {
+		md.WriteString(fmt.Sprintf("Unable to show changes: %s\n\n", err))
+		return
+	}
+	if diff == "" {
+		return
+	}
+
+	truncated := false
+	if len(diff) > maxMarkdownDiffLength {
+		diff = diff[:strings.LastIndexByte(diff[:maxMarkdownDiffLength], '\n')+1]
+		truncated = true
+	}
+
+	md.WriteString("<details>\n<summary>Changes</summary>\n\n")
+	md.WriteString("```diff\n")
+	md.WriteString(diff)
+	md.WriteString("```\n\n")
+	if truncated {
+		md.WriteString("Diff is too large to show in full, see the changed files for the rest.\n\n")
+	}
+	md.WriteString("</details>\n")
+}
@@ -0,0 +1,184 @@
+package golang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuarantinedFile_Diff(t *testing.T) {
+	t.Parallel()
+
+	file := QuarantinedFile{
+		File: "pkg/a_test.go",
+		OriginalSourceCode: `package example
+
+import "testing"
+
+func TestA(t *testing.T) {
+	t.Parallel()
+}
+`,
+		ModifiedSourceCode: `package example
+
+import "testing"
+import "github.com/smartcontractkit/branch-out/quarantine"
+
+func TestA(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-A")
+	t.Parallel()
+}
+`,
+	}
+
+	diff, err := file.Diff()
+	require.NoError(t, err)
+	expectedDiff := strings.Join([]string{
+		"--- a/pkg/a_test.go",
+		"+++ b/pkg/a_test.go",
+		"@@ -1,7 +1,9 @@",
+		" package example",
+		" ",
+		` import "testing"`,
+		`+import "github.com/smartcontractkit/branch-out/quarantine"`,
+		" ",
+		" func TestA(t *testing.T) {",
+		`+	quarantine.Flaky(t, "JIRA-A")`,
+		" 	t.Parallel()",
+		" }",
+		"",
+	}, "\n")
+	assert.Equal(t, expectedDiff, diff)
+
+	file.ModifiedSourceCode = file.OriginalSourceCode
+	diff, err = file.Diff()
+	require.NoError(t, err)
+	assert.Empty(t, diff, "unmodified files should not have a diff")
+}
+
+func TestQuarantinedFile_Diff_EndOfFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		file         QuarantinedFile
+		expectedDiff string
+	}{
+		{
+			name: "created file",
+			file: QuarantinedFile{
+				File:               "pkg/main_test.go",
+				ModifiedSourceCode: "package pkg\n\nimport \"testing\"\n",
+				Created:            true,
+			},
+			expectedDiff: strings.Join([]string{
+				"--- /dev/null",
+				"+++ b/pkg/main_test.go",
+				"@@ -0,0 +1,3 @@",
+				"+package pkg",
+				"+",
+				`+import "testing"`,
+				"",
+			}, "\n"),
+		},
+		{
+			name: "deleted file",
+			file: QuarantinedFile{
+				File:               "pkg/main_test.go",
+				OriginalSourceCode: "package pkg\n",
+				Deleted:            true,
+			},
+			expectedDiff: strings.Join([]string{
+				"--- a/pkg/main_test.go",
+				"+++ /dev/null",
+				"@@ -1 +0,0 @@",
+				"-package pkg",
+				"",
+			}, "\n"),
+		},
+		{
+			name: "newline added",
+			file: QuarantinedFile{
+				File:               "go.sum",
+				OriginalSourceCode: "a v1.0.0 h1:a=",
+				ModifiedSourceCode: "a v1.0.0 h1:a=\nb v1.0.0 h1:b=\n",
+			},
+			expectedDiff: strings.Join([]string{
+				"--- a/go.sum",
+				"+++ b/go.sum",
+				"@@ -1 +1,2 @@",
+				"-a v1.0.0 h1:a=",
+				`\ No newline at end of file`,
+				"+a v1.0.0 h1:a=",
+				"+b v1.0.0 h1:b=",
+				"",
+			}, "\n"),
+		},
+		{
+			name: "no newline kept",
+			file: QuarantinedFile{
+				File:               "pkg/a_test.go",
+				OriginalSourceCode: "package pkg\n\nfunc TestA(t *testing.T) {}",
+				ModifiedSourceCode: "package pkg\n\n// A\n\nfunc TestA(t *testing.T) {}",
+			},
+			expectedDiff: strings.Join([]string{
+				"--- a/pkg/a_test.go",
+				"+++ b/pkg/a_test.go",
+				"@@ -1,3 +1,5 @@",
+				" package pkg",
+				" ",
+				"+// A",
+				"+",
+				" func TestA(t *testing.T) {}",
+				`\ No newline at end of file`,
+				"",
+			}, "\n"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			diff, err := test.file.Diff()
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedDiff, diff)
+		})
+	}
+}
+
+func TestQuarantineResults_Diff(t *testing.T) {
+	t.Parallel()
+
+	results := QuarantineResults{
+		"github.com/example/b": {
+			Package: "github.com/example/b",
+			Successes: []QuarantinedFile{
+				{File: "b/b_test.go", OriginalSourceCode: "package b\n", ModifiedSourceCode: "package b\n\n// B\n"},
+			},
+		},
+		"github.com/example/a": {
+			Package: "github.com/example/a",
+			Successes: []QuarantinedFile{
+				{File: "a/a_test.go", OriginalSourceCode: "package a\n", ModifiedSourceCode: "package a\n\n// A\n"},
+				{File: "a/untouched_test.go", OriginalSourceCode: "package a\n", ModifiedSourceCode: "package a\n"},
+			},
+		},
+	}
+
+	patch, err := results.Diff()
+	require.NoError(t, err)
+	assert.NotContains(t, patch, "untouched_test.go", "unmodified files should not be in the patch")
+	aIndex, bIndex := strings.Index(patch, "--- a/a/a_test.go"), strings.Index(patch, "--- a/b/b_test.go")
+	require.NotEqual(t, -1, aIndex, "patch should contain a/a_test.go")
+	require.NotEqual(t, -1, bIndex, "patch should contain b/b_test.go")
+	assert.Less(t, aIndex, bIndex, "patch should be sorted by package")
+
+	md := results.Markdown("owner", "repo", "branch")
+	assert.Contains(t, md, "<details>\n<summary>Changes</summary>\n\n```diff\n"+patch+"```\n")
+
+	empty := QuarantineResults{"github.com/example/a": {Package: "github.com/example/a"}}
+	assert.NotContains(t, empty.Markdown("owner", "repo", "branch"), "<summary>Changes</summary>")
+}
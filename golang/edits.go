@@ -0,0 +1,299 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// textEdit replaces the bytes of a file in the range [Start, End) with Text.
+// Editing the source text directly, rather than re-printing the whole AST, keeps the diff down to exactly the lines
+// we mean to change, and leaves comments and any unusual formatting in the rest of the file alone.
+type textEdit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// applyEdits applies the edits to the source. Edits must not overlap.
+func applyEdits(src []byte, edits []textEdit) (string, error) {
+	slices.SortStableFunc(edits, func(a, b textEdit) int { return a.Start - b.Start })
+
+	var (
+		result strings.Builder
+		last   int
+	)
+	for _, edit := range edits {
+		if edit.Start < last || edit.End < edit.Start || edit.End > len(src) {
+			return "", fmt.Errorf("invalid edit of bytes [%d, %d) after byte %d", edit.Start, edit.End, last)
+		}
+		result.Write(src[last:edit.Start])
+		result.WriteString(edit.Text)
+		last = edit.End
+	}
+	result.Write(src[last:])
+	return result.String(), nil
+}
+
+// lineStart returns the offset of the start of the line containing offset.
+func lineStart(src []byte, offset int) int {
+	return bytes.LastIndexByte(src[:offset], '\n') + 1
+}
+
+// lineEnd returns the offset just past the newline ending the line containing offset.
+func lineEnd(src []byte, offset int) int {
+	if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+		return offset + i + 1
+	}
+	return len(src)
+}
+
+// lineIndent returns the leading whitespace of the line containing offset.
+func lineIndent(src []byte, offset int) string {
+	start := lineStart(src, offset)
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return string(src[start:end])
+}
+
+// isBlankLine checks if the line starting at offset only contains whitespace.
+func isBlankLine(src []byte, offset int) bool {
+	return offset < len(src) && len(bytes.TrimSpace(src[offset:lineEnd(src, offset)])) == 0
+}
+
+// formatStmt prints a generated statement, indenting every line of it.
+func formatStmt(stmt ast.Stmt, indent string) (string, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), stmt); err != nil {
+		return "", fmt.Errorf("failed to format statement: %w", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	for i, line := range lines {
+		lines[i] = indent + line
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// insertStmtsEdits inserts statements at the top of a block, on their own lines.
+func insertStmtsEdits(src []byte, tokenFile *token.File, body *ast.BlockStmt, stmts []ast.Stmt) ([]textEdit, error) {
+	var (
+		lbrace      = tokenFile.Offset(body.Lbrace)
+		rbrace      = tokenFile.Offset(body.Rbrace)
+		outerIndent = lineIndent(src, lbrace)
+		next        = rbrace // Whatever comes first after the opening brace
+	)
+	if len(body.List) > 0 {
+		next = tokenFile.Offset(body.List[0].Pos())
+	}
+	onNextLine := lineStart(src, next) > lbrace
+
+	indent := outerIndent + "\t"
+	if len(body.List) > 0 && onNextLine {
+		indent = lineIndent(src, next) // Match the indentation of the existing statements
+	}
+
+	var text strings.Builder
+	for _, stmt := range stmts {
+		stmtText, err := formatStmt(stmt, indent)
+		if err != nil {
+			return nil, err
+		}
+		text.WriteString(stmtText)
+	}
+
+	// Usual case, the body starts on the line after the opening brace
+	if onNextLine {
+		insertAt := lineEnd(src, lbrace)
+		return []textEdit{{Start: insertAt, End: insertAt, Text: text.String()}}, nil
+	}
+
+	// The body starts on the same line as the opening brace, e.g. {} or { t.Parallel() }, so it needs to be split
+	// onto multiple lines. The body itself is left alone, as it may contain other edits.
+	if len(body.List) == 0 {
+		return []textEdit{{Start: lbrace + 1, End: rbrace, Text: "\n" + text.String() + outerIndent}}, nil
+	}
+	lastEnd := tokenFile.Offset(body.List[len(body.List)-1].End())
+	return []textEdit{
+		{Start: lbrace + 1, End: next, Text: "\n" + text.String() + indent},
+		{Start: lastEnd, End: rbrace, Text: "\n" + outerIndent},
+	}, nil
+}
+
+// removeStmtEdit removes a statement, along with its line if nothing else is on it.
+func removeStmtEdit(src []byte, tokenFile *token.File, stmt ast.Stmt) textEdit {
+	var (
+		start = tokenFile.Offset(stmt.Pos())
+		end   = tokenFile.Offset(stmt.End())
+	)
+	if len(bytes.TrimSpace(src[lineStart(src, start):start])) == 0 && onlyCommentAfter(src[end:lineEnd(src, end)]) {
+		return textEdit{Start: lineStart(src, start), End: lineEnd(src, end)}
+	}
+
+	// Something else shares the line, only remove the statement and its separator
+	for end < len(src) && (src[end] == ' ' || src[end] == ';') {
+		end++
+	}
+	return textEdit{Start: start, End: end}
+}
+
+// replaceStmtEdit replaces a statement with another, keeping the indentation of the original.
+func replaceStmtEdit(src []byte, tokenFile *token.File, stmt, replacement ast.Stmt) (textEdit, error) {
+	start := tokenFile.Offset(stmt.Pos())
+	indent := lineIndent(src, start)
+	text, err := formatStmt(replacement, indent)
+	if err != nil {
+		return textEdit{}, err
+	}
+	return textEdit{
+		Start: start,
+		End:   tokenFile.Offset(stmt.End()),
+		Text:  strings.TrimSuffix(strings.TrimPrefix(text, indent), "\n"),
+	}, nil
+}
+
+// replaceExprEdit replaces an expression with another, formatted on its own.
+func replaceExprEdit(tokenFile *token.File, expr, replacement ast.Expr) (textEdit, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), replacement); err != nil {
+		return textEdit{}, fmt.Errorf("failed to format expression: %w", err)
+	}
+	return textEdit{
+		Start: tokenFile.Offset(expr.Pos()),
+		End:   tokenFile.Offset(expr.End()),
+		Text:  buf.String(),
+	}, nil
+}
+
+// onlyCommentAfter checks if the rest of a line is empty, or only a line comment.
+func onlyCommentAfter(rest []byte) bool {
+	rest = bytes.TrimSpace(rest)
+	return len(rest) == 0 || bytes.HasPrefix(rest, []byte("//"))
+}
+
+// addImportEdit adds an import to the file.
+// If the file has an import block, the import is added to it in sorted order, in the first group of non-standard
+// library imports, or a new group at the end of the block if there is none.
+func addImportEdit(src []byte, tokenFile *token.File, node *ast.File, importPath string) textEdit {
+	importLine := strconv.Quote(importPath)
+
+	var lastImportDecl *ast.GenDecl
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		lastImportDecl = genDecl
+		if !genDecl.Lparen.IsValid() || len(genDecl.Specs) == 0 {
+			continue
+		}
+
+		// Find the first group of imports of the same kind, standard library or not
+		var (
+			standardLibrary = isStandardLibraryPath(importPath)
+			group           []*ast.ImportSpec
+		)
+		for i, spec := range genDecl.Specs {
+			importSpec := spec.(*ast.ImportSpec)
+			if i > 0 && tokenFile.Line(spec.Pos()) > tokenFile.Line(genDecl.Specs[i-1].End())+1 {
+				if len(group) > 0 && isStandardLibrary(group[0]) == standardLibrary {
+					break
+				}
+				group = nil
+			}
+			group = append(group, importSpec)
+		}
+		if len(group) == 0 || isStandardLibrary(group[0]) != standardLibrary {
+			if standardLibrary {
+				// Standard library imports go first
+				insertAt := lineEnd(src, tokenFile.Offset(genDecl.Lparen))
+				return textEdit{Start: insertAt, End: insertAt, Text: "\t" + importLine + "\n\n"}
+			}
+			insertAt := lineStart(src, tokenFile.Offset(genDecl.Rparen))
+			return textEdit{Start: insertAt, End: insertAt, Text: "\n\t" + importLine + "\n"}
+		}
+
+		insertAt := lineEnd(src, tokenFile.Offset(group[len(group)-1].End()))
+		for _, spec := range group {
+			if strings.Trim(spec.Path.Value, "`\"") > importPath {
+				insertAt = lineStart(src, tokenFile.Offset(spec.Pos()))
+				break
+			}
+		}
+		indent := lineIndent(src, tokenFile.Offset(group[0].Pos()))
+		return textEdit{Start: insertAt, End: insertAt, Text: indent + importLine + "\n"}
+	}
+
+	if lastImportDecl != nil {
+		insertAt := lineEnd(src, tokenFile.Offset(lastImportDecl.End()))
+		return textEdit{Start: insertAt, End: insertAt, Text: "import " + importLine + "\n"}
+	}
+
+	// No imports at all, add one after the package clause
+	insertAt := lineEnd(src, tokenFile.Offset(node.Name.End()))
+	return textEdit{Start: insertAt, End: insertAt, Text: "\nimport " + importLine + "\n"}
+}
+
+// isStandardLibrary checks if the import is from the standard library, i.e. its first path element has no dot.
+func isStandardLibrary(spec *ast.ImportSpec) bool {
+	return isStandardLibraryPath(strings.Trim(spec.Path.Value, "`\""))
+}
+
+// isStandardLibraryPath is isStandardLibrary for an import path.
+func isStandardLibraryPath(importPath string) bool {
+	firstElement, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(firstElement, ".")
+}
+
+// removeImportEdit removes the import of the given path from the file, undoing addImportEdit.
+// Returns false if the file doesn't import the path.
+func removeImportEdit(src []byte, tokenFile *token.File, node *ast.File, importPath string) (textEdit, bool) {
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			importSpec := spec.(*ast.ImportSpec)
+			if strings.Trim(importSpec.Path.Value, "`\"") != importPath {
+				continue
+			}
+
+			// Remove the whole declaration if this is its only import
+			removeFrom, removeTo := importSpec.Pos(), importSpec.End()
+			if len(genDecl.Specs) == 1 {
+				removeFrom, removeTo = genDecl.Pos(), genDecl.End()
+			}
+			start := lineStart(src, tokenFile.Offset(removeFrom))
+			end := lineEnd(src, tokenFile.Offset(removeTo))
+
+			// Don't leave behind an empty group of imports, or a doubled blank line
+			blankBefore := start > 0 && isBlankLine(src, lineStart(src, start-1))
+			blankAfter := end < len(src) && isBlankLine(src, end)
+			switch {
+			case blankBefore && (end >= len(src) || blankAfter || closesImportBlock(src, end)):
+				start = lineStart(src, start-1)
+			case blankAfter && start > 0 && opensImportBlock(src, lineStart(src, start-1)):
+				end = lineEnd(src, end)
+			}
+			return textEdit{Start: start, End: end}, true
+		}
+	}
+	return textEdit{}, false
+}
+
+// opensImportBlock checks if the line starting at offset opens an import block.
+func opensImportBlock(src []byte, offset int) bool {
+	return string(bytes.TrimSpace(src[offset:lineEnd(src, offset)])) == "import ("
+}
+
+// closesImportBlock checks if the line starting at offset is the closing parenthesis of an import block.
+func closesImportBlock(src []byte, offset int) bool {
+	return string(bytes.TrimSpace(src[offset:lineEnd(src, offset)])) == ")"
+}
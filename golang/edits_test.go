@@ -0,0 +1,252 @@
+package golang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipTests_MinimalEdits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		source         string
+		target         QuarantineTarget
+		expectedSource string
+	}{
+		{
+			name: "leaves comments and formatting alone",
+			source: `package example
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestA does things.
+func TestA(t *testing.T) {
+	// Run in parallel
+	t.Parallel()
+	require.True(t,
+		true) // The printer would not keep this as is
+}
+
+func TestB(t *testing.T) { t.Parallel() }
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA", JiraTicket: "JIRA-A"}}},
+			expectedSource: `package example
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+	"github.com/stretchr/testify/require"
+)
+
+// TestA does things.
+func TestA(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-A")
+	// Run in parallel
+	t.Parallel()
+	require.True(t,
+		true) // The printer would not keep this as is
+}
+
+func TestB(t *testing.T) { t.Parallel() }
+`,
+		},
+		{
+			name: "new import group after the standard library",
+			source: `package example
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestA(t *testing.T) {
+	fmt.Println("a")
+}
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA", JiraTicket: "JIRA-A"}}},
+			expectedSource: `package example
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-A")
+	fmt.Println("a")
+}
+`,
+		},
+		{
+			name: "single line bodies",
+			source: `package example
+
+import "testing"
+
+func TestA(t *testing.T) {}
+
+func TestB(t *testing.T) { t.Parallel() }
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{
+				{Name: "TestA", JiraTicket: "JIRA-A"},
+				{Name: "TestB", JiraTicket: "JIRA-B"},
+			}},
+			expectedSource: `package example
+
+import "testing"
+import "github.com/smartcontractkit/branch-out/quarantine"
+
+func TestA(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-A")
+}
+
+func TestB(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-B")
+	t.Parallel()
+}
+`,
+		},
+		{
+			name: "updates only the ticket",
+			source: `package example
+
+import (
+	"testing"
+
+	q "github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {
+	q.Flaky(t,   "JIRA-OLD") // Tracked elsewhere
+	t.Parallel()
+}
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA", JiraTicket: "JIRA-NEW"}}},
+			expectedSource: `package example
+
+import (
+	"testing"
+
+	q "github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {
+	q.Flaky(t,   "JIRA-NEW") // Tracked elsewhere
+	t.Parallel()
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testFile, err := parseTestFile("example_test.go", []byte(test.source))
+			require.NoError(t, err)
+
+			modifiedSource, quarantinedTests, err := skipTests(
+				testFile,
+				testsInFile(testFile.Node, test.target, nil),
+				nil,
+				FlakyGenerator(),
+			)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSource, modifiedSource)
+			require.Len(t, quarantinedTests, len(test.target.Tests))
+			for _, quarantinedTest := range quarantinedTests {
+				assert.False(t, quarantinedTest.AlreadyQuarantined, "%s should have been modified", quarantinedTest.Name)
+			}
+		})
+	}
+}
+
+func TestUnskipTests_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	sources := map[string]string{
+		"import block": `package example
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestA(t *testing.T) {
+	// Run in parallel
+	t.Parallel()
+	require.True(t, true)
+}
+`,
+		"standard library only": `package example
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestA(t *testing.T) {
+	fmt.Println("a")
+}
+`,
+		"no standard library import group": `package example
+
+import "testing"
+
+import (
+	"github.com/stretchr/testify/require"
+)
+
+func TestA(t *testing.T) {
+	require.True(t, true)
+}
+`,
+		"single import": `package example
+
+import "testing"
+
+func TestA(t *testing.T) {
+	t.Parallel()
+}
+`,
+	}
+
+	target := QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA", JiraTicket: "JIRA-A"}}}
+	generators := map[string]Generator{"flaky": FlakyGenerator(), "inline skip": InlineSkipGenerator()}
+	for name, source := range sources {
+		for generatorName, generator := range generators {
+			t.Run(name+"/"+generatorName, func(t *testing.T) {
+				t.Parallel()
+
+				testFile, err := parseTestFile("example_test.go", []byte(source))
+				require.NoError(t, err)
+				modifiedSource, _, err := skipTests(testFile, testsInFile(testFile.Node, target, nil), nil, generator)
+				require.NoError(t, err)
+				require.NotEqual(t, source, modifiedSource)
+
+				testFile, err = parseTestFile("example_test.go", []byte(modifiedSource))
+				require.NoError(t, err)
+				unquarantinedSource, unquarantinedTests, err := unskipTests(
+					testFile,
+					testsInFile(testFile.Node, target, nil),
+					nil,
+					builtinGenerators(),
+				)
+				require.NoError(t, err)
+				require.Len(t, unquarantinedTests, 1)
+				assert.Equal(t, source, unquarantinedSource, "un-quarantining should restore the original source exactly")
+			})
+		}
+	}
+}
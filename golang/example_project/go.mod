@@ -3,6 +3,14 @@ module github.com/smartcontractkit/branch-out-example-project
 go 1.25.0
 
 replace (
-	// Get proper local version of branch-out
-	github.com/smartcontractkit/branch-out => ../..
+	// Get proper local version of the quarantine module
+	github.com/smartcontractkit/branch-out/quarantine => ../../quarantine
+)
+
+require github.com/stretchr/testify v1.10.0
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,12 @@
+//go:build example_project && integration
+
+package example_project
+
+import "testing"
+
+// TestIntegrationTagged only builds with the integration build tag.
+func TestIntegrationTagged(t *testing.T) {
+	t.Parallel()
+
+	Helper(t, "This is a test that only runs with the integration build tag")
+}
@@ -0,0 +1,12 @@
+//go:build example_project
+
+package example_project
+
+import "testing"
+
+// TestWindowsOnly only builds on Windows, because of its file name.
+func TestWindowsOnly(t *testing.T) {
+	t.Parallel()
+
+	Helper(t, "This is a test that only runs on Windows")
+}
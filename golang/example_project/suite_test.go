@@ -0,0 +1,28 @@
+//go:build example_project
+
+package example_project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// ExampleSuite shows tests written as testify suite methods.
+type ExampleSuite struct {
+	suite.Suite
+}
+
+func TestExampleSuite(t *testing.T) {
+	t.Parallel()
+
+	suite.Run(t, new(ExampleSuite))
+}
+
+func (s *ExampleSuite) TestSuiteMethod1() {
+	Helper(s.T(), "This is a testify suite method")
+}
+
+func (s *ExampleSuite) TestSuiteMethod2() {
+	Helper(s.T(), "This is a testify suite method")
+}
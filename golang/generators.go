@@ -0,0 +1,531 @@
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+// inlineSkipMessageSuffix ends the message of the skips InlineSkipGenerator generates, after the ticket.
+const inlineSkipMessageSuffix = ". To run quarantined tests, set the " + quarantine.RunQuarantinedTestsEnvVar +
+	" environment variable to true."
+
+// ErrUnknownGenerator is returned by ParseGenerator for a name that doesn't select a generator.
+var ErrUnknownGenerator = errors.New("unknown quarantine generator")
+
+// Generator generates the code that quarantines a test, and recognizes code it generated before so that it can be
+// updated or removed.
+type Generator interface {
+	// ImportPath is the import path of the package the generated code uses, which is imported if needed.
+	// Empty if the code doesn't need any imports.
+	ImportPath() string
+	// Generate returns the statement that quarantines the test at the site.
+	Generate(site QuarantineSite) ast.Stmt
+	// Match checks if the statement is one that Generate returns for the site, for any ticket,
+	// and returns the ticket it quarantines the test for, along with the expression in the statement holding it,
+	// e.g. its string literal. Changing the ticket only replaces that expression. It's nil if there is none.
+	Match(stmt ast.Stmt, site QuarantineSite) (ticket string, ticketExpr ast.Expr, ok bool)
+}
+
+// QuarantineSite describes a test that a Generator generates quarantine code for, and where the code goes.
+type QuarantineSite struct {
+	ImportName   string // Name the generator's import path is referred to by in the file, if it has one
+	TestingParam string // Name of the *testing.T parameter, or the receiver of a testify suite method
+	Suite        bool   // True for testify suite methods, where the *testing.T is reached through TestingParam.T()
+	TestName     string // Full name of the test, e.g. "TestFoo/subtest_1"
+	Ticket       string // Ticket tracking the flaky test
+	// True if the test can only be told apart from its siblings by its name at runtime,
+	// so the generated code has to compare the name itself, e.g. with t.Name().
+	MatchName bool
+	// True for a guard in TestMain that skips every test of the package by returning early.
+	// TestingParam is then the name of the *testing.M parameter.
+	Package bool
+	// Date the quarantine expires after, zero if it never does. Generators that can't express it ignore it.
+	Until time.Time
+	// Variant the test is flaky on, empty for every variant. Generators that can't express it ignore it.
+	Variant string
+}
+
+// testingExpr returns the expression for the test's *testing.T, e.g. t, or s.T() for suite methods.
+func (s QuarantineSite) testingExpr() ast.Expr {
+	if !s.Suite {
+		return ast.NewIdent(s.TestingParam)
+	}
+	return &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(s.TestingParam), Sel: ast.NewIdent("T")}}
+}
+
+// nameCond returns the condition that the running test has the site's test name, e.g. t.Name() == "TestFoo/sub".
+func (s QuarantineSite) nameCond() ast.Expr {
+	return &ast.BinaryExpr{
+		X:  &ast.CallExpr{Fun: &ast.SelectorExpr{X: s.testingExpr(), Sel: ast.NewIdent("Name")}},
+		Op: token.EQL,
+		Y:  stringLit(s.TestName),
+	}
+}
+
+// isNameCond checks if the expression is the site's nameCond.
+func (s QuarantineSite) isNameCond(expr ast.Expr) bool {
+	cond, ok := expr.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.EQL || types.ExprString(cond.X) != types.ExprString(s.nameCond().(*ast.BinaryExpr).X) {
+		return false
+	}
+	name, ok := stringLiteral(cond.Y)
+	return ok && name == s.TestName
+}
+
+// FlakyGenerator quarantines tests with the quarantine package of branch-out. This is the default.
+//
+//	quarantine.Flaky(t, "JIRA-123")
+//	quarantine.Flaky(t, "JIRA-123", quarantine.IfNamed("TestFoo/subtest_1")) // Subtests only named at runtime
+//	quarantine.FlakySuite(s, "JIRA-123")                                     // testify suite methods
+//	quarantine.Flaky(t, "JIRA-123", quarantine.Until("2026-12-01"))          // Quarantines that expire
+//	quarantine.Flaky(t, "JIRA-123", quarantine.Variant("linux+race"))        // Tests only flaky on some variants
+func FlakyGenerator() Generator {
+	return flakyGenerator{}
+}
+
+type flakyGenerator struct{}
+
+// ImportPath returns the import path of the quarantine package.
+func (flakyGenerator) ImportPath() string {
+	return quarantineImportPath
+}
+
+// Generate returns a quarantine.Flaky() or quarantine.FlakySuite() call,
+// or an if quarantine.FlakyPackage() { return } guard for packages.
+func (flakyGenerator) Generate(site QuarantineSite) ast.Stmt {
+	if site.Package {
+		return &ast.IfStmt{
+			Cond: &ast.CallExpr{
+				Fun:  &ast.SelectorExpr{X: ast.NewIdent(site.ImportName), Sel: ast.NewIdent("FlakyPackage")},
+				Args: append([]ast.Expr{stringLit(site.Ticket)}, site.options()...),
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{}}},
+		}
+	}
+	args := []ast.Expr{ast.NewIdent(site.TestingParam), stringLit(site.Ticket)}
+	if site.MatchName {
+		args = append(args, &ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: ast.NewIdent(site.ImportName), Sel: ast.NewIdent("IfNamed")},
+			Args: []ast.Expr{stringLit(site.TestName)},
+		})
+	}
+	args = append(args, site.options()...)
+	flakyFunc := "Flaky"
+	if site.Suite {
+		flakyFunc = "FlakySuite"
+	}
+	return &ast.ExprStmt{X: &ast.CallExpr{
+		Fun:  &ast.SelectorExpr{X: ast.NewIdent(site.ImportName), Sel: ast.NewIdent(flakyFunc)},
+		Args: args,
+	}}
+}
+
+// Match checks for a quarantine.Flaky() or quarantine.FlakySuite() call, with a quarantine.IfNamed() option
+// for the test if the site needs its name matched, or the quarantine.FlakyPackage() guard for packages.
+// Other options, like quarantine.Until() and quarantine.Variant(), are ignored, so that expiring quarantines and
+// quarantines of a single variant are recognized too.
+func (flakyGenerator) Match(stmt ast.Stmt, site QuarantineSite) (string, ast.Expr, bool) {
+	if site.Package {
+		ifStmt, ok := singleStmtIf(stmt)
+		if !ok || !isBareReturn(ifStmt.Body.List[0]) {
+			return "", nil, false
+		}
+		call, ok := ifStmt.Cond.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 || types.ExprString(call.Fun) != site.ImportName+".FlakyPackage" {
+			return "", nil, false
+		}
+		ticket, _ := stringLiteral(call.Args[0])
+		return ticket, call.Args[0], true
+	}
+	call, ok := quarantineCall(stmt, site.ImportName)
+	if !ok || (call.Fun.(*ast.SelectorExpr).Sel.Name == "FlakySuite") != site.Suite {
+		return "", nil, false
+	}
+	expectedName := ""
+	if site.MatchName {
+		expectedName = site.TestName
+	}
+	if ifNamedArg(call, site.ImportName) != expectedName {
+		return "", nil, false
+	}
+	ticket, ticketExpr := quarantineTicket(call)
+	return ticket, ticketExpr, true
+}
+
+// options returns the quarantine.Variant() and quarantine.Until() options for the site's variant and expiry,
+// if it has them.
+func (s QuarantineSite) options() []ast.Expr {
+	var options []ast.Expr
+	if s.Variant != "" {
+		options = append(options, s.option("Variant", s.Variant))
+	}
+	if !s.Until.IsZero() {
+		options = append(options, s.option("Until", s.Until.Format(quarantine.UntilLayout)))
+	}
+	return options
+}
+
+// option returns a call to an option of the quarantine package with a single string argument.
+func (s QuarantineSite) option(name, value string) ast.Expr {
+	return &ast.CallExpr{
+		Fun:  &ast.SelectorExpr{X: ast.NewIdent(s.ImportName), Sel: ast.NewIdent(name)},
+		Args: []ast.Expr{stringLit(value)},
+	}
+}
+
+// FuncGenerator quarantines tests by calling a function with the same signature as quarantine.Flaky,
+// e.g. a wrapper in a repo's own test utilities.
+// Tests that are only named at runtime are guarded by comparing their name.
+// Packages can't be quarantined, as the function takes a testing.TB.
+//
+//	testutil.Quarantine(t, "JIRA-123")
+//	testutil.Quarantine(s.T(), "JIRA-123")                                    // testify suite methods
+//	if t.Name() == "TestFoo/subtest_1" { testutil.Quarantine(t, "JIRA-123") } // Subtests only named at runtime
+func FuncGenerator(importPath, funcName string) Generator {
+	return funcGenerator{importPath: importPath, funcName: funcName}
+}
+
+type funcGenerator struct {
+	importPath string
+	funcName   string
+}
+
+// ImportPath returns the import path of the package with the function.
+func (g funcGenerator) ImportPath() string {
+	return g.importPath
+}
+
+// Generate returns a call to the function. Returns nil for packages.
+func (g funcGenerator) Generate(site QuarantineSite) ast.Stmt {
+	if site.Package {
+		return nil
+	}
+	var call ast.Stmt = &ast.ExprStmt{X: &ast.CallExpr{
+		Fun:  &ast.SelectorExpr{X: ast.NewIdent(site.ImportName), Sel: ast.NewIdent(g.funcName)},
+		Args: []ast.Expr{site.testingExpr(), stringLit(site.Ticket)},
+	}}
+	if !site.MatchName {
+		return call
+	}
+	return &ast.IfStmt{Cond: site.nameCond(), Body: &ast.BlockStmt{List: []ast.Stmt{call}}}
+}
+
+// Match checks for a call to the function, guarded by a name comparison if the site needs its name matched.
+func (g funcGenerator) Match(stmt ast.Stmt, site QuarantineSite) (string, ast.Expr, bool) {
+	if site.Package {
+		return "", nil, false
+	}
+	if site.MatchName {
+		ifStmt, ok := singleStmtIf(stmt)
+		if !ok || !site.isNameCond(ifStmt.Cond) {
+			return "", nil, false
+		}
+		stmt = ifStmt.Body.List[0]
+	}
+
+	exprStmt, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return "", nil, false
+	}
+	call, ok := exprStmt.X.(*ast.CallExpr)
+	if !ok || len(call.Args) != 2 || types.ExprString(call.Args[0]) != types.ExprString(site.testingExpr()) {
+		return "", nil, false
+	}
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != g.funcName {
+		return "", nil, false
+	}
+	if ident, ok := selector.X.(*ast.Ident); !ok || ident.Name != site.ImportName {
+		return "", nil, false
+	}
+	ticket, ticketExpr := quarantineTicket(call)
+	return ticket, ticketExpr, true
+}
+
+// InlineSkipGenerator quarantines tests with an inline skip, so that the tests don't need any dependencies.
+// Like quarantine.Flaky, the tests still run if the RUN_QUARANTINED_TESTS environment variable is set to true.
+//
+//	if os.Getenv("RUN_QUARANTINED_TESTS") != "true" {
+//		t.Skip("Known flaky test. Ticket JIRA-123. To run quarantined tests, ...")
+//	}
+//
+// Packages are quarantined by returning early from TestMain:
+//
+//	if os.Getenv("RUN_QUARANTINED_TESTS") != "true" {
+//		println("Known flaky package. Ticket JIRA-123. To run quarantined tests, ...")
+//		return
+//	}
+func InlineSkipGenerator() Generator {
+	return inlineSkipGenerator{}
+}
+
+type inlineSkipGenerator struct{}
+
+// ImportPath returns "os", for os.Getenv.
+func (inlineSkipGenerator) ImportPath() string {
+	return "os"
+}
+
+// Generate returns an if statement skipping the test unless quarantined tests should run.
+func (inlineSkipGenerator) Generate(site QuarantineSite) ast.Stmt {
+	var cond ast.Expr = inlineSkipEnvCond(site.ImportName)
+	if site.MatchName {
+		cond = &ast.BinaryExpr{X: site.nameCond(), Op: token.LAND, Y: cond}
+	}
+	skipFunc := ast.Expr(&ast.SelectorExpr{X: site.testingExpr(), Sel: ast.NewIdent("Skip")})
+	if site.Package {
+		skipFunc = ast.NewIdent("println")
+	}
+	body := []ast.Stmt{&ast.ExprStmt{X: &ast.CallExpr{
+		Fun:  skipFunc,
+		Args: []ast.Expr{stringLit(inlineSkipMessagePrefix(site) + site.Ticket + inlineSkipMessageSuffix)},
+	}}}
+	if site.Package {
+		body = append(body, &ast.ReturnStmt{})
+	}
+	return &ast.IfStmt{Cond: cond, Body: &ast.BlockStmt{List: body}}
+}
+
+// Match checks for the if statement skipping the test, and reads the ticket from the skip message.
+func (inlineSkipGenerator) Match(stmt ast.Stmt, site QuarantineSite) (string, ast.Expr, bool) {
+	ifStmt, ok := stmt.(*ast.IfStmt)
+	if !ok || ifStmt.Init != nil || ifStmt.Else != nil || len(ifStmt.Body.List) == 0 {
+		return "", nil, false
+	}
+	envCond := ifStmt.Cond
+	if site.MatchName {
+		cond, ok := ifStmt.Cond.(*ast.BinaryExpr)
+		if !ok || cond.Op != token.LAND || !site.isNameCond(cond.X) {
+			return "", nil, false
+		}
+		envCond = cond.Y
+	}
+	if types.ExprString(envCond) != types.ExprString(inlineSkipEnvCond(site.ImportName)) {
+		return "", nil, false
+	}
+
+	skipFunc := types.ExprString(site.testingExpr()) + ".Skip"
+	body := ifStmt.Body.List
+	if site.Package {
+		if len(body) != 2 || !isBareReturn(body[1]) {
+			return "", nil, false
+		}
+		skipFunc = "println"
+	} else if len(body) != 1 {
+		return "", nil, false
+	}
+	exprStmt, ok := body[0].(*ast.ExprStmt)
+	if !ok {
+		return "", nil, false
+	}
+	call, ok := exprStmt.X.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 || types.ExprString(call.Fun) != skipFunc {
+		return "", nil, false
+	}
+	message, ok := stringLiteral(call.Args[0])
+	if !ok {
+		return "", nil, false
+	}
+	ticket, hasPrefix := strings.CutPrefix(message, inlineSkipMessagePrefix(site))
+	ticket, hasSuffix := strings.CutSuffix(ticket, inlineSkipMessageSuffix)
+	if !hasPrefix || !hasSuffix {
+		return "", nil, false
+	}
+	return ticket, call.Args[0], true
+}
+
+// inlineSkipMessagePrefix starts the message of the skips InlineSkipGenerator generates, before the ticket.
+func inlineSkipMessagePrefix(site QuarantineSite) string {
+	if site.Package {
+		return "Known flaky package. Ticket "
+	}
+	return "Known flaky test. Ticket "
+}
+
+// inlineSkipEnvCond returns the condition that quarantined tests should not run,
+// os.Getenv("RUN_QUARANTINED_TESTS") != "true".
+func inlineSkipEnvCond(osImportName string) ast.Expr {
+	return &ast.BinaryExpr{
+		X: &ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: ast.NewIdent(osImportName), Sel: ast.NewIdent("Getenv")},
+			Args: []ast.Expr{stringLit(quarantine.RunQuarantinedTestsEnvVar)},
+		},
+		Op: token.NEQ,
+		Y:  stringLit("true"),
+	}
+}
+
+// ParseGenerator returns the generator selected by name: "flaky" (the default when empty) for FlakyGenerator,
+// "inline-skip" for InlineSkipGenerator, or the import path and name of a function for FuncGenerator,
+// e.g. github.com/org/repo/testutil.Quarantine.
+func ParseGenerator(name string) (Generator, error) {
+	switch name {
+	case "", "flaky":
+		return FlakyGenerator(), nil
+	case "inline-skip":
+		return InlineSkipGenerator(), nil
+	}
+	dot := strings.LastIndex(name, ".")
+	if dot <= strings.LastIndex(name, "/") || !token.IsExported(name[dot+1:]) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownGenerator, name)
+	}
+	return FuncGenerator(name[:dot], name[dot+1:]), nil
+}
+
+// builtinGenerators are the generators whose code is recognized when un-quarantining,
+// on top of the one configured with WithGenerator.
+func builtinGenerators() []Generator {
+	return []Generator{FlakyGenerator(), InlineSkipGenerator()}
+}
+
+// quarantineStmt builds the statement that quarantines a test in the given scope with the generator.
+// Table test entries are guarded by their name, e.g. if tc.name == "subtest 1" { quarantine.Flaky(t, "JIRA-123") }.
+func quarantineStmt(generator Generator, importName string, scope testScope, test TestToQuarantine) ast.Stmt {
+	site := quarantineSite(importName, scope, test.Name, test.JiraTicket)
+	site.Until, site.Variant = test.Until, test.Variant
+	stmt := generator.Generate(site)
+	if scope.Match != matchTable {
+		return stmt
+	}
+
+	return &ast.IfStmt{
+		Cond: &ast.BinaryExpr{
+			X:  copyNameExpr(scope.Guard),
+			Op: token.EQL,
+			Y:  stringLit(scope.GuardValue),
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{stmt}},
+	}
+}
+
+// scopeQuarantine checks if the statement is the one quarantining the named test in the scope,
+// in the form quarantineStmt generates for it with the generator.
+// It returns the generated statement, without any table test guard, and the ticket it quarantines the test for,
+// see Generator.Match.
+func scopeQuarantine(
+	generator Generator,
+	importName string,
+	stmt ast.Stmt,
+	scope testScope,
+	testName string,
+) (quarantine ast.Stmt, ticket string, ticketExpr ast.Expr, ok bool) {
+	if scope.Match == matchTable {
+		ifStmt, ok := singleStmtIf(stmt)
+		if !ok {
+			return nil, "", nil, false
+		}
+		cond, ok := ifStmt.Cond.(*ast.BinaryExpr)
+		if !ok || cond.Op != token.EQL || types.ExprString(cond.X) != types.ExprString(scope.Guard) {
+			return nil, "", nil, false
+		}
+		if value, ok := stringLiteral(cond.Y); !ok || value != scope.GuardValue {
+			return nil, "", nil, false
+		}
+		stmt = ifStmt.Body.List[0]
+	}
+	ticket, ticketExpr, ok = generator.Match(stmt, quarantineSite(importName, scope, testName, ""))
+	return stmt, ticket, ticketExpr, ok
+}
+
+// quarantineSite describes the scope to a generator.
+func quarantineSite(importName string, scope testScope, testName, ticket string) QuarantineSite {
+	testingParam := scope.TestingParam
+	if testingParam == "" {
+		testingParam = "t" // default fallback for testing.T param name
+	}
+	return QuarantineSite{
+		ImportName:   importName,
+		TestingParam: testingParam,
+		Suite:        scope.Suite,
+		TestName:     testName,
+		Ticket:       ticket,
+		MatchName:    scope.Match == matchRuntime,
+	}
+}
+
+// isBareReturn checks if the statement is a return without any results.
+func isBareReturn(stmt ast.Stmt) bool {
+	returnStmt, ok := stmt.(*ast.ReturnStmt)
+	return ok && len(returnStmt.Results) == 0
+}
+
+// singleStmtIf returns the statement if it is a plain if statement with a single statement in its body.
+func singleStmtIf(stmt ast.Stmt) (*ast.IfStmt, bool) {
+	ifStmt, ok := stmt.(*ast.IfStmt)
+	if !ok || ifStmt.Init != nil || ifStmt.Else != nil || len(ifStmt.Body.List) != 1 {
+		return nil, false
+	}
+	return ifStmt, true
+}
+
+// quarantineCall returns the quarantine.Flaky() or quarantine.FlakySuite() call if the statement is one.
+// importName is the name the quarantine package is imported as in the file.
+func quarantineCall(stmt ast.Stmt, importName string) (*ast.CallExpr, bool) {
+	exprStmt, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return nil, false
+	}
+	call, ok := exprStmt.X.(*ast.CallExpr)
+	if !ok {
+		return nil, false
+	}
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || (selector.Sel.Name != "Flaky" && selector.Sel.Name != "FlakySuite") {
+		return nil, false
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	if !ok || ident.Name != importName {
+		return nil, false
+	}
+	return call, true
+}
+
+// quarantineTicket returns the ticket passed to a quarantine.Flaky() call, or a function with the same signature,
+// and the argument holding it.
+// Returns the empty string if the ticket is not a string literal.
+func quarantineTicket(call *ast.CallExpr) (string, ast.Expr) {
+	if len(call.Args) < 2 {
+		return "", nil
+	}
+	ticket, _ := stringLiteral(call.Args[1])
+	return ticket, call.Args[1]
+}
+
+// ifNamedArg returns the name passed to a quarantine.IfNamed() option of a quarantine.Flaky() call.
+// Returns the empty string if the call has no such option.
+func ifNamedArg(call *ast.CallExpr, importName string) string {
+	return optionArg(call, importName, "IfNamed")
+}
+
+// optionArg returns the string literal passed to the named option of the quarantine package in a quarantine.Flaky()
+// call, e.g. "linux" for quarantine.Variant("linux"). Returns the empty string if the call has no such option.
+func optionArg(call *ast.CallExpr, importName, optionName string) string {
+	for _, arg := range call.Args[min(len(call.Args), 2):] {
+		option, ok := arg.(*ast.CallExpr)
+		if !ok || len(option.Args) != 1 {
+			continue
+		}
+		selector, ok := option.Fun.(*ast.SelectorExpr)
+		if !ok || selector.Sel.Name != optionName {
+			continue
+		}
+		if ident, ok := selector.X.(*ast.Ident); ok && ident.Name == importName {
+			value, _ := stringLiteral(option.Args[0])
+			return value
+		}
+	}
+	return ""
+}
+
+// stringLit returns a string literal expression of the value.
+func stringLit(value string) *ast.BasicLit {
+	return &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(value)}
+}
@@ -0,0 +1,317 @@
+package golang
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const generatorTestSource = `package example
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestA(t *testing.T) {
+	t.Parallel()
+}
+
+func TestB(t *testing.T) {
+	for i := range 2 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+		})
+	}
+}
+`
+
+func TestGenerators(t *testing.T) {
+	t.Parallel()
+
+	target := QuarantineTarget{Tests: []TestToQuarantine{
+		{Name: "TestA", JiraTicket: "JIRA-A"},
+		{Name: "TestB/1", JiraTicket: "JIRA-B"},
+	}}
+	tests := []struct {
+		name           string
+		generator      Generator
+		expectedSource string
+	}{
+		{
+			name:      "flaky",
+			generator: FlakyGenerator(),
+			expectedSource: `package example
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-A")
+	t.Parallel()
+}
+
+func TestB(t *testing.T) {
+	for i := range 2 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			quarantine.Flaky(t, "JIRA-B", quarantine.IfNamed("TestB/1"))
+			t.Parallel()
+		})
+	}
+}
+`,
+		},
+		{
+			name:      "func",
+			generator: FuncGenerator("example.com/repo/testutil", "Quarantine"),
+			expectedSource: `package example
+
+import (
+	"fmt"
+	"testing"
+
+	"example.com/repo/testutil"
+)
+
+func TestA(t *testing.T) {
+	testutil.Quarantine(t, "JIRA-A")
+	t.Parallel()
+}
+
+func TestB(t *testing.T) {
+	for i := range 2 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if t.Name() == "TestB/1" {
+				testutil.Quarantine(t, "JIRA-B")
+			}
+			t.Parallel()
+		})
+	}
+}
+`,
+		},
+		{
+			name:      "inline skip",
+			generator: InlineSkipGenerator(),
+			expectedSource: `package example
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestA(t *testing.T) {
+	if os.Getenv("RUN_QUARANTINED_TESTS") != "true" {
+		t.Skip("Known flaky test. Ticket JIRA-A. To run quarantined tests, set the RUN_QUARANTINED_TESTS environment variable to true.")
+	}
+	t.Parallel()
+}
+
+func TestB(t *testing.T) {
+	for i := range 2 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if t.Name() == "TestB/1" && os.Getenv("RUN_QUARANTINED_TESTS") != "true" {
+				t.Skip("Known flaky test. Ticket JIRA-B. To run quarantined tests, set the RUN_QUARANTINED_TESTS environment variable to true.")
+			}
+			t.Parallel()
+		})
+	}
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testFile, err := parseTestFile("example_test.go", []byte(generatorTestSource))
+			require.NoError(t, err)
+			modifiedSource, quarantinedTests, err := skipTests(
+				testFile,
+				testsInFile(testFile.Node, target, nil),
+				nil,
+				test.generator,
+			)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSource, modifiedSource)
+			require.Len(t, quarantinedTests, len(target.Tests))
+
+			// Quarantining again is a no-op, and a new ticket only changes the ticket
+			testFile, err = parseTestFile("example_test.go", []byte(modifiedSource))
+			require.NoError(t, err)
+			requarantinedSource, quarantinedTests, err := skipTests(
+				testFile,
+				testsInFile(testFile.Node, target, nil),
+				nil,
+				test.generator,
+			)
+			require.NoError(t, err)
+			assert.Equal(t, modifiedSource, requarantinedSource)
+			for _, quarantinedTest := range quarantinedTests {
+				assert.True(t, quarantinedTest.AlreadyQuarantined, "%s should already be quarantined", quarantinedTest.Name)
+			}
+
+			newTicketTarget := QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA", JiraTicket: "JIRA-NEW"}}}
+			newTicketSource, quarantinedTests, err := skipTests(
+				testFile,
+				testsInFile(testFile.Node, newTicketTarget, nil),
+				nil,
+				test.generator,
+			)
+			require.NoError(t, err)
+			require.Len(t, quarantinedTests, 1)
+			assert.Equal(t, "JIRA-A", quarantinedTests[0].PreviousJiraTicket)
+			assert.Contains(t, newTicketSource, "JIRA-NEW")
+			assert.NotContains(t, newTicketSource, "JIRA-A")
+
+			// Un-quarantining with the generator, or the default one for the built-ins, restores the original
+			testFile, err = parseTestFile("example_test.go", []byte(modifiedSource))
+			require.NoError(t, err)
+			unquarantinedSource, unquarantinedTests, err := unskipTests(
+				testFile,
+				testsInFile(testFile.Node, target, nil),
+				nil,
+				unquarantineGenerators(test.generator),
+			)
+			require.NoError(t, err)
+			assert.Equal(t, generatorTestSource, unquarantinedSource)
+			require.Len(t, unquarantinedTests, len(target.Tests))
+			assert.Equal(t, "JIRA-A", unquarantinedTests[0].JiraTicket)
+		})
+	}
+}
+
+func TestFlakyGenerator_Options(t *testing.T) {
+	t.Parallel()
+
+	until := time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC)
+	target := QuarantineTarget{Tests: []TestToQuarantine{
+		{Name: "TestA", JiraTicket: "JIRA-A", Until: until, Variant: "linux+race"},
+		{Name: "TestB/1", JiraTicket: "JIRA-B", Until: until},
+	}}
+
+	testFile, err := parseTestFile("example_test.go", []byte(generatorTestSource))
+	require.NoError(t, err)
+	modifiedSource, _, err := skipTests(testFile, testsInFile(testFile.Node, target, nil), nil, FlakyGenerator())
+	require.NoError(t, err)
+	assert.Contains(
+		t,
+		modifiedSource,
+		`quarantine.Flaky(t, "JIRA-A", quarantine.Variant("linux+race"), quarantine.Until("2026-12-01"))`,
+	)
+	assert.Contains(
+		t,
+		modifiedSource,
+		`quarantine.Flaky(t, "JIRA-B", quarantine.IfNamed("TestB/1"), quarantine.Until("2026-12-01"))`,
+	)
+
+	// Quarantines with options are recognized when un-quarantining
+	testFile, err = parseTestFile("example_test.go", []byte(modifiedSource))
+	require.NoError(t, err)
+	unquarantinedSource, unquarantinedTests, err := unskipTests(
+		testFile,
+		testsInFile(testFile.Node, target, nil),
+		nil,
+		unquarantineGenerators(FlakyGenerator()),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, generatorTestSource, unquarantinedSource)
+	require.Len(t, unquarantinedTests, 2)
+	assert.Equal(t, "JIRA-B", unquarantinedTests[1].JiraTicket)
+
+	site := QuarantineSite{ImportName: "quarantine", TestingParam: "m", Ticket: "JIRA-P", Package: true, Until: until}
+	guard := FlakyGenerator().Generate(site)
+	formatted, err := formatStmt(guard, "")
+	require.NoError(t, err)
+	assert.Equal(t, "if quarantine.FlakyPackage(\"JIRA-P\", quarantine.Until(\"2026-12-01\")) {\n\treturn\n}\n", formatted)
+	ticket, _, ok := FlakyGenerator().Match(guard, site)
+	require.True(t, ok, "expiring package guard should be recognized")
+	assert.Equal(t, "JIRA-P", ticket)
+}
+
+func TestGenerators_Suite(t *testing.T) {
+	t.Parallel()
+
+	site := QuarantineSite{ImportName: "pkg", TestingParam: "s", Suite: true, TestName: "TestS/TestA", Ticket: "JIRA-A"}
+	tests := []struct {
+		name      string
+		generator Generator
+		expected  string
+	}{
+		{name: "flaky", generator: FlakyGenerator(), expected: `pkg.FlakySuite(s, "JIRA-A")`},
+		{
+			name:      "func",
+			generator: FuncGenerator("example.com/pkg", "Quarantine"),
+			expected:  `pkg.Quarantine(s.T(), "JIRA-A")`,
+		},
+		{
+			name:      "inline skip",
+			generator: InlineSkipGenerator(),
+			expected: "if pkg.Getenv(\"RUN_QUARANTINED_TESTS\") != \"true\" {\n" +
+				"\ts.T().Skip(\"Known flaky test. Ticket JIRA-A. " +
+				"To run quarantined tests, set the RUN_QUARANTINED_TESTS environment variable to true.\")\n}",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			stmt := test.generator.Generate(site)
+			formatted, err := formatStmt(stmt, "")
+			require.NoError(t, err)
+			assert.Equal(t, test.expected+"\n", formatted)
+
+			ticket, ticketExpr, ok := test.generator.Match(stmt, site)
+			require.True(t, ok, "generated statement should be recognized")
+			assert.Equal(t, "JIRA-A", ticket)
+			assert.NotNil(t, ticketExpr)
+
+			_, _, ok = test.generator.Match(stmt, QuarantineSite{ImportName: "pkg", TestingParam: "t"})
+			assert.False(t, ok, "statement for a suite should not be recognized for a test function")
+		})
+	}
+}
+
+func TestParseGenerator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		expectedGenerator Generator
+		expectedErr       error
+	}{
+		{name: "", expectedGenerator: FlakyGenerator()},
+		{name: "flaky", expectedGenerator: FlakyGenerator()},
+		{name: "inline-skip", expectedGenerator: InlineSkipGenerator()},
+		{
+			name:              "github.com/org/repo/testutil.Quarantine",
+			expectedGenerator: FuncGenerator("github.com/org/repo/testutil", "Quarantine"),
+		},
+		{name: "github.com/org/repo/testutil.quarantine", expectedErr: ErrUnknownGenerator},
+		{name: "github.com/org/repo", expectedErr: ErrUnknownGenerator},
+		{name: "skip", expectedErr: ErrUnknownGenerator},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			generator, err := ParseGenerator(test.name)
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedGenerator, generator)
+		})
+	}
+}
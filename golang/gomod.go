@@ -0,0 +1,336 @@
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/dirhash"
+
+	"github.com/smartcontractkit/branch-out/base"
+)
+
+const (
+	// quarantineModulePath is the path of the Go module that provides the quarantine package.
+	// It's a module of its own, without any dependencies, so that requiring it doesn't pull in those of branch-out.
+	quarantineModulePath = "github.com/smartcontractkit/branch-out/quarantine"
+	// legacyQuarantineModulePath is the path of the module that provided the quarantine package before it was split
+	// into its own module. Modules that require it already have the quarantine package.
+	legacyQuarantineModulePath = "github.com/smartcontractkit/branch-out"
+	// replacedModuleVersion is the version the go tool requires modules at when they're replaced by a local directory.
+	replacedModuleVersion = "v0.0.0-00010101000000-000000000000"
+	// defaultChecksumDBURL is the URL of the checksum database that the go tool verifies public modules with.
+	defaultChecksumDBURL = "https://sum.golang.org"
+)
+
+var (
+	// ErrNoQuarantineModuleVersion is returned when there is no version of the quarantine module to add to a go.mod file.
+	ErrNoQuarantineModuleVersion = errors.New("no version of the quarantine module to require")
+	// ErrNoQuarantineModuleChecksums is returned when the go.sum hashes of the quarantine module can't be found,
+	// neither in the local module cache nor in the checksum database.
+	ErrNoQuarantineModuleChecksums = errors.New("no checksums of the quarantine module for go.sum")
+)
+
+// WithChecksumDB sets the URL of the checksum database that the go.sum hashes of the quarantine module are looked up
+// in, when the local module cache doesn't have them. Defaults to https://sum.golang.org.
+// An empty URL keeps the default.
+func WithChecksumDB(url string) QuarantineOption {
+	return func(options *quarantineOptions) {
+		if url != "" {
+			options.checksumDBURL = url
+		}
+	}
+}
+
+// WithQuarantineModuleVersion sets the version of the quarantine module that go.mod files are updated to require.
+// Defaults to the version of the quarantine module that branch-out requires, see defaultQuarantineModuleVersion.
+// An empty version keeps the default.
+func WithQuarantineModuleVersion(version string) QuarantineOption {
+	return func(options *quarantineOptions) {
+		if version != "" {
+			options.quarantineModuleVersion = version
+		}
+	}
+}
+
+// defaultQuarantineModuleVersion returns the version of the quarantine module that this binary was built with.
+// That's the release branch-out's go.mod requires, even when it's replaced by the local copy of the module.
+// Returns the empty string if it's only required at the placeholder version of replaced modules.
+func defaultQuarantineModuleVersion() string {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, mod := range buildInfo.Deps {
+		if mod.Path == quarantineModulePath && mod.Version != "" && mod.Version != replacedModuleVersion {
+			return mod.Version
+		}
+	}
+	return ""
+}
+
+// requireQuarantineModule updates the go.mod and go.sum files of every module where tests were newly quarantined,
+// so that they require the quarantine module. This replaces `go mod tidy`, which needs network access, is slow in
+// large repos, and can update unrelated dependencies.
+// The updated files are added to the results of the first package of each module, see QuarantinePackageResults.ModuleFiles.
+func requireQuarantineModule(
+	l zerolog.Logger,
+	repoPath string,
+	results QuarantineResults,
+	version, checksumDBURL string,
+) error {
+	// Go module directory -> import path of the first package in it that needs the quarantine module
+	modulePackages := make(map[string]string)
+	for importPath, result := range results {
+		if result.GoModDir == "" || !slices.ContainsFunc(result.Successes, QuarantinedFile.Modified) {
+			continue
+		}
+		if existing, ok := modulePackages[result.GoModDir]; !ok || importPath < existing {
+			modulePackages[result.GoModDir] = importPath
+		}
+	}
+
+	for goModDir, importPath := range modulePackages {
+		moduleFiles, err := goModRequireEdits(l, repoPath, goModDir, version, checksumDBURL)
+		if err != nil {
+			return fmt.Errorf("failed to update go.mod in %s: %w", goModDir, err)
+		}
+		result := results[importPath]
+		result.ModuleFiles = moduleFiles
+		results[importPath] = result
+	}
+	return nil
+}
+
+// goModRequireEdits returns the go.mod and go.sum files of the module updated to require the quarantine module.
+// Nothing is returned if the module already requires it, or the legacy module that provided it before,
+// or is one of them itself.
+// The go.sum hashes come from the local module cache, or the checksum database if it doesn't have them.
+func goModRequireEdits(l zerolog.Logger, repoPath, goModDir, version, checksumDBURL string) ([]QuarantinedFile, error) {
+	goModPath := filepath.Join(goModDir, "go.mod")
+	goModSource, err := os.ReadFile(goModPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	goMod, err := modfile.Parse(goModPath, goModSource, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+
+	providesQuarantine := func(modulePath string) bool {
+		return modulePath == quarantineModulePath || modulePath == legacyQuarantineModulePath
+	}
+	if goMod.Module != nil && providesQuarantine(goMod.Module.Mod.Path) {
+		return nil, nil
+	}
+	for _, require := range goMod.Require {
+		if providesQuarantine(require.Mod.Path) {
+			return nil, nil
+		}
+	}
+
+	// Replaced modules are required at a placeholder version, and don't need go.sum entries
+	replaced := false
+	for _, replace := range goMod.Replace {
+		if replace.Old.Path == quarantineModulePath {
+			replaced = true
+			version = replace.Old.Version
+			if version == "" {
+				version = replacedModuleVersion
+			}
+		}
+	}
+	if version == "" {
+		return nil, fmt.Errorf(
+			"%w: set one with WithQuarantineModuleVersion",
+			ErrNoQuarantineModuleVersion,
+		)
+	}
+
+	// Keep direct requirements in their own block, like the go tool does
+	requires := make([]*modfile.Require, 0, len(goMod.Require)+1)
+	requires = append(requires, goMod.Require...)
+	requires = append(requires, &modfile.Require{Mod: module.Version{Path: quarantineModulePath, Version: version}})
+	goMod.SetRequireSeparateIndirect(requires)
+	goMod.Cleanup()
+	modifiedGoMod, err := goMod.Format()
+	if err != nil {
+		return nil, fmt.Errorf("failed to format go.mod: %w", err)
+	}
+
+	goModFile, err := moduleFile(repoPath, goModPath, string(goModSource), string(modifiedGoMod))
+	if err != nil {
+		return nil, err
+	}
+	moduleFiles := []QuarantinedFile{goModFile}
+	l = l.With().Str("go_mod", goModPath).Str("version", version).Logger()
+	l.Debug().Msg("Added quarantine module to go.mod")
+	if replaced {
+		return moduleFiles, nil
+	}
+
+	sums, err := moduleCacheSums(quarantineModulePath, version)
+	if err != nil {
+		l.Debug().Err(err).Str("checksum_db", checksumDBURL).Msg("Looking up checksums of the quarantine module")
+		sums, err = checksumDBSums(l, checksumDBURL, quarantineModulePath, version)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNoQuarantineModuleChecksums, err)
+		}
+	}
+	goSumPath := filepath.Join(goModDir, "go.sum")
+	goSumSource, err := os.ReadFile(goSumPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read go.sum: %w", err)
+	}
+	created := errors.Is(err, os.ErrNotExist)
+	goSumFile, err := moduleFile(repoPath, goSumPath, string(goSumSource), addGoSumLines(string(goSumSource), sums))
+	if err != nil {
+		return nil, err
+	}
+	goSumFile.Created = created
+	return append(moduleFiles, goSumFile), nil
+}
+
+// moduleFile describes an updated go.mod or go.sum file.
+func moduleFile(repoPath, path, originalSource, modifiedSource string) (QuarantinedFile, error) {
+	relativeFilePath, err := relativePath(repoPath, path)
+	if err != nil {
+		return QuarantinedFile{}, err
+	}
+	return QuarantinedFile{
+		File:               relativeFilePath,
+		FileAbs:            path,
+		OriginalSourceCode: originalSource,
+		ModifiedSourceCode: modifiedSource,
+	}, nil
+}
+
+// moduleCacheSums returns the go.sum lines of a module version, using the hashes in the local module cache.
+func moduleCacheSums(modulePath, version string) ([]string, error) {
+	escapedPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	downloadDir := filepath.Join(moduleCacheDir(), "cache", "download", escapedPath, "@v")
+
+	zipHash, err := os.ReadFile(filepath.Join(downloadDir, escapedVersion+".ziphash"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module zip hash: %w", err)
+	}
+	goModHash, err := dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(downloadDir, escapedVersion+".mod"))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash module go.mod: %w", err)
+	}
+	return []string{
+		fmt.Sprintf("%s %s %s", modulePath, version, strings.TrimSpace(string(zipHash))),
+		fmt.Sprintf("%s %s/go.mod %s", modulePath, version, goModHash),
+	}, nil
+}
+
+// checksumDBSums returns the go.sum lines of a module version, looking up its hashes in the checksum database.
+// See https://go.dev/design/25530-sumdb#checksum-database for the lookup endpoint.
+func checksumDBSums(l zerolog.Logger, checksumDBURL, modulePath, version string) ([]string, error) {
+	escapedPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	lookupURL := fmt.Sprintf("%s/lookup/%s@%s", strings.TrimSuffix(checksumDBURL, "/"), escapedPath, escapedVersion)
+
+	client := base.NewClient("checksum_db", base.WithLogger(l))
+	resp, err := client.Get(lookupURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up checksums at %s: %w", lookupURL, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			l.Error().Err(closeErr).Msg("Failed to close response body")
+		}
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksums from %s: %w", lookupURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(
+			"failed to look up checksums at %s: %s: %s",
+			lookupURL,
+			resp.Status,
+			strings.TrimSpace(string(body)),
+		)
+	}
+
+	// The record is the go.sum lines of the module version, between its ID and the signed tree
+	var zipLine, goModLine string
+	for _, line := range strings.Split(string(body), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != modulePath || !strings.HasPrefix(fields[2], "h1:") {
+			continue
+		}
+		switch fields[1] {
+		case version:
+			zipLine = line
+		case version + "/go.mod":
+			goModLine = line
+		}
+	}
+	if zipLine == "" || goModLine == "" {
+		return nil, fmt.Errorf("no checksums of %s@%s in the response of %s", modulePath, version, lookupURL)
+	}
+	return []string{zipLine, goModLine}, nil
+}
+
+// moduleCacheDir returns the directory of the local module cache.
+func moduleCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" { //nolint:forbidigo // Same lookup as the go tool
+		return dir
+	}
+	return filepath.Join(filepath.SplitList(build.Default.GOPATH)[0], "pkg", "mod")
+}
+
+// addGoSumLines adds the lines to go.sum after the lines of modules with paths that sort before theirs,
+// leaving the existing lines untouched.
+func addGoSumLines(goSum string, lines []string) string {
+	existing := strings.SplitAfter(goSum, "\n")
+	if existing[len(existing)-1] == "" {
+		existing = existing[:len(existing)-1]
+	}
+	if len(existing) > 0 && !strings.HasSuffix(existing[len(existing)-1], "\n") {
+		existing[len(existing)-1] += "\n"
+	}
+
+	for _, line := range lines {
+		if slices.Contains(existing, line+"\n") {
+			continue
+		}
+		modulePath, _, _ := strings.Cut(line, " ")
+		insertAt := len(existing)
+		for i, existingLine := range existing {
+			if existingPath, _, _ := strings.Cut(existingLine, " "); existingPath > modulePath {
+				insertAt = i
+				break
+			}
+		}
+		existing = slices.Insert(existing, insertAt, line+"\n")
+	}
+	return strings.Join(existing, "")
+}
@@ -0,0 +1,192 @@
+package golang
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checksumDB serves the checksums of the quarantine module at the versions, like sum.golang.org's lookup endpoint.
+func checksumDB(t *testing.T, versions ...string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for _, version := range versions {
+		mux.HandleFunc(
+			fmt.Sprintf("/lookup/%s@%s", quarantineModulePath, version),
+			func(w http.ResponseWriter, _ *http.Request) {
+				fmt.Fprintf(w, "42\n%s\n\ngo.sum database tree\n43\nhash=\n\n— sum.golang.org signature=\n",
+					checksumDBLines(version))
+			},
+		)
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// checksumDBLines are the go.sum lines that checksumDB serves for the version of the quarantine module.
+func checksumDBLines(version string) string {
+	return fmt.Sprintf("%s %s h1:zip%s=\n%s %s/go.mod h1:mod%s=", quarantineModulePath, version, version,
+		quarantineModulePath, version, version)
+}
+
+func TestGoModRequireEdits(t *testing.T) {
+	t.Parallel()
+
+	server := checksumDB(t, "v1.2.0")
+
+	tests := []struct {
+		name          string
+		goMod         string
+		version       string
+		expectedGoMod string // Empty if go.mod should not be updated
+		expectedGoSum string // Empty if go.sum should not be updated
+		expectedErr   error
+	}{
+		{
+			name:    "already required",
+			goMod:   "module example.com/a\n\ngo 1.24\n\nrequire github.com/smartcontractkit/branch-out/quarantine v1.0.0\n",
+			version: "v1.2.0",
+		},
+		{
+			name:    "legacy module required",
+			goMod:   "module example.com/a\n\ngo 1.24\n\nrequire github.com/smartcontractkit/branch-out v1.0.0\n",
+			version: "v1.2.0",
+		},
+		{
+			name:    "quarantine module itself",
+			goMod:   "module github.com/smartcontractkit/branch-out/quarantine\n\ngo 1.24\n",
+			version: "v1.2.0",
+		},
+		{
+			name:    "replaced",
+			goMod:   "module example.com/a\n\ngo 1.24\n\nreplace github.com/smartcontractkit/branch-out/quarantine => ../..\n",
+			version: "",
+			expectedGoMod: "module example.com/a\n\ngo 1.24\n\n" +
+				"replace github.com/smartcontractkit/branch-out/quarantine => ../..\n\n" +
+				"require github.com/smartcontractkit/branch-out/quarantine v0.0.0-00010101000000-000000000000\n",
+		},
+		{
+			name: "existing requirements",
+			goMod: "module example.com/a\n\ngo 1.24\n\n" +
+				"require github.com/stretchr/testify v1.10.0\n\n" +
+				"require github.com/davecgh/go-spew v1.1.1 // indirect\n",
+			version: "v1.2.0",
+			expectedGoMod: "module example.com/a\n\ngo 1.24\n\n" +
+				"require (\n" +
+				"\tgithub.com/smartcontractkit/branch-out/quarantine v1.2.0\n" +
+				"\tgithub.com/stretchr/testify v1.10.0\n" +
+				")\n\n" +
+				"require github.com/davecgh/go-spew v1.1.1 // indirect\n",
+			expectedGoSum: checksumDBLines("v1.2.0") + "\n",
+		},
+		{
+			name:        "no checksums",
+			goMod:       "module example.com/a\n\ngo 1.24\n",
+			version:     "v1.3.0",
+			expectedErr: ErrNoQuarantineModuleChecksums,
+		},
+		{
+			name:        "no version",
+			goMod:       "module example.com/a\n\ngo 1.24\n",
+			version:     "",
+			expectedErr: ErrNoQuarantineModuleVersion,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := t.TempDir()
+			goModPath := filepath.Join(repoPath, "go.mod")
+			require.NoError(t, os.WriteFile(goModPath, []byte(test.goMod), 0600))
+
+			files, err := goModRequireEdits(zerolog.Nop(), repoPath, repoPath, test.version, server.URL)
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			if test.expectedGoMod == "" {
+				assert.Empty(t, files, "go.mod should not be updated")
+				return
+			}
+
+			require.NotEmpty(t, files)
+			assert.Equal(t, "go.mod", files[0].File)
+			assert.Equal(t, goModPath, files[0].FileAbs)
+			assert.Equal(t, test.goMod, files[0].OriginalSourceCode)
+			assert.Equal(t, test.expectedGoMod, files[0].ModifiedSourceCode)
+			if test.expectedGoSum == "" {
+				assert.Len(t, files, 1, "go.sum should not be updated")
+				return
+			}
+			require.Len(t, files, 2)
+			assert.Equal(t, "go.sum", files[1].File)
+			assert.True(t, files[1].Created, "go.sum should be created")
+			assert.Equal(t, test.expectedGoSum, files[1].ModifiedSourceCode)
+		})
+	}
+}
+
+func TestQuarantineTests_RequiresQuarantineModule(t *testing.T) {
+	t.Parallel()
+
+	repoPath := t.TempDir()
+	goModPath := filepath.Join(repoPath, "go.mod")
+	require.NoError(t, os.WriteFile(goModPath, []byte("module example.com/a\n\ngo 1.24\n"), 0600))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(repoPath, "a_test.go"),
+		[]byte("package a\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n"),
+		0600,
+	))
+
+	results, err := QuarantineTests(
+		zerolog.Nop(),
+		repoPath,
+		[]QuarantineTarget{
+			{Package: "example.com/a", Tests: []TestToQuarantine{{Name: "TestA", JiraTicket: "JIRA-1"}}},
+		},
+		WithChecksumDB(checksumDB(t, defaultQuarantineModuleVersion()).URL),
+	)
+	require.NoError(t, err, "the default version of the quarantine module should be required")
+	require.Equal(t, 1, results.SuccessfulTestsCount())
+
+	moduleFiles := results["example.com/a"].ModuleFiles
+	require.NotEmpty(t, moduleFiles)
+	assert.Equal(t, "go.mod", moduleFiles[0].File)
+	assert.Contains(t, moduleFiles[0].ModifiedSourceCode, quarantineModulePath+" "+defaultQuarantineModuleVersion())
+	assert.NotContains(t, moduleFiles[0].ModifiedSourceCode, replacedModuleVersion)
+}
+
+func TestAddGoSumLines(t *testing.T) {
+	t.Parallel()
+
+	goSum := "github.com/a/a v1.0.0 h1:a=\n" +
+		"github.com/a/a v1.0.0/go.mod h1:amod=\n" +
+		"github.com/z/z v1.10.0 h1:z10=\n" +
+		"github.com/z/z v1.9.0 h1:z9=\n"
+	lines := []string{
+		"github.com/m/m v1.0.0 h1:m=",
+		"github.com/m/m v1.0.0/go.mod h1:mmod=",
+	}
+
+	expected := "github.com/a/a v1.0.0 h1:a=\n" +
+		"github.com/a/a v1.0.0/go.mod h1:amod=\n" +
+		"github.com/m/m v1.0.0 h1:m=\n" +
+		"github.com/m/m v1.0.0/go.mod h1:mmod=\n" +
+		"github.com/z/z v1.10.0 h1:z10=\n" +
+		"github.com/z/z v1.9.0 h1:z9=\n"
+	assert.Equal(t, expected, addGoSumLines(goSum, lines))
+	assert.Equal(t, expected, addGoSumLines(expected, lines), "existing lines should not be added again")
+	assert.Equal(t, lines[0]+"\n"+lines[1]+"\n", addGoSumLines("", lines))
+}
@@ -0,0 +1,362 @@
+package golang
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+// InventoryEntry is a quarantined test found in a repository, see FindQuarantinedTests.
+type InventoryEntry struct {
+	Package string `json:"package"`           // Import path of the test's package
+	Test    string `json:"test"`              // Full name of the test, e.g. "TestFoo/subtest_1", "TestMain" for packages
+	File    string `json:"file"`              // Path of the file quarantining the test, relative to the repository
+	Line    int    `json:"line"`              // Line of the quarantine in the file
+	Ticket  string `json:"ticket"`            // Ticket the test is quarantined for, empty if it isn't a string literal
+	Until   string `json:"until,omitempty"`   // Date the quarantine expires after, see quarantine.Until
+	Variant string `json:"variant,omitempty"` // Variant the test is quarantined on, see quarantine.Variant
+}
+
+// Inventory lists the quarantined tests of a repository, sorted by package, test and location.
+type Inventory []InventoryEntry
+
+// RepositoryTests are the tests of a repository, and the quarantined ones among them, see FindTests.
+type RepositoryTests struct {
+	Modules     []string            // Paths of the repository's modules
+	Tests       map[string][]string // Full names of the test functions and suite methods of each package
+	Quarantined Inventory           // Quarantined tests of the repository, see FindQuarantinedTests
+}
+
+// FindQuarantinedTests lists every test quarantined in the repository: the tests quarantined with quarantine.Flaky
+// or quarantine.FlakySuite, the packages quarantined with quarantine.FlakyPackage in their TestMain, and the tests
+// listed in the repository's quarantine manifest.
+//
+// Quarantines are recognized the same way they are when un-quarantining. Subtests are named after the t.Run call
+// they're in, or the table test entry or quarantine.IfNamed option they're guarded by.
+func FindQuarantinedTests(l zerolog.Logger, repoPath string, options ...QuarantineOption) (Inventory, error) {
+	tests, err := FindTests(l, repoPath, options...)
+	if err != nil {
+		return nil, err
+	}
+	return tests.Quarantined, nil
+}
+
+// FindTests lists every test of the repository, keyed by package, along with the quarantined ones,
+// see FindQuarantinedTests.
+func FindTests(l zerolog.Logger, repoPath string, options ...QuarantineOption) (*RepositoryTests, error) {
+	start := time.Now()
+	quarantineOptions := newQuarantineOptions(options...)
+	packages, err := Packages(l, repoPath, quarantineOptions.buildFlags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+
+	var (
+		tests     = &RepositoryTests{Tests: make(map[string][]string, len(packages.Packages))}
+		inventory Inventory
+	)
+	for _, importPath := range slices.Sorted(maps.Keys(packages.Packages)) {
+		pkg := packages.Packages[importPath]
+		if pkg.Module != nil && !slices.Contains(tests.Modules, pkg.Module.Path) {
+			tests.Modules = append(tests.Modules, pkg.Module.Path)
+		}
+		testFiles, suites, err := parseTestFiles(pkg, "listing")
+		if err != nil {
+			return nil, err
+		}
+		tests.Tests[pkg.ImportPath] = packageTestNames(testFiles, suites)
+		for _, testFile := range testFiles {
+			file, err := relativePath(repoPath, testFile.Path)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range fileQuarantines(testFile, suites) {
+				entry.Package, entry.File = pkg.ImportPath, file
+				inventory = append(inventory, entry)
+			}
+		}
+	}
+
+	manifestEntries, err := manifestInventory(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	inventory = append(inventory, manifestEntries...)
+
+	slices.SortStableFunc(inventory, func(a, b InventoryEntry) int {
+		return cmp.Or(
+			strings.Compare(a.Package, b.Package),
+			strings.Compare(a.Test, b.Test),
+			strings.Compare(a.File, b.File),
+			cmp.Compare(a.Line, b.Line),
+		)
+	})
+	tests.Quarantined = inventory
+	slices.Sort(tests.Modules)
+	l.Info().
+		Int("packages", len(tests.Tests)).
+		Int("quarantined_tests", len(inventory)).
+		Str("duration", time.Since(start).String()).
+		Msg("Found tests")
+	return tests, nil
+}
+
+// Owns checks if the package belongs to one of the repository's modules, even if it doesn't exist anymore.
+func (r *RepositoryTests) Owns(pkg string) bool {
+	return slices.ContainsFunc(r.Modules, func(module string) bool {
+		return pkg == module || strings.HasPrefix(pkg, module+"/")
+	})
+}
+
+// Exists checks if the test is in the repository. Subtests exist if their test function or suite method does,
+// and TestMain if its package does.
+func (r *RepositoryTests) Exists(pkg, test string) bool {
+	names, ok := r.Tests[pkg]
+	if !ok {
+		return false
+	}
+	if test == PackageTestName {
+		return true
+	}
+	return slices.ContainsFunc(names, func(name string) bool {
+		return test == name || strings.HasPrefix(test, name+"/")
+	})
+}
+
+// Covering returns the quarantine that covers the test on the variant: the quarantine of the test itself, of its
+// parent test or of its package, on every variant or on the same one. ok is false if the test isn't quarantined.
+func (i Inventory) Covering(pkg, test, variant string) (entry InventoryEntry, ok bool) {
+	for _, entry := range i {
+		if entry.Package != pkg || (entry.Variant != "" && entry.Variant != variant) {
+			continue
+		}
+		if entry.Test == test || entry.Test == PackageTestName || strings.HasPrefix(test, entry.Test+"/") {
+			return entry, true
+		}
+	}
+	return InventoryEntry{}, false
+}
+
+// fileQuarantines finds the quarantines of a test file, without their package and file.
+func fileQuarantines(testFile parsedTestFile, suites suiteRunners) []InventoryEntry {
+	node := testFile.Node
+	importName := importLocalName(node, quarantineImportPath)
+	if importName == "" || importName == "_" || importName == "." {
+		return nil
+	}
+
+	// Suite methods are reported under every test function that runs their suite
+	suiteRunnersByType := make(map[string][]string)
+	for _, runner := range slices.Sorted(maps.Keys(suites)) {
+		suiteRunnersByType[suites[runner]] = append(suiteRunnersByType[suites[runner]], runner)
+	}
+
+	finder := quarantineFinder{file: testFile, importName: importName}
+	for _, decl := range node.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Body == nil {
+			continue
+		}
+		switch {
+		case funcDecl.Recv != nil && len(funcDecl.Recv.List) == 1:
+			for _, runner := range suiteRunnersByType[typeName(funcDecl.Recv.List[0].Type)] {
+				finder.body(funcDecl.Body, runner+"/"+funcDecl.Name.Name, "", "")
+			}
+		case funcDecl.Name.Name == PackageTestName:
+			finder.testMain(funcDecl.Body)
+		case isTestFunction(node, funcDecl):
+			finder.body(funcDecl.Body, funcDecl.Name.Name, "", testingParamName(funcDecl.Type))
+		}
+	}
+	return finder.entries
+}
+
+// quarantineFinder collects the quarantines of the tests of a file.
+type quarantineFinder struct {
+	file       parsedTestFile
+	importName string
+	entries    []InventoryEntry
+}
+
+// add records a quarantine call of the test.
+func (f *quarantineFinder) add(call *ast.CallExpr, testName string) {
+	ticket, _ := quarantineTicket(call)
+	if len(call.Args) > 0 && types.ExprString(call.Fun) == f.importName+".FlakyPackage" {
+		ticket, _ = stringLiteral(call.Args[0]) // FlakyPackage doesn't take a testing.TB
+	}
+	f.entries = append(f.entries, InventoryEntry{
+		Test:    testName,
+		Line:    f.file.Fset.Position(call.Pos()).Line,
+		Ticket:  ticket,
+		Until:   optionArg(call, f.importName, "Until"),
+		Variant: optionArg(call, f.importName, "Variant"),
+	})
+}
+
+// testMain records the quarantine.FlakyPackage guard of a TestMain, quarantining the whole package.
+func (f *quarantineFinder) testMain(body *ast.BlockStmt) {
+	site := QuarantineSite{ImportName: f.importName, Package: true}
+	for _, stmt := range body.List {
+		if _, _, ok := FlakyGenerator().Match(stmt, site); ok {
+			f.add(stmt.(*ast.IfStmt).Cond.(*ast.CallExpr), PackageTestName)
+		}
+	}
+}
+
+// body records the quarantines at the top of a test's body, and those of the subtests it runs with t.Run calls
+// on its testing parameter. Suite methods, which don't have one, only have their own quarantines recorded.
+// runName is the expression naming the subtest the body runs, if it isn't a string literal, e.g. tc.name,
+// for table test quarantines guarded by it to be named after the table test entry instead.
+func (f *quarantineFinder) body(body *ast.BlockStmt, testName, runName, testingParam string) {
+	for _, stmt := range body.List {
+		call, guard, ok := guardedQuarantineCall(stmt, f.importName)
+		if !ok {
+			continue
+		}
+		name := testName
+		if ifNamed := ifNamedArg(call, f.importName); ifNamed != "" {
+			name = ifNamed
+		} else if subject, value, ok := guardValue(stmt, guard); ok && subject == runName {
+			name = strings.TrimSuffix(testName, runName) + subtestName(value)
+		} else if ok {
+			name = testName + "/" + subtestName(value)
+		}
+		f.add(call, name)
+	}
+	if testingParam == "" {
+		return
+	}
+
+	ast.Inspect(body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		closure, ok := runCall(call, testingParam)
+		if !ok {
+			return true
+		}
+		subtest, runName := "", types.ExprString(call.Args[0])
+		if value, ok := stringLiteral(call.Args[0]); ok {
+			subtest, runName = subtestName(value), ""
+		}
+		f.body(closure.Body, testName+"/"+subtest+runName, runName, testingParamName(closure.Type))
+		return false
+	})
+}
+
+// guardValue returns the subtest name a table test quarantine is guarded by, and what it's compared to,
+// e.g. "tc.name" and "subtest 1" for if tc.name == "subtest 1" { quarantine.Flaky(t, "JIRA-123") }.
+func guardValue(stmt ast.Stmt, guard string) (subject, value string, ok bool) {
+	if guard == "" {
+		return "", "", false
+	}
+	cond, isBinary := stmt.(*ast.IfStmt).Cond.(*ast.BinaryExpr)
+	if !isBinary || cond.Op != token.EQL {
+		return "", "", false
+	}
+	value, ok = stringLiteral(cond.Y)
+	return types.ExprString(cond.X), value, ok
+}
+
+// manifestInventory lists the tests of the repository's quarantine manifest, if it has one.
+func manifestInventory(repoPath string) (Inventory, error) {
+	manifest, err := ReadManifest(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	lines := manifestLines(manifest)
+	inventory := make(Inventory, 0, len(manifest.Tests))
+	for _, test := range manifest.Tests {
+		inventory = append(inventory, InventoryEntry{
+			Package: test.Package,
+			Test:    test.Test,
+			File:    filepath.ToSlash(quarantine.ManifestPath),
+			Line:    lines[manifestKey(test.Package, test.Test, test.Variant)],
+			Ticket:  test.Ticket,
+			Variant: test.Variant,
+		})
+	}
+	return inventory, nil
+}
+
+// inventoryColumns are the columns of the CSV and Markdown forms of an inventory.
+var inventoryColumns = []string{"Package", "Test", "File", "Line", "Ticket", "Until", "Variant"}
+
+// fields returns the values of the entry's columns, see inventoryColumns.
+func (e InventoryEntry) fields() []string {
+	return []string{e.Package, e.Test, e.File, strconv.Itoa(e.Line), e.Ticket, e.Until, e.Variant}
+}
+
+// WriteJSON writes the inventory as an indented JSON array.
+func (i Inventory) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if i == nil {
+		i = Inventory{} // Written as [], not null
+	}
+	if err := encoder.Encode(i); err != nil {
+		return fmt.Errorf("failed to write inventory as JSON: %w", err)
+	}
+	return nil
+}
+
+// WriteCSV writes the inventory as CSV, with a header row.
+func (i Inventory) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(inventoryColumns); err != nil {
+		return fmt.Errorf("failed to write inventory as CSV: %w", err)
+	}
+	for _, entry := range i {
+		if err := writer.Write(entry.fields()); err != nil {
+			return fmt.Errorf("failed to write inventory as CSV: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write inventory as CSV: %w", err)
+	}
+	return nil
+}
+
+// Markdown returns a Markdown table of the inventory, linking each test to its quarantine if the repository's
+// owner, repo and branch on GitHub are given. Good for a report or an issue.
+func (i Inventory) Markdown(owner, repo, branch string) string {
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("## Quarantined Tests\n\n%d tests are quarantined.\n\n", len(i)))
+	if len(i) == 0 {
+		return md.String()
+	}
+
+	md.WriteString("| " + strings.Join(inventoryColumns, " | ") + " |\n")
+	md.WriteString(strings.Repeat("| --- ", len(inventoryColumns)) + "|\n")
+	for _, entry := range i {
+		fields := entry.fields()
+		for index, field := range fields {
+			fields[index] = strings.ReplaceAll(field, "|", `\|`)
+		}
+		if owner != "" && repo != "" && branch != "" {
+			fields[2] = fmt.Sprintf(
+				"[%s](https://github.com/%s/%s/blob/%s/%s#L%d)",
+				fields[2], owner, repo, branch, filepath.ToSlash(entry.File), entry.Line,
+			)
+		}
+		md.WriteString("| " + strings.Join(fields, " | ") + " |\n")
+	}
+	return md.String()
+}
@@ -0,0 +1,237 @@
+package golang
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileQuarantines(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		source   string
+		expected []InventoryEntry
+	}{
+		{
+			name: "tests and options",
+			source: `package example
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-A")
+	t.Parallel()
+}
+
+func TestB(t *testing.T) {
+	t.Parallel()
+	quarantine.Flaky(t, "JIRA-B", quarantine.Until("2026-12-01"), quarantine.Variant("linux"))
+}
+
+func TestC(t *testing.T) {}
+`,
+			expected: []InventoryEntry{
+				{Test: "TestA", Line: 10, Ticket: "JIRA-A"},
+				{Test: "TestB", Line: 16, Ticket: "JIRA-B", Until: "2026-12-01", Variant: "linux"},
+			},
+		},
+		{
+			name: "subtests",
+			source: `package example
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {
+	t.Run("sub test", func(t *testing.T) {
+		quarantine.Flaky(t, "JIRA-A")
+	})
+
+	for _, tc := range []struct{ name string }{{name: "a"}, {name: "b"}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.name == "b" {
+				quarantine.Flaky(t, "JIRA-B")
+			}
+			quarantine.Flaky(t, "JIRA-C", quarantine.IfNamed("TestA/a"))
+		})
+	}
+}
+`,
+			expected: []InventoryEntry{
+				{Test: "TestA/sub_test", Line: 11, Ticket: "JIRA-A"},
+				{Test: "TestA/b", Line: 17, Ticket: "JIRA-B"},
+				{Test: "TestA/a", Line: 19, Ticket: "JIRA-C"},
+			},
+		},
+		{
+			name: "suites and packages",
+			source: `package example
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExampleSuite struct {
+	suite.Suite
+}
+
+func TestMain(m *testing.M) {
+	if quarantine.FlakyPackage("JIRA-A") {
+		return
+	}
+	m.Run()
+}
+
+func TestExampleSuite(t *testing.T) {
+	suite.Run(t, new(ExampleSuite))
+}
+
+func (s *ExampleSuite) TestB() {
+	quarantine.FlakySuite(s.T(), "JIRA-B")
+}
+`,
+			expected: []InventoryEntry{
+				{Test: PackageTestName, Line: 15, Ticket: "JIRA-A"},
+				{Test: "TestExampleSuite/TestB", Line: 26, Ticket: "JIRA-B"},
+			},
+		},
+		{
+			name: "renamed import",
+			source: `package example
+
+import (
+	"testing"
+
+	q "github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {
+	q.Flaky(t, "JIRA-A")
+}
+`,
+			expected: []InventoryEntry{{Test: "TestA", Line: 10, Ticket: "JIRA-A"}},
+		},
+		{
+			name: "no quarantine import",
+			source: `package example
+
+import "testing"
+
+func TestA(t *testing.T) {}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			parsed, err := parseTestFile("example_test.go", []byte(tt.source))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, fileQuarantines(parsed, findSuiteRunners(parsed.Node)))
+		})
+	}
+}
+
+func TestInventory_Write(t *testing.T) {
+	t.Parallel()
+
+	inventory := Inventory{
+		{Package: "example.com/a", Test: "TestA", File: "a/a_test.go", Line: 10, Ticket: "JIRA-A"},
+		{Package: "example.com/b", Test: "TestB|x", File: "b/b_test.go", Line: 5, Ticket: "JIRA-B", Variant: "linux"},
+	}
+
+	var jsonOutput bytes.Buffer
+	require.NoError(t, inventory.WriteJSON(&jsonOutput))
+	assert.JSONEq(t, `[
+		{"package": "example.com/a", "test": "TestA", "file": "a/a_test.go", "line": 10, "ticket": "JIRA-A"},
+		{"package": "example.com/b", "test": "TestB|x", "file": "b/b_test.go", "line": 5, "ticket": "JIRA-B",
+		 "variant": "linux"}
+	]`, jsonOutput.String())
+
+	jsonOutput.Reset()
+	require.NoError(t, Inventory(nil).WriteJSON(&jsonOutput))
+	assert.JSONEq(t, `[]`, jsonOutput.String())
+
+	var csvOutput bytes.Buffer
+	require.NoError(t, inventory.WriteCSV(&csvOutput))
+	assert.Equal(t, `Package,Test,File,Line,Ticket,Until,Variant
+example.com/a,TestA,a/a_test.go,10,JIRA-A,,
+example.com/b,TestB|x,b/b_test.go,5,JIRA-B,,linux
+`, csvOutput.String())
+
+	markdown := inventory.Markdown("org", "repo", "main")
+	assert.Contains(t, markdown, "2 tests are quarantined.")
+	assert.Contains(t, markdown,
+		"| example.com/a | TestA | [a/a_test.go](https://github.com/org/repo/blob/main/a/a_test.go#L10) | 10 | JIRA-A |  |  |")
+	assert.Contains(t, markdown, `| TestB\|x |`)
+	assert.NotContains(t, inventory.Markdown("", "", ""), "https://github.com")
+	assert.NotContains(t, Inventory(nil).Markdown("", "", ""), "| Package |")
+}
+
+func TestRepositoryTests(t *testing.T) {
+	t.Parallel()
+
+	tests := &RepositoryTests{
+		Modules: []string{"example.com/repo"},
+		Tests: map[string][]string{
+			"example.com/repo/a": {"TestA", "TestSuite/TestB"},
+			"example.com/repo/b": nil,
+		},
+	}
+
+	assert.True(t, tests.Owns("example.com/repo"))
+	assert.True(t, tests.Owns("example.com/repo/deleted"))
+	assert.False(t, tests.Owns("example.com/repository"))
+	assert.False(t, tests.Owns("example.com/other"))
+
+	assert.True(t, tests.Exists("example.com/repo/a", "TestA"))
+	assert.True(t, tests.Exists("example.com/repo/a", "TestA/subtest"))
+	assert.True(t, tests.Exists("example.com/repo/a", "TestSuite/TestB"))
+	assert.True(t, tests.Exists("example.com/repo/b", PackageTestName))
+	assert.False(t, tests.Exists("example.com/repo/a", "TestAB"))
+	assert.False(t, tests.Exists("example.com/repo/a", "TestSuite/TestC"))
+	assert.False(t, tests.Exists("example.com/repo/deleted", PackageTestName))
+}
+
+func TestInventory_Covering(t *testing.T) {
+	t.Parallel()
+
+	inventory := Inventory{
+		{Package: "example.com/a", Test: "TestA", Ticket: "JIRA-A"},
+		{Package: "example.com/a", Test: "TestB", Ticket: "JIRA-B", Variant: "linux"},
+		{Package: "example.com/b", Test: PackageTestName, Ticket: "JIRA-C"},
+	}
+
+	tests := []struct {
+		pkg, test, variant string
+		expectedTicket     string
+	}{
+		{pkg: "example.com/a", test: "TestA", expectedTicket: "JIRA-A"},
+		{pkg: "example.com/a", test: "TestA/subtest", variant: "linux", expectedTicket: "JIRA-A"},
+		{pkg: "example.com/a", test: "TestB", variant: "linux", expectedTicket: "JIRA-B"},
+		{pkg: "example.com/a", test: "TestB", variant: "windows"},
+		{pkg: "example.com/a", test: "TestAB"},
+		{pkg: "example.com/b", test: "TestD", expectedTicket: "JIRA-C"},
+		{pkg: "example.com/c", test: "TestA"},
+	}
+	for _, tt := range tests {
+		entry, ok := inventory.Covering(tt.pkg, tt.test, tt.variant)
+		assert.Equal(t, tt.expectedTicket != "", ok, "%s %s %s", tt.pkg, tt.test, tt.variant)
+		assert.Equal(t, tt.expectedTicket, entry.Ticket, "%s %s %s", tt.pkg, tt.test, tt.variant)
+	}
+}
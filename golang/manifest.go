@@ -0,0 +1,222 @@
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+// HasManifest checks if the repository has a quarantine manifest checked in, see quarantine.ManifestPath.
+// Repositories with a manifest have opted in to quarantining tests with it rather than by editing their source.
+func HasManifest(repoPath string) bool {
+	_, err := os.Stat(filepath.Join(repoPath, quarantine.ManifestPath))
+	return err == nil
+}
+
+// ReadManifest reads the quarantine manifest of the repository.
+// Returns an empty manifest if the repository doesn't have one.
+func ReadManifest(repoPath string) (*quarantine.Manifest, error) {
+	manifest, _, err := readManifest(repoPath)
+	return manifest, err
+}
+
+// readManifest reads and parses the quarantine manifest of the repository, returning its source too.
+// Returns an empty manifest and no source if the repository doesn't have one.
+func readManifest(repoPath string) (*quarantine.Manifest, []byte, error) {
+	data, err := os.ReadFile(filepath.Join(repoPath, quarantine.ManifestPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return &quarantine.Manifest{}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read quarantine manifest: %w", err)
+	}
+	manifest, err := quarantine.ParseManifest(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return manifest, data, nil
+}
+
+// WriteManifest writes the quarantine manifest of the repository, creating its directory if needed.
+func WriteManifest(repoPath string, manifest *quarantine.Manifest) error {
+	manifestPath := filepath.Join(repoPath, quarantine.ManifestPath)
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0750); err != nil {
+		return fmt.Errorf("failed to create quarantine manifest directory: %w", err)
+	}
+	if err := os.WriteFile(manifestPath, manifest.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write quarantine manifest: %w", err)
+	}
+	return nil
+}
+
+// MergeManifest adds the tests of the targets to the manifest, or removes them if unquarantine is true.
+// The tickets of tests that are already listed for the same variant are updated. Un-quarantining a test without a
+// variant removes it for every variant.
+// It returns what happened to each test, with the manifest as the file of every package, see QuarantineManifest.
+// Lines are those of the tests' entries in the merged manifest, or in the original one for removed tests.
+func MergeManifest(manifest *quarantine.Manifest, targets []QuarantineTarget, unquarantine bool) QuarantineResults {
+	originalLines := manifestLines(manifest)
+	results := make(QuarantineResults, len(targets))
+	variants := make(map[string]string) // Variant of each test, keyed by package and name, to find its modified line
+	for _, target := range sanitizeQuarantineTargets(targets) {
+		result := QuarantinePackageResults{Package: target.Package, Unquarantine: unquarantine}
+		var tests []QuarantinedTest
+		for _, test := range target.Tests {
+			if test.Pattern != PatternExact {
+				result.Failures = append(result.Failures, test.Name)
+				continue
+			}
+
+			matches := func(entry quarantine.ManifestEntry) bool {
+				return entry.Package == target.Package && entry.Test == test.Name &&
+					(entry.Variant == test.Variant || (unquarantine && test.Variant == ""))
+			}
+			index := slices.IndexFunc(manifest.Tests, matches)
+			key := manifestKey(target.Package, test.Name, test.Variant)
+			variants[target.Package+"."+test.Name] = test.Variant
+			quarantinedTest := QuarantinedTest{Name: test.Name, JiraTicket: test.JiraTicket}
+			switch {
+			case unquarantine && index < 0:
+				result.Failures = append(result.Failures, test.Name)
+				continue
+			case unquarantine:
+				existing := manifest.Tests[index]
+				quarantinedTest.JiraTicket = existing.Ticket
+				quarantinedTest.OriginalLine = originalLines[manifestKey(existing.Package, existing.Test, existing.Variant)]
+				manifest.Tests = slices.DeleteFunc(manifest.Tests, matches)
+			case index < 0:
+				manifest.Tests = append(manifest.Tests, quarantine.ManifestEntry{
+					Package: target.Package,
+					Test:    test.Name,
+					Ticket:  test.JiraTicket,
+					Variant: test.Variant,
+				})
+			case manifest.Tests[index].Ticket == test.JiraTicket:
+				quarantinedTest.AlreadyQuarantined = true
+				quarantinedTest.OriginalLine = originalLines[key]
+			default:
+				quarantinedTest.PreviousJiraTicket = manifest.Tests[index].Ticket
+				quarantinedTest.OriginalLine = originalLines[key]
+				manifest.Tests[index].Ticket = test.JiraTicket
+			}
+			tests = append(tests, quarantinedTest)
+		}
+		if len(tests) > 0 {
+			result.Successes = []QuarantinedFile{{
+				Package: target.Package,
+				File:    quarantine.ManifestPath,
+				Tests:   tests,
+			}}
+		}
+		results[target.Package] = result
+	}
+
+	if unquarantine {
+		return results
+	}
+	modifiedLines := manifestLines(manifest)
+	for _, result := range results {
+		for _, file := range result.Successes {
+			for index, test := range file.Tests {
+				key := manifestKey(result.Package, test.Name, variants[result.Package+"."+test.Name])
+				file.Tests[index].ModifiedLine = modifiedLines[key]
+			}
+		}
+	}
+	return results
+}
+
+// manifestKey identifies the entry of a test on a variant in the manifest.
+func manifestKey(pkg, test, variant string) string {
+	return pkg + "." + test + "@" + variant
+}
+
+// manifestLines returns the line of each test's entry in the written manifest, keyed by manifestKey.
+func manifestLines(manifest *quarantine.Manifest) map[string]int {
+	data := manifest.Bytes()
+	written, err := quarantine.ParseManifest(data)
+	if err != nil {
+		return nil
+	}
+
+	// Entries are written in order, each starting with a "- " line
+	lines := make(map[string]int, len(written.Tests))
+	entry := 0
+	for index, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "- ") || entry >= len(written.Tests) {
+			continue
+		}
+		test := written.Tests[entry]
+		lines[manifestKey(test.Package, test.Test, test.Variant)] = index + 1
+		entry++
+	}
+	return lines
+}
+
+// QuarantineManifest quarantines the tests of the targets by listing them in the repository's quarantine manifest,
+// rather than by editing their source. The tests need to opt in with quarantine.Check or quarantine.Main.
+// Like QuarantineTests, it returns the modified files, so that they can be committed.
+//
+// The manifest is shared by every package, so only the result of the first package carries the change to it.
+// The other packages list the manifest as their file too, unmodified, so that their tests are reported.
+func QuarantineManifest(l zerolog.Logger, repoPath string, targets []QuarantineTarget) (QuarantineResults, error) {
+	return processManifest(l, repoPath, targets, false)
+}
+
+// UnquarantineManifest removes the tests of the targets from the repository's quarantine manifest,
+// see QuarantineManifest. Tests that aren't listed are failures.
+func UnquarantineManifest(l zerolog.Logger, repoPath string, targets []QuarantineTarget) (QuarantineResults, error) {
+	return processManifest(l, repoPath, targets, true)
+}
+
+// processManifest merges the targets into the repository's quarantine manifest, see QuarantineManifest.
+func processManifest(
+	l zerolog.Logger,
+	repoPath string,
+	targets []QuarantineTarget,
+	unquarantine bool,
+) (QuarantineResults, error) {
+	manifest, original, err := readManifest(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		results  = MergeManifest(manifest, targets, unquarantine)
+		modified = manifest.Bytes()
+		changed  = false
+	)
+	if results.SuccessfulTestsCount() == 0 {
+		modified = original // Don't rewrite a manifest that didn't change
+	}
+	for _, pkg := range slices.Sorted(maps.Keys(results)) {
+		result := results[pkg]
+		for index := range result.Successes {
+			file := &result.Successes[index]
+			file.FileAbs = filepath.Join(repoPath, quarantine.ManifestPath)
+			file.ModifiedSourceCode = string(modified)
+			file.OriginalSourceCode = string(modified)
+			if !changed {
+				file.OriginalSourceCode = string(original)
+				changed = true
+			}
+		}
+		results[pkg] = result
+	}
+
+	l.Info().
+		Int("successes", results.SuccessfulTestsCount()).
+		Int("already_quarantined", results.AlreadyQuarantinedTestsCount()).
+		Int("failures", results.FailedTestsCount()).
+		Bool("unquarantine", unquarantine).
+		Msg("Merged targets into quarantine manifest")
+	return results, nil
+}
@@ -0,0 +1,124 @@
+package golang
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestMergeManifest(t *testing.T) {
+	t.Parallel()
+
+	manifest := &quarantine.Manifest{Tests: []quarantine.ManifestEntry{
+		{Package: "example.com/a", Test: "TestOld", Ticket: "JIRA-1"},
+		{Package: "example.com/a", Test: "TestUpdated", Ticket: "JIRA-0"},
+	}}
+	results := MergeManifest(manifest, []QuarantineTarget{
+		{Package: "example.com/a", Tests: []TestToQuarantine{
+			{Name: "TestNew", JiraTicket: "JIRA-3"},
+			{Name: "TestOld", JiraTicket: "JIRA-1"},
+			{Name: "TestUpdated", JiraTicket: "JIRA-2"},
+			{Name: "Test*", JiraTicket: "JIRA-4", Pattern: PatternGlob},
+		}},
+	}, false)
+
+	require.Len(t, results, 1)
+	result := results["example.com/a"]
+	assert.Equal(t, []string{"Test*"}, result.Failures, "patterns can't be listed in the manifest")
+	require.Len(t, result.Successes, 1)
+	assert.Equal(t, quarantine.ManifestPath, result.Successes[0].File)
+	assert.Equal(t, []QuarantinedTest{
+		{Name: "TestNew", JiraTicket: "JIRA-3", ModifiedLine: 4},
+		{Name: "TestOld", JiraTicket: "JIRA-1", AlreadyQuarantined: true, OriginalLine: 4, ModifiedLine: 7},
+		{Name: "TestUpdated", JiraTicket: "JIRA-2", PreviousJiraTicket: "JIRA-0", OriginalLine: 7, ModifiedLine: 10},
+	}, result.Successes[0].Tests)
+	assert.ElementsMatch(t, []quarantine.ManifestEntry{
+		{Package: "example.com/a", Test: "TestNew", Ticket: "JIRA-3"},
+		{Package: "example.com/a", Test: "TestOld", Ticket: "JIRA-1"},
+		{Package: "example.com/a", Test: "TestUpdated", Ticket: "JIRA-2"},
+	}, manifest.Tests)
+
+	results = MergeManifest(manifest, []QuarantineTarget{
+		{Package: "example.com/a", Tests: []TestToQuarantine{{Name: "TestOld"}, {Name: "TestMissing"}}},
+	}, true)
+	result = results["example.com/a"]
+	assert.True(t, result.Unquarantine)
+	assert.Equal(t, []string{"TestMissing"}, result.Failures)
+	require.Len(t, result.Successes, 1)
+	assert.Equal(t, []QuarantinedTest{{Name: "TestOld", JiraTicket: "JIRA-1", OriginalLine: 7}}, result.Successes[0].Tests)
+	assert.Len(t, manifest.Tests, 2)
+}
+
+func TestMergeManifest_Variants(t *testing.T) {
+	t.Parallel()
+
+	manifest := &quarantine.Manifest{Tests: []quarantine.ManifestEntry{
+		{Package: "example.com/a", Test: "TestA", Ticket: "JIRA-1"},
+	}}
+	results := MergeManifest(manifest, []QuarantineTarget{
+		{Package: "example.com/a", Tests: []TestToQuarantine{{Name: "TestA", JiraTicket: "JIRA-2", Variant: "race"}}},
+	}, false)
+	require.Len(t, results["example.com/a"].Successes, 1)
+	assert.Equal(t, []QuarantinedTest{
+		{Name: "TestA", JiraTicket: "JIRA-2", ModifiedLine: 7},
+	}, results["example.com/a"].Successes[0].Tests, "a new variant should get its own entry")
+	assert.Equal(t, []quarantine.ManifestEntry{
+		{Package: "example.com/a", Test: "TestA", Ticket: "JIRA-1"},
+		{Package: "example.com/a", Test: "TestA", Ticket: "JIRA-2", Variant: "race"},
+	}, manifest.Tests)
+
+	results = MergeManifest(manifest, []QuarantineTarget{
+		{Package: "example.com/a", Tests: []TestToQuarantine{{Name: "TestA"}}},
+	}, true)
+	assert.Equal(t, 1, results.SuccessfulTestsCount())
+	assert.Empty(t, manifest.Tests, "un-quarantining without a variant should remove every variant")
+}
+
+func TestQuarantineManifest(t *testing.T) {
+	t.Parallel()
+
+	repoPath := t.TempDir()
+	assert.False(t, HasManifest(repoPath))
+	targets := []QuarantineTarget{
+		{Package: "example.com/a", Tests: []TestToQuarantine{{Name: "TestA", JiraTicket: "JIRA-1"}}},
+		{Package: "example.com/b", Tests: []TestToQuarantine{{Name: "TestB", JiraTicket: "JIRA-2"}}},
+	}
+
+	results, err := QuarantineManifest(zerolog.Nop(), repoPath, targets)
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.SuccessfulTestsCount())
+	diff, err := results.Diff()
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+    test: TestA")
+	assert.Contains(t, diff, "+    test: TestB")
+	assert.Equal(t, 1, strings.Count(diff, "+++ b/"+quarantine.ManifestPath), "the manifest should only be changed once")
+
+	require.NoError(t, WriteQuarantineResultsToFiles(zerolog.Nop(), results))
+	require.True(t, HasManifest(repoPath))
+	manifest, err := ReadManifest(repoPath)
+	require.NoError(t, err)
+	assert.Len(t, manifest.Tests, 2)
+
+	// Quarantining again doesn't change the manifest
+	results, err = QuarantineManifest(zerolog.Nop(), repoPath, targets)
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.AlreadyQuarantinedTestsCount())
+	diff, err = results.Diff()
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	results, err = UnquarantineManifest(zerolog.Nop(), repoPath, targets[:1])
+	require.NoError(t, err)
+	require.NoError(t, WriteQuarantineResultsToFiles(zerolog.Nop(), results))
+	data, err := os.ReadFile(filepath.Join(repoPath, quarantine.ManifestPath))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "TestA")
+	assert.Contains(t, string(data), "TestB")
+}
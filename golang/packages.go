@@ -6,10 +6,12 @@ import (
 	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
+	"golang.org/x/mod/modfile"
 	"golang.org/x/tools/go/packages"
 )
 
@@ -38,10 +40,17 @@ func (p *PackagesInfo) String() string {
 
 // Get returns the PackageInfo for the given import path.
 // Note that the import path is the full import path, not just the package name.
+// The import path of an external test package, e.g. "foo_test", returns the package it tests, e.g. "foo",
+// as its test files are tracked in XTestGoFiles.
 func (p *PackagesInfo) Get(importPath string) (PackageInfo, error) {
 	if pkg, ok := p.Packages[importPath]; ok {
 		return pkg, nil
 	}
+	if underTest, ok := strings.CutSuffix(importPath, "_test"); ok {
+		if pkg, ok := p.Packages[underTest]; ok {
+			return pkg, nil
+		}
+	}
 
 	allPackages := make([]string, 0, len(p.Packages))
 	for pkg := range p.Packages {
@@ -62,12 +71,17 @@ type PackageInfo struct {
 	Name         string           // Package name
 	Dir          string           // Directory containing the package
 	GoFiles      []string         // .go source files
-	TestGoFiles  []string         // _test.go files
-	XTestGoFiles []string         // _test.go files with different package names
+	TestGoFiles  []string         // _test.go files in the package itself
+	XTestGoFiles []string         // _test.go files in the external test package, e.g. foo_test for foo
 	Module       *packages.Module // Module information for the package
 	IsCommand    bool             // True if this is a main package
 }
 
+// AllTestGoFiles returns the test files of both the package itself and its external test package.
+func (p *PackageInfo) AllTestGoFiles() []string {
+	return append(slices.Clone(p.TestGoFiles), p.XTestGoFiles...)
+}
+
 func (p *PackageInfo) String() string {
 	str := strings.Builder{}
 	str.WriteString(p.ImportPath)
@@ -86,6 +100,7 @@ func (p *PackageInfo) String() string {
 	}
 	if len(p.XTestGoFiles) > 0 {
 		str.WriteString(fmt.Sprintf("XTestGoFiles: %v", p.XTestGoFiles))
+		str.WriteString("\n")
 	}
 	str.WriteString(fmt.Sprintf("Module: %s", p.Module.Path))
 	str.WriteString("\n")
@@ -98,6 +113,10 @@ func (p *PackageInfo) String() string {
 // This includes packages of nested Go projects by discovering all go.mod files and
 // loading packages from each module root.
 // buildFlags are passed to the go command when loading packages, e.g. []string{"-tags", "build_tag"}
+//
+// Test files guarded by build constraints, e.g. //go:build integration or foo_windows_test.go, are found as well.
+// Each module's test files are scanned for their constraints, and the packages are loaded again for every
+// combination of build tags, GOOS and GOARCH needed to see them. The files found by each load are merged together.
 func Packages(l zerolog.Logger, rootDir string, buildFlags []string) (*PackagesInfo, error) {
 	absRootDir, err := filepath.Abs(rootDir)
 	if err != nil {
@@ -127,15 +146,93 @@ func Packages(l zerolog.Logger, rootDir string, buildFlags []string) (*PackagesI
 
 	// Load packages from each Go module
 	for _, modDir := range goModDirs {
-		modulePackages, err := loadPackagesFromModule(l, modDir, buildFlags)
+		configs := extraBuildConfigs(l, modDir, buildFlags)
+		modulePackages, err := loadPackagesWithConfigs(l, modDir, []string{"./..."}, buildFlags, configs)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load packages from module %s: %w", modDir, err)
+			return nil, err
 		}
 
 		// Merge packages from this module into the result
 		maps.Copy(result.Packages, modulePackages)
 	}
 
+	logPackages(l, result)
+	l.Trace().Int("count", len(result.Packages)).Str("duration", time.Since(start).String()).Msg("Found packages")
+	return result, nil
+}
+
+// PackagesFor finds only the Go packages with the given import paths, instead of every package in the project.
+// Each import path is mapped to the module that owns it, by the module paths in the project's go.mod files,
+// and to its directory inside that module. Only those packages are loaded, which is much faster in large projects.
+// The import path of an external test package, e.g. "foo_test", loads the package it tests, e.g. "foo".
+//
+// Returns ErrPackageNotFound if any of the import paths can't be resolved or loaded this way, in which case callers
+// can fall back to Packages.
+func PackagesFor(l zerolog.Logger, rootDir string, buildFlags []string, importPaths []string) (*PackagesInfo, error) {
+	absRootDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	l = l.With().Str("rootDir", rootDir).Str("absRootDir", absRootDir).Logger()
+	l.Trace().Strs("importPaths", importPaths).Msg("Loading targeted packages")
+	start := time.Now()
+
+	goModDirs, err := findGoModDirectories(absRootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find go.mod files: %w", err)
+	}
+	modules, err := readGoModules(goModDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Module directory -> import paths of the packages to load from it, and their directories
+	var (
+		moduleImportPaths = make(map[string][]string)
+		modulePackageDirs = make(map[string][]string)
+	)
+	for _, importPath := range importPaths {
+		mod, packageDir, ok := resolveImportPath(modules, importPath)
+		if underTest, isExternalTest := strings.CutSuffix(importPath, "_test"); !ok && isExternalTest {
+			importPath = underTest
+			mod, packageDir, ok = resolveImportPath(modules, importPath)
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: unable to resolve %s to a module directory", ErrPackageNotFound, importPath)
+		}
+		moduleImportPaths[mod.Dir] = appendUnique(moduleImportPaths[mod.Dir], []string{importPath})
+		modulePackageDirs[mod.Dir] = appendUnique(modulePackageDirs[mod.Dir], []string{packageDir})
+	}
+
+	result := &PackagesInfo{
+		Packages: make(map[string]PackageInfo),
+	}
+	for modDir, modImportPaths := range moduleImportPaths {
+		configs := packageBuildConfigs(l, modulePackageDirs[modDir], buildFlags)
+		modulePackages, err := loadPackagesWithConfigs(l, modDir, modImportPaths, buildFlags, configs)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(result.Packages, modulePackages)
+	}
+
+	for _, importPath := range importPaths {
+		if pkg, err := result.Get(importPath); err != nil || pkg.Dir == "" {
+			return nil, fmt.Errorf("%w: %s was not loaded", ErrPackageNotFound, importPath)
+		}
+	}
+
+	logPackages(l, result)
+	l.Trace().
+		Int("count", len(result.Packages)).
+		Str("duration", time.Since(start).String()).
+		Msg("Found targeted packages")
+	return result, nil
+}
+
+// logPackages traces every package found.
+func logPackages(l zerolog.Logger, result *PackagesInfo) {
 	for _, pkg := range result.Packages {
 		l.Trace().
 			Strs("files", pkg.GoFiles).
@@ -149,9 +246,6 @@ func Packages(l zerolog.Logger, rootDir string, buildFlags []string) (*PackagesI
 			Str("pkgDir", pkg.Dir).
 			Msg("Found package")
 	}
-
-	l.Trace().Int("count", len(result.Packages)).Str("duration", time.Since(start).String()).Msg("Found packages")
-	return result, nil
 }
 
 // findGoModDirectories recursively finds all directories containing go.mod files
@@ -178,16 +272,111 @@ func findGoModDirectories(rootDir string) ([]string, error) {
 	return goModDirs, err
 }
 
-// loadPackagesFromModule loads all packages from a single Go module
-func loadPackagesFromModule(l zerolog.Logger, moduleDir string, buildFlags []string) (map[string]PackageInfo, error) {
+// goModule is a Go module found in the project.
+type goModule struct {
+	Path string // Module path declared in go.mod
+	Dir  string // Absolute path to the directory containing go.mod
+}
+
+// readGoModules reads the module paths of the go.mod files in the directories.
+func readGoModules(goModDirs []string) ([]goModule, error) {
+	modules := make([]goModule, 0, len(goModDirs))
+	for _, dir := range goModDirs {
+		goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read go.mod in %s: %w", dir, err)
+		}
+		modulePath := modfile.ModulePath(goMod)
+		if modulePath == "" {
+			return nil, fmt.Errorf("no module path in go.mod in %s", dir)
+		}
+		modules = append(modules, goModule{Path: modulePath, Dir: dir})
+	}
+	return modules, nil
+}
+
+// resolveImportPath finds the module that owns the import path, and the directory of the package inside it.
+// The module with the longest matching module path wins, like the go command does for nested modules.
+// Returns false if no module owns the import path, or its directory doesn't exist.
+func resolveImportPath(modules []goModule, importPath string) (goModule, string, bool) {
+	var (
+		owner goModule
+		found bool
+	)
+	for _, mod := range modules {
+		if importPath != mod.Path && !strings.HasPrefix(importPath, mod.Path+"/") {
+			continue
+		}
+		if !found || len(mod.Path) > len(owner.Path) {
+			owner, found = mod, true
+		}
+	}
+	if !found {
+		return goModule{}, "", false
+	}
+
+	packageDir := filepath.Join(owner.Dir, filepath.FromSlash(strings.TrimPrefix(importPath, owner.Path)))
+	if info, err := os.Stat(packageDir); err != nil || !info.IsDir() {
+		return goModule{}, "", false
+	}
+	// A nested module in between owns the directory instead, but declares a module path we can't match
+	for dir := packageDir; dir != owner.Dir; dir = filepath.Dir(dir) {
+		if isModuleRoot(dir) {
+			return goModule{}, "", false
+		}
+	}
+	return owner, packageDir, true
+}
+
+// loadPackagesWithConfigs loads the packages matching the patterns from a single Go module, then loads them again
+// with every extra build config, for test files that are only visible with other build tags, GOOS or GOARCH.
+// The files found by each load are merged together.
+func loadPackagesWithConfigs(
+	l zerolog.Logger,
+	moduleDir string,
+	patterns []string,
+	buildFlags []string,
+	configs []buildConfig,
+) (map[string]PackageInfo, error) {
+	modulePackages, err := loadPackagesFromModule(l, moduleDir, patterns, buildFlags, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages from module %s: %w", moduleDir, err)
+	}
+
+	for _, config := range configs {
+		l := l.With().Str("modDir", moduleDir).Str("buildConfig", config.String()).Logger()
+		l.Trace().Msg("Loading packages with extra build config")
+		configPackages, err := loadPackagesFromModule(l, moduleDir, patterns, config.buildFlags(buildFlags), config.env())
+		if err != nil {
+			// Not every combination can be loaded on every machine, so don't fail discovery entirely
+			l.Warn().Err(err).Msg("Failed to load packages with extra build config")
+			continue
+		}
+		for importPath, pkg := range configPackages {
+			modulePackages[importPath] = mergePackageInfo(modulePackages[importPath], pkg)
+		}
+	}
+	return modulePackages, nil
+}
+
+// loadPackagesFromModule loads the packages matching the patterns, e.g. "./...", from a single Go module.
+// env is the environment of the go command, nil means the current process's environment.
+func loadPackagesFromModule(
+	l zerolog.Logger,
+	moduleDir string,
+	patterns []string,
+	buildFlags []string,
+	env []string,
+) (map[string]PackageInfo, error) {
 	config := &packages.Config{
 		Mode:       packages.NeedName | packages.NeedModule | packages.NeedFiles,
 		Dir:        moduleDir,
 		Tests:      true,
 		BuildFlags: buildFlags,
+		Env:        env,
 	}
 
-	pkgs, err := packages.Load(config, "./...")
+	pkgs, err := packages.Load(config, patterns...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load packages from module %s: %w", moduleDir, err)
 	}
@@ -204,28 +393,115 @@ func loadPackagesFromModule(l zerolog.Logger, moduleDir string, buildFlags []str
 			}
 		}
 
+		importPath, variant := packageVariant(pkg)
+		if variant == variantTestMain {
+			continue // Generated by the go tool, nothing to quarantine in there
+		}
+
 		info := PackageInfo{
-			ImportPath: pkg.PkgPath,
+			ImportPath: importPath,
 			Name:       pkg.Name,
 			IsCommand:  pkg.Name == "main",
 			Module:     pkg.Module,
 		}
 
-		// Separate regular files from test files
 		for _, file := range pkg.GoFiles {
-			if strings.HasSuffix(file, "_test.go") {
+			switch {
+			case variant == variantExternalTest:
+				info.XTestGoFiles = append(info.XTestGoFiles, file)
+			case strings.HasSuffix(file, "_test.go"):
 				info.TestGoFiles = append(info.TestGoFiles, file)
-			} else {
+			default:
 				info.GoFiles = append(info.GoFiles, file)
 			}
 		}
+		if variant == variantExternalTest {
+			// The external test package is always named after the package under test, e.g. foo_test for foo
+			info.Name = strings.TrimSuffix(pkg.Name, "_test")
+			info.IsCommand = info.Name == "main"
+		}
 
 		if len(pkg.GoFiles) > 0 {
 			info.Dir = filepath.Dir(pkg.GoFiles[0])
 		}
 
-		result[info.ImportPath] = info
+		result[importPath] = mergePackageInfo(result[importPath], info)
+	}
+
+	// Variants are loaded in no particular order, keep the file lists stable
+	for importPath, info := range result {
+		slices.Sort(info.GoFiles)
+		slices.Sort(info.TestGoFiles)
+		slices.Sort(info.XTestGoFiles)
+		result[importPath] = info
 	}
 
 	return result, nil
 }
+
+// packageLoadVariant describes which variant of a package packages.Load returned when loading tests.
+type packageLoadVariant int
+
+const (
+	variantPackage      packageLoadVariant = iota // The package itself, e.g. "foo"
+	variantInternalTest                           // The package compiled with its tests, e.g. "foo [foo.test]"
+	variantExternalTest                           // The external _test package, e.g. "foo_test [foo.test]"
+	variantTestMain                               // The generated test binary, e.g. "foo.test"
+)
+
+// packageVariant returns the import path of the package under test, and which variant of it the loaded package is.
+// When loading tests, packages.Load returns every package up to four times, all of them describing the same
+// directory, so they need to be merged together.
+func packageVariant(pkg *packages.Package) (string, packageLoadVariant) {
+	id, forTest, isTestVariant := strings.Cut(pkg.ID, " [")
+	if !isTestVariant {
+		if pkg.Name == "main" && strings.HasSuffix(id, ".test") && id == pkg.PkgPath {
+			return strings.TrimSuffix(id, ".test"), variantTestMain
+		}
+		return pkg.PkgPath, variantPackage
+	}
+
+	underTest := strings.TrimSuffix(strings.TrimSuffix(forTest, "]"), ".test")
+	switch pkg.PkgPath {
+	case underTest:
+		return underTest, variantInternalTest
+	case underTest + "_test":
+		return underTest, variantExternalTest
+	default:
+		// A dependency of the tests that imports the package under test, recompiled along with it
+		return pkg.PkgPath, variantPackage
+	}
+}
+
+// mergePackageInfo adds the files of another load of the same package to the package info.
+// Files are only added once, so merging the same package twice is harmless.
+func mergePackageInfo(info, other PackageInfo) PackageInfo {
+	if info.ImportPath == "" {
+		return other
+	}
+	info.GoFiles = appendUnique(info.GoFiles, other.GoFiles)
+	info.TestGoFiles = appendUnique(info.TestGoFiles, other.TestGoFiles)
+	info.XTestGoFiles = appendUnique(info.XTestGoFiles, other.XTestGoFiles)
+	if info.Name == "" {
+		// Packages without any files that build in one variant have no name there
+		info.Name = other.Name
+		info.IsCommand = other.IsCommand
+	}
+	if info.Dir == "" {
+		info.Dir = other.Dir
+	}
+	if info.Module == nil {
+		info.Module = other.Module
+	}
+	return info
+}
+
+// appendUnique appends the values that are not already in the list.
+func appendUnique(list, more []string) []string {
+	for _, value := range more {
+		if !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -1,6 +1,8 @@
 package golang_test
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -16,9 +18,11 @@ var (
 	exampleProjectPackages = []string{
 		"github.com/smartcontractkit/branch-out-example-project",
 		"github.com/smartcontractkit/branch-out-example-project/oddly_named_package",
+		"github.com/smartcontractkit/branch-out-example-project/test_package",
 		"github.com/smartcontractkit/branch-out-example-project/test_package_test",
 		"github.com/smartcontractkit/branch-out-example-project/nested_project",
 		"github.com/smartcontractkit/branch-out-example-project/nested_project/nested_oddly_named_package",
+		"github.com/smartcontractkit/branch-out-example-project/nested_project/nested_test_package",
 		"github.com/smartcontractkit/branch-out-example-project/nested_project/nested_test_package_test",
 	}
 )
@@ -43,4 +47,80 @@ func TestPackages_Integration(t *testing.T) {
 		_, err := packages.Get(pkg)
 		assert.NoError(t, err, "package should be found")
 	}
+
+	// Test variants of a package are merged into the package under test
+	testPackage, err := packages.Get("github.com/smartcontractkit/branch-out-example-project/test_package")
+	require.NoError(t, err)
+	externalTestPackage, err := packages.Get("github.com/smartcontractkit/branch-out-example-project/test_package_test")
+	require.NoError(t, err)
+	assert.Equal(t, testPackage, externalTestPackage, "external test package should resolve to the package under test")
+	assert.Equal(t, "test_package", testPackage.Name)
+	assert.Empty(t, testPackage.TestGoFiles, "test package only has external tests")
+	require.Len(t, testPackage.XTestGoFiles, 1)
+	assert.Equal(t, "test_package_test.go", filepath.Base(testPackage.XTestGoFiles[0]))
+
+	for importPath := range packages.Packages {
+		assert.False(t, strings.HasSuffix(importPath, ".test"), "generated test main %s should not be a package", importPath)
+		assert.False(t, strings.HasSuffix(importPath, "_test"), "external test package %s should be merged", importPath)
+	}
+}
+
+func TestPackages_Integration_BuildConstraints(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	// No build flags, every test file of the example project is guarded by the example_project build tag
+	l := testhelpers.Logger(t)
+	packages, err := golang.Packages(l, exampleProjectDir, nil)
+	require.NoError(t, err)
+
+	for _, importPath := range exampleProjectPackages {
+		_, err := packages.Get(importPath)
+		assert.NoError(t, err, "package should be found")
+	}
+
+	pkg, err := packages.Get(exampleProjectPackages[0])
+	require.NoError(t, err)
+	testFiles := make([]string, 0, len(pkg.TestGoFiles))
+	for _, file := range pkg.TestGoFiles {
+		testFiles = append(testFiles, filepath.Base(file))
+	}
+	assert.Contains(t, testFiles, "standard_test.go", "test files behind a custom build tag should be found")
+	assert.Contains(t, testFiles, "integration_tag_test.go", "test files behind multiple build tags should be found")
+	assert.Contains(t, testFiles, "platform_windows_test.go", "test files for another GOOS should be found")
+}
+
+func TestPackagesFor_Integration(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	l := testhelpers.Logger(t)
+	allPackages, err := golang.Packages(l, exampleProjectDir, nil)
+	require.NoError(t, err)
+
+	targeted := []string{
+		"github.com/smartcontractkit/branch-out-example-project",
+		"github.com/smartcontractkit/branch-out-example-project/test_package_test",
+		"github.com/smartcontractkit/branch-out-example-project/nested_project/nested_test_package",
+	}
+	packages, err := golang.PackagesFor(l, exampleProjectDir, nil, targeted)
+	require.NoError(t, err)
+	assert.Len(t, packages.Packages, len(targeted), "only the targeted packages should be loaded")
+	for _, importPath := range targeted {
+		pkg, err := packages.Get(importPath)
+		require.NoError(t, err, "targeted package should be found")
+		expected, err := allPackages.Get(importPath)
+		require.NoError(t, err)
+		assert.Equal(t, expected, pkg, "targeted package should match the full scan, including build constrained files")
+	}
+
+	missing := []string{"github.com/smartcontractkit/branch-out-example-project/missing"}
+	_, err = golang.PackagesFor(l, exampleProjectDir, nil, missing)
+	require.ErrorIs(t, err, golang.ErrPackageNotFound)
+	_, err = golang.PackagesFor(l, exampleProjectDir, nil, []string{"github.com/someone/else"})
+	require.ErrorIs(t, err, golang.ErrPackageNotFound)
 }
@@ -0,0 +1,162 @@
+package golang
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestPackageVariant(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name               string
+		pkg                *packages.Package
+		expectedImportPath string
+		expectedVariant    packageLoadVariant
+	}{
+		{
+			name:               "package",
+			pkg:                &packages.Package{ID: "example.com/foo", PkgPath: "example.com/foo", Name: "foo"},
+			expectedImportPath: "example.com/foo",
+			expectedVariant:    variantPackage,
+		},
+		{
+			name: "internal test",
+			pkg: &packages.Package{
+				ID:      "example.com/foo [example.com/foo.test]",
+				PkgPath: "example.com/foo",
+				Name:    "foo",
+			},
+			expectedImportPath: "example.com/foo",
+			expectedVariant:    variantInternalTest,
+		},
+		{
+			name: "external test",
+			pkg: &packages.Package{
+				ID:      "example.com/foo_test [example.com/foo.test]",
+				PkgPath: "example.com/foo_test",
+				Name:    "foo_test",
+			},
+			expectedImportPath: "example.com/foo",
+			expectedVariant:    variantExternalTest,
+		},
+		{
+			name: "test main",
+			pkg: &packages.Package{
+				ID:      "example.com/foo.test",
+				PkgPath: "example.com/foo.test",
+				Name:    "main",
+			},
+			expectedImportPath: "example.com/foo",
+			expectedVariant:    variantTestMain,
+		},
+		{
+			name: "dependency recompiled for a test",
+			pkg: &packages.Package{
+				ID:      "example.com/bar [example.com/foo.test]",
+				PkgPath: "example.com/bar",
+				Name:    "bar",
+			},
+			expectedImportPath: "example.com/bar",
+			expectedVariant:    variantPackage,
+		},
+		{
+			name: "package in a directory ending in _test",
+			pkg: &packages.Package{
+				ID:      "example.com/foo_test",
+				PkgPath: "example.com/foo_test",
+				Name:    "foo_test",
+			},
+			expectedImportPath: "example.com/foo_test",
+			expectedVariant:    variantPackage,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			importPath, variant := packageVariant(test.pkg)
+			assert.Equal(t, test.expectedImportPath, importPath)
+			assert.Equal(t, test.expectedVariant, variant)
+		})
+	}
+}
+
+func TestPackagesInfo_Get(t *testing.T) {
+	t.Parallel()
+
+	info := &PackagesInfo{Packages: map[string]PackageInfo{
+		"example.com/foo":      {ImportPath: "example.com/foo", XTestGoFiles: []string{"foo_test.go"}},
+		"example.com/bar_test": {ImportPath: "example.com/bar_test"},
+	}}
+
+	pkg, err := info.Get("example.com/foo_test")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/foo", pkg.ImportPath, "external test package should resolve to the package under test")
+
+	pkg, err = info.Get("example.com/bar_test")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/bar_test", pkg.ImportPath, "exact matches come first")
+
+	_, err = info.Get("example.com/baz_test")
+	require.ErrorIs(t, err, ErrPackageNotFound)
+}
+
+func TestResolveImportPath(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"pkg", "nested/pkg", "unlisted/pkg"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0700))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "unlisted", "go.mod"), []byte("module other.com/x\n"), 0600))
+	modules := []goModule{
+		{Path: "example.com/root", Dir: root},
+		{Path: "example.com/root/nested", Dir: filepath.Join(root, "nested")},
+	}
+
+	tests := []struct {
+		name        string
+		importPath  string
+		expectedMod string
+		expectedDir string
+		ok          bool
+	}{
+		{name: "module root", importPath: "example.com/root", expectedMod: root, expectedDir: root, ok: true},
+		{
+			name:        "package",
+			importPath:  "example.com/root/pkg",
+			expectedMod: root,
+			expectedDir: filepath.Join(root, "pkg"),
+			ok:          true,
+		},
+		{
+			name:        "nested module",
+			importPath:  "example.com/root/nested/pkg",
+			expectedMod: filepath.Join(root, "nested"),
+			expectedDir: filepath.Join(root, "nested", "pkg"),
+			ok:          true,
+		},
+		{name: "missing directory", importPath: "example.com/root/missing"},
+		{name: "other module", importPath: "other.com/root/pkg"},
+		{name: "module path prefix", importPath: "example.com/rootpkg"},
+		{name: "inside unlisted nested module", importPath: "example.com/root/unlisted/pkg"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mod, dir, ok := resolveImportPath(modules, test.importPath)
+			require.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expectedMod, mod.Dir)
+			assert.Equal(t, test.expectedDir, dir)
+		})
+	}
+}
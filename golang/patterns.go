@@ -0,0 +1,179 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// TestPattern describes how the name of a TestToQuarantine is matched against the tests of a package.
+type TestPattern int
+
+const (
+	// PatternExact is the exact name of a test, e.g. "TestFoo" or "TestFoo/subtest_1". This is the default.
+	PatternExact TestPattern = iota
+	// PatternRegex is a regular expression matched against the full names of the package's test functions and testify
+	// suite methods, e.g. "TestMySuite/TestFoo". Like go test -run, it's not anchored.
+	PatternRegex
+	// PatternGlob is a glob matched against the full names of the package's test functions and testify suite methods,
+	// see path.Match. "*" doesn't match the "/" between a suite and its methods, so "TestChaos*" only matches test
+	// functions, and "TestMySuite/*" the methods of a suite.
+	PatternGlob
+)
+
+// String returns the name of the pattern kind.
+func (p TestPattern) String() string {
+	switch p {
+	case PatternExact:
+		return "exact"
+	case PatternRegex:
+		return "regex"
+	case PatternGlob:
+		return "glob"
+	default:
+		return fmt.Sprintf("TestPattern(%d)", int(p))
+	}
+}
+
+// PackageTarget returns a target that quarantines every test function in the package with the ticket.
+func PackageTarget(importPath, jiraTicket string) QuarantineTarget {
+	return QuarantineTarget{
+		Package: importPath,
+		Tests:   []TestToQuarantine{{Name: "*", JiraTicket: jiraTicket, Pattern: PatternGlob}},
+	}
+}
+
+// matcher returns a function that checks if the full name of a test matches the test's pattern.
+func (t TestToQuarantine) matcher() (func(testName string) bool, error) {
+	switch t.Pattern {
+	case PatternExact:
+		return func(testName string) bool { return testName == t.Name }, nil
+	case PatternRegex:
+		re, err := regexp.Compile(t.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", t.Name, err)
+		}
+		return re.MatchString, nil
+	case PatternGlob:
+		if _, err := path.Match(t.Name, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", t.Name, err)
+		}
+		return func(testName string) bool {
+			matched, _ := path.Match(t.Name, testName)
+			return matched
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown pattern %s for %q", t.Pattern, t.Name)
+	}
+}
+
+// expandPatterns replaces the target's pattern tests with the concrete tests they match in the package's test files,
+// with the ticket of the pattern. Tests that are also targeted by their exact name, or by an earlier pattern,
+// keep that ticket.
+// It returns the expanded target, and the concrete tests each pattern matched, keyed by the pattern.
+// Invalid patterns are logged and don't match any tests.
+func expandPatterns(
+	l zerolog.Logger,
+	testFiles []parsedTestFile,
+	suites suiteRunners,
+	target QuarantineTarget,
+) (QuarantineTarget, map[string][]string) {
+	expanded := QuarantineTarget{Package: target.Package, Tests: make([]TestToQuarantine, 0, len(target.Tests))}
+	for _, test := range target.Tests {
+		if test.Pattern == PatternExact {
+			expanded.Tests = append(expanded.Tests, test)
+		}
+	}
+	if len(expanded.Tests) == len(target.Tests) {
+		return target, nil
+	}
+
+	var (
+		testNames = packageTestNames(testFiles, suites)
+		matches   = make(map[string][]string)
+	)
+	for _, test := range target.Tests {
+		if test.Pattern == PatternExact {
+			continue
+		}
+		matches[test.Name] = []string{}
+		match, err := test.matcher()
+		if err != nil {
+			l.Warn().Err(err).Str("pattern", test.Name).Msg("Ignoring invalid test pattern")
+			continue
+		}
+		for _, testName := range testNames {
+			if !match(testName) {
+				continue
+			}
+			matches[test.Name] = append(matches[test.Name], testName)
+			if !slices.ContainsFunc(expanded.Tests, func(t TestToQuarantine) bool { return t.Name == testName }) {
+				expanded.Tests = append(expanded.Tests, TestToQuarantine{
+					Name:       testName,
+					JiraTicket: test.JiraTicket,
+					Until:      test.Until,
+					Variant:    test.Variant,
+				})
+			}
+		}
+	}
+	return expanded, matches
+}
+
+// packageTestNames returns the full names of the test functions and testify suite methods in the test files,
+// e.g. "TestFoo" and "TestMySuite/TestBar". Subtests run with t.Run aren't included.
+func packageTestNames(testFiles []parsedTestFile, suites suiteRunners) []string {
+	var names []string
+	for _, testFile := range testFiles {
+		for _, decl := range testFile.Node.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Body == nil {
+				continue
+			}
+			if funcDecl.Recv == nil {
+				if isTestFunction(testFile.Node, funcDecl) {
+					names = append(names, funcDecl.Name.Name)
+				}
+				continue
+			}
+			if !strings.HasPrefix(funcDecl.Name.Name, "Test") {
+				continue
+			}
+			for runner := range suites {
+				testName := runner + "/" + funcDecl.Name.Name
+				if len(suiteMethodScopes(funcDecl, testName, suites)) > 0 {
+					names = append(names, testName)
+				}
+			}
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// targetFailures returns the names of the target's tests that weren't processed, and the patterns that didn't match
+// any processed test.
+// The pattern matches are narrowed down to the tests that were processed.
+func targetFailures(target QuarantineTarget, matches map[string][]string, processed map[string]bool) []string {
+	var failures []string
+	for _, test := range target.Tests {
+		if test.Pattern == PatternExact {
+			if !processed[test.Name] {
+				failures = append(failures, test.Name)
+			}
+			continue
+		}
+		matches[test.Name] = slices.DeleteFunc(matches[test.Name], func(testName string) bool {
+			return !processed[testName]
+		})
+		if len(matches[test.Name]) == 0 {
+			failures = append(failures, test.Name)
+		}
+	}
+	return failures
+}
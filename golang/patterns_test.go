@@ -0,0 +1,158 @@
+package golang
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const patternsTestSource = `package example
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestChaosA(t *testing.T) {}
+
+func TestChaosB(t *testing.T) {
+	t.Run("sub", func(t *testing.T) {})
+}
+
+func TestOther(t *testing.T) {}
+
+func BenchmarkChaos(b *testing.B) {}
+
+func helper(t *testing.T) {}
+
+type ChaosSuite struct {
+	suite.Suite
+}
+
+func (s *ChaosSuite) TestA() {}
+
+func (s *ChaosSuite) SetupTest() {}
+
+func TestChaosSuite(t *testing.T) {
+	suite.Run(t, new(ChaosSuite))
+}
+`
+
+func TestExpandPatterns(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		tests           []TestToQuarantine
+		expectedTests   []TestToQuarantine
+		expectedMatches map[string][]string
+	}{
+		{
+			name:          "no patterns",
+			tests:         []TestToQuarantine{{Name: "TestOther", JiraTicket: "JIRA-1"}},
+			expectedTests: []TestToQuarantine{{Name: "TestOther", JiraTicket: "JIRA-1"}},
+		},
+		{
+			name:  "glob",
+			tests: []TestToQuarantine{{Name: "TestChaos*", JiraTicket: "JIRA-1", Pattern: PatternGlob}},
+			expectedTests: []TestToQuarantine{
+				{Name: "TestChaosA", JiraTicket: "JIRA-1"},
+				{Name: "TestChaosB", JiraTicket: "JIRA-1"},
+				{Name: "TestChaosSuite", JiraTicket: "JIRA-1"},
+			},
+			expectedMatches: map[string][]string{"TestChaos*": {"TestChaosA", "TestChaosB", "TestChaosSuite"}},
+		},
+		{
+			name:            "glob of suite methods",
+			tests:           []TestToQuarantine{{Name: "TestChaosSuite/*", JiraTicket: "JIRA-1", Pattern: PatternGlob}},
+			expectedTests:   []TestToQuarantine{{Name: "TestChaosSuite/TestA", JiraTicket: "JIRA-1"}},
+			expectedMatches: map[string][]string{"TestChaosSuite/*": {"TestChaosSuite/TestA"}},
+		},
+		{
+			name:  "regex",
+			tests: []TestToQuarantine{{Name: "Chaos[AB]?$", JiraTicket: "JIRA-1", Pattern: PatternRegex}},
+			expectedTests: []TestToQuarantine{
+				{Name: "BenchmarkChaos", JiraTicket: "JIRA-1"},
+				{Name: "TestChaosA", JiraTicket: "JIRA-1"},
+				{Name: "TestChaosB", JiraTicket: "JIRA-1"},
+			},
+			expectedMatches: map[string][]string{"Chaos[AB]?$": {"BenchmarkChaos", "TestChaosA", "TestChaosB"}},
+		},
+		{
+			name: "exact names keep their ticket",
+			tests: []TestToQuarantine{
+				{Name: "TestChaosA", JiraTicket: "JIRA-A"},
+				{Name: "TestChaos[A-B]", JiraTicket: "JIRA-1", Pattern: PatternGlob},
+			},
+			expectedTests: []TestToQuarantine{
+				{Name: "TestChaosA", JiraTicket: "JIRA-A"},
+				{Name: "TestChaosB", JiraTicket: "JIRA-1"},
+			},
+			expectedMatches: map[string][]string{"TestChaos[A-B]": {"TestChaosA", "TestChaosB"}},
+		},
+		{
+			name:            "invalid pattern",
+			tests:           []TestToQuarantine{{Name: "Test(", JiraTicket: "JIRA-1", Pattern: PatternRegex}},
+			expectedTests:   []TestToQuarantine{},
+			expectedMatches: map[string][]string{"Test(": {}},
+		},
+		{
+			name:  "whole package",
+			tests: PackageTarget("example", "JIRA-1").Tests,
+			expectedTests: []TestToQuarantine{
+				{Name: "BenchmarkChaos", JiraTicket: "JIRA-1"},
+				{Name: "TestChaosA", JiraTicket: "JIRA-1"},
+				{Name: "TestChaosB", JiraTicket: "JIRA-1"},
+				{Name: "TestChaosSuite", JiraTicket: "JIRA-1"},
+				{Name: "TestOther", JiraTicket: "JIRA-1"},
+			},
+			expectedMatches: map[string][]string{
+				"*": {"BenchmarkChaos", "TestChaosA", "TestChaosB", "TestChaosSuite", "TestOther"},
+			},
+		},
+	}
+
+	testFile, err := parseTestFile("example_test.go", []byte(patternsTestSource))
+	require.NoError(t, err)
+	suites := findSuiteRunners(testFile.Node)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			expanded, matches := expandPatterns(
+				zerolog.Nop(),
+				[]parsedTestFile{testFile},
+				suites,
+				QuarantineTarget{Package: "example", Tests: test.tests},
+			)
+			assert.Equal(t, "example", expanded.Package)
+			assert.Equal(t, test.expectedTests, expanded.Tests)
+			assert.Equal(t, test.expectedMatches, matches)
+		})
+	}
+}
+
+func TestTargetFailures(t *testing.T) {
+	t.Parallel()
+
+	target := QuarantineTarget{Tests: []TestToQuarantine{
+		{Name: "TestA"},
+		{Name: "TestB"},
+		{Name: "TestChaos*", Pattern: PatternGlob},
+		{Name: "TestNothing*", Pattern: PatternGlob},
+	}}
+	matches := map[string][]string{
+		"TestChaos*":   {"TestChaosA", "TestChaosB"},
+		"TestNothing*": {"TestNothingA"},
+	}
+	processed := map[string]bool{"TestA": true, "TestChaosB": true}
+
+	failures := targetFailures(target, matches, processed)
+	assert.Equal(t, []string{"TestB", "TestNothing*"}, failures)
+	assert.Equal(t, []string{"TestChaosB"}, matches["TestChaos*"], "only processed tests should be reported as matched")
+	assert.Empty(t, matches["TestNothing*"])
+}
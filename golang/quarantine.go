@@ -2,14 +2,12 @@
 package golang
 
 import (
-	"bytes"
 	"fmt"
 	"go/ast"
-	"go/format"
 	"go/parser"
 	"go/token"
+	"maps"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -19,7 +17,11 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
+// quarantineImportPath is the import path of the package that provides quarantine.Flaky.
+const quarantineImportPath = "github.com/smartcontractkit/branch-out/quarantine"
+
 // QuarantineTarget describes a package and a list of test functions to quarantine.
+// Tests can also be patterns matching multiple tests, see TestPattern, e.g. every test in the package with PackageTarget.
 type QuarantineTarget struct {
 	Package string             // Import path of the Go package
 	Tests   []TestToQuarantine // Names of the test functions in the package to quarantine
@@ -45,10 +47,48 @@ func (q QuarantineTarget) JiraTicketForTestName(testName string) string {
 	return ""
 }
 
+// testForName returns the test to quarantine with the given name.
+// Returns a test with only the name if the test is not found.
+func (q QuarantineTarget) testForName(testName string) TestToQuarantine {
+	for _, test := range q.Tests {
+		if test.Name == testName {
+			test.Pattern = PatternExact
+			return test
+		}
+	}
+	return TestToQuarantine{Name: testName}
+}
+
+// withDefaultExpiry returns the target with the tests that don't have an expiry expiring after the given duration,
+// rounded down to the day, see TestToQuarantine.Until. Nothing changes if the duration isn't positive.
+func (q QuarantineTarget) withDefaultExpiry(expiry time.Duration) QuarantineTarget {
+	if expiry <= 0 {
+		return q
+	}
+	until := time.Now().UTC().Add(expiry).Truncate(24 * time.Hour)
+	tests := make([]TestToQuarantine, 0, len(q.Tests))
+	for _, test := range q.Tests {
+		if test.Until.IsZero() {
+			test.Until = until
+		}
+		tests = append(tests, test)
+	}
+	q.Tests = tests
+	return q
+}
+
 // TestToQuarantine describes a test to quarantine and the associated Jira ticket.
 type TestToQuarantine struct {
-	Name       string // Name of the test function to quarantine, e.g. "TestFoo"
-	JiraTicket string // Jira ticket of the test function to quarantine, e.g. "JIRA-123"
+	Name       string      // Name of the test function to quarantine, e.g. "TestFoo", or a subtest, e.g. "TestFoo/subtest_1"
+	JiraTicket string      // Jira ticket of the test function to quarantine, e.g. "JIRA-123"
+	Pattern    TestPattern // How Name matches tests, by default it's the exact name of a single test
+	// Date the quarantine expires after, see quarantine.Until. Zero for quarantines that never expire.
+	// Only generators that can express an expiry use it, like FlakyGenerator.
+	Until time.Time
+	// Variant the test is flaky on, e.g. "linux/amd64" or "race", see quarantine.Variant. Empty for every variant.
+	// Only generators that can express a variant use it, like FlakyGenerator.
+	// Tests that are already quarantined keep the variant they were quarantined on.
+	Variant string
 }
 
 // QuarantineResults describes the result of quarantining multiple packages.
@@ -66,15 +106,31 @@ func (q QuarantineResults) String() string {
 		if len(result.Successes) > 0 {
 			b.WriteString("Successes\n\n")
 			for _, success := range result.Successes {
-				if len(success.TestNames()) > 0 {
-					b.WriteString(fmt.Sprintf("%s: %s\n", success.File, strings.Join(success.TestNames(), ", ")))
-				} else {
-					b.WriteString(fmt.Sprintf("%s: No tests quarantined\n", success.File))
+				modifiedTests := success.ModifiedTests()
+				if len(modifiedTests) > 0 {
+					names := make([]string, 0, len(modifiedTests))
+					for _, test := range modifiedTests {
+						names = append(names, test.Name)
+					}
+					b.WriteString(fmt.Sprintf("%s: %s\n", success.File, strings.Join(names, ", ")))
+				} else if len(success.Tests) == 0 {
+					b.WriteString(fmt.Sprintf("%s: No tests %s\n", success.File, result.verb()))
+				}
+				for _, test := range success.AlreadyQuarantinedTests() {
+					b.WriteString(fmt.Sprintf("%s: %s was already quarantined\n", success.File, test.Name))
 				}
 			}
 		} else {
 			b.WriteString("\nNo successes!\n")
 		}
+		for _, moduleFile := range result.ModuleFiles {
+			b.WriteString(fmt.Sprintf("%s: Updated to require the quarantine module\n", moduleFile.File))
+		}
+		for _, pattern := range slices.Sorted(maps.Keys(result.PatternMatches)) {
+			if tests := result.PatternMatches[pattern]; len(tests) > 0 {
+				b.WriteString(fmt.Sprintf("%s matched %s\n", pattern, strings.Join(tests, ", ")))
+			}
+		}
 
 		if len(result.Failures) > 0 {
 			b.WriteString("\nFailures\n\n")
@@ -88,11 +144,66 @@ func (q QuarantineResults) String() string {
 	return b.String()
 }
 
+// Get returns the results of the package with the given import path.
+// The import path of an external test package, e.g. "foo_test", returns the results of the package it tests,
+// e.g. "foo", as they're keyed by the package that PackagesInfo.Get resolved.
+func (q QuarantineResults) Get(importPath string) (QuarantinePackageResults, bool) {
+	if result, ok := q[importPath]; ok {
+		return result, true
+	}
+	if underTest, ok := strings.CutSuffix(importPath, "_test"); ok {
+		result, ok := q[underTest]
+		return result, ok
+	}
+	return QuarantinePackageResults{}, false
+}
+
+// SuccessfulTestsCount returns the number of tests that were successfully processed across all packages.
+func (q QuarantineResults) SuccessfulTestsCount() int {
+	count := 0
+	for _, result := range q {
+		count += result.SuccessfulTestsCount()
+	}
+	return count
+}
+
+// AlreadyQuarantinedTestsCount returns the number of tests that were already quarantined across all packages.
+func (q QuarantineResults) AlreadyQuarantinedTestsCount() int {
+	count := 0
+	for _, result := range q {
+		count += result.AlreadyQuarantinedTestsCount()
+	}
+	return count
+}
+
+// FailedTestsCount returns the number of tests that could not be processed across all packages.
+func (q QuarantineResults) FailedTestsCount() int {
+	count := 0
+	for _, result := range q {
+		count += len(result.Failures)
+	}
+	return count
+}
+
+// Unquarantine returns true if the results describe removing quarantines from tests rather than adding them.
+func (q QuarantineResults) Unquarantine() bool {
+	for _, result := range q {
+		if result.Unquarantine {
+			return true
+		}
+	}
+	return false
+}
+
 // Markdown returns a Markdown representation of the quarantine results.
 // Good for a PR description.
 func (q QuarantineResults) Markdown(owner, repo, branch string) string {
 	var md strings.Builder
-	md.WriteString("# Quarantined Flaky Tests using branch-out\n\n")
+	if q.Unquarantine() {
+		md.WriteString("# Un-quarantined Recovered Tests using branch-out\n\n")
+	} else {
+		md.WriteString("# Quarantined Flaky Tests using branch-out\n\n")
+	}
 
 	for _, result := range q {
 		emoji := "🟢"
@@ -102,31 +213,39 @@ func (q QuarantineResults) Markdown(owner, repo, branch string) string {
 		md.WriteString(fmt.Sprintf("## `%s` %s\n\n", result.Package, emoji))
 
 		// Process successes
-		if len(result.Successes) > 0 {
-			md.WriteString(fmt.Sprintf("### Successfully Quarantined %d tests\n\n", result.SuccessfulTestsCount()))
-			md.WriteString("| File | Tests |\n")
-			md.WriteString("|------|-------|\n")
-			for _, file := range result.Successes {
-				githubBlobURL := fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", owner, repo, branch, file.File)
-
-				// Create individual test links with line numbers
-				var testLinks []string
-				for _, test := range file.Tests {
-					testLink := fmt.Sprintf("[%s](%s#L%d)", test.Name, githubBlobURL, test.ModifiedLine)
-					testLinks = append(testLinks, testLink)
-				}
+		if result.SuccessfulTestsCount() > 0 {
+			md.WriteString(
+				fmt.Sprintf("### Successfully %s %d tests\n\n", titleCase(result.verb()), result.SuccessfulTestsCount()),
+			)
+			writeTestsTable(&md, owner, repo, branch, result.Successes, QuarantinedFile.ModifiedTests)
+		}
 
-				md.WriteString(
-					fmt.Sprintf("| [%s](%s) | %s |\n", file.File, githubBlobURL, strings.Join(testLinks, ", ")),
-				)
+		// Process patterns, so that it's clear why each test was picked
+		for _, pattern := range slices.Sorted(maps.Keys(result.PatternMatches)) {
+			if tests := result.PatternMatches[pattern]; len(tests) > 0 {
+				md.WriteString(fmt.Sprintf("`%s` matched `%s`\n\n", pattern, strings.Join(tests, "`, `")))
 			}
-			md.WriteString("\n")
+		}
+
+		// Process tests that were already quarantined
+		if result.AlreadyQuarantinedTestsCount() > 0 {
+			md.WriteString(
+				fmt.Sprintf(
+					"### %d tests were already quarantined, no changes needed\n\n",
+					result.AlreadyQuarantinedTestsCount(),
+				),
+			)
+			writeTestsTable(&md, owner, repo, branch, result.Successes, QuarantinedFile.AlreadyQuarantinedTests)
 		}
 
 		// Process failures
 		if len(result.Failures) > 0 {
 			md.WriteString(
-				fmt.Sprintf("### Failed to Quarantine %d tests. Need manual intervention!\n\n", len(result.Failures)),
+				fmt.Sprintf(
+					"### Failed to %s %d tests. Need manual intervention!\n\n",
+					titleCase(strings.TrimSuffix(result.verb(), "d")),
+					len(result.Failures),
+				),
 			)
 			for _, test := range result.Failures {
 				md.WriteString(fmt.Sprintf("- %s\n", test))
@@ -134,6 +253,7 @@ func (q QuarantineResults) Markdown(owner, repo, branch string) string {
 			md.WriteString("\n")
 		}
 	}
+	writeChangesSection(&md, q)
 	md.WriteString("\n\n---\n\n")
 	md.WriteString(
 		"Created automatically by [branch-out](https://github.com/smartcontractkit/branch-out).",
@@ -141,19 +261,85 @@ func (q QuarantineResults) Markdown(owner, repo, branch string) string {
 	return md.String()
 }
 
+// writeTestsTable writes a Markdown table of the files and the tests selected from them, linking to each test.
+func writeTestsTable(
+	md *strings.Builder,
+	owner, repo, branch string,
+	files []QuarantinedFile,
+	selectTests func(QuarantinedFile) []QuarantinedTest,
+) {
+	md.WriteString("| File | Tests |\n")
+	md.WriteString("|------|-------|\n")
+	for _, file := range files {
+		tests := selectTests(file)
+		if len(tests) == 0 {
+			continue
+		}
+		githubBlobURL := fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", owner, repo, branch, file.File)
+
+		// Create individual test links with line numbers
+		var testLinks []string
+		for _, test := range tests {
+			testLink := fmt.Sprintf("[%s](%s#L%d)", test.Name, githubBlobURL, test.ModifiedLine)
+			if test.PreviousJiraTicket != "" {
+				testLink += fmt.Sprintf(" (ticket updated from %s to %s)", test.PreviousJiraTicket, test.JiraTicket)
+			}
+			testLinks = append(testLinks, testLink)
+		}
+
+		md.WriteString(
+			fmt.Sprintf("| [%s](%s) | %s |\n", file.File, githubBlobURL, strings.Join(testLinks, ", ")),
+		)
+	}
+	md.WriteString("\n")
+}
+
 // QuarantinePackageResults describes the result of quarantining a list of tests in a package.
 type QuarantinePackageResults struct {
-	Package   string            // Import path of the Go package (redundant, but kept for handy access)
-	GoModDir  string            // Directory containing the go.mod file for the package
-	Successes []QuarantinedFile // Every file where we found and quarantined tests
-	Failures  []string          // Names of the test functions that were not able to be quarantined
+	Package      string            // Import path of the Go package (redundant, but kept for handy access)
+	GoModDir     string            // Directory containing the go.mod file for the package
+	Successes    []QuarantinedFile // Every file where we found and quarantined tests
+	Failures     []string          // Names of the test functions that were not able to be quarantined
+	Unquarantine bool              // True if the tests had their quarantine removed rather than added
+	// go.mod and go.sum files of the package's module, updated to require the quarantine module (if needed).
+	// Only set on one package of each module, so that the files are not written twice.
+	ModuleFiles []QuarantinedFile
+	// Concrete tests that each pattern of the target matched and were processed, keyed by the pattern, see TestPattern.
+	// Patterns that didn't match any tests are failures.
+	PatternMatches map[string][]string
+}
+
+// verb returns the past tense of the action performed on the tests, e.g. "quarantined".
+func (q QuarantinePackageResults) verb() string {
+	if q.Unquarantine {
+		return "un-quarantined"
+	}
+	return "quarantined"
+}
+
+// titleCase upper-cases the first letter of s.
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
 }
 
 // SuccessfulTestsCount returns the number of tests that were successfully quarantined.
+// Tests that were already quarantined are not counted, see AlreadyQuarantinedTestsCount.
 func (q QuarantinePackageResults) SuccessfulTestsCount() int {
 	count := 0
 	for _, success := range q.Successes {
-		count += len(success.TestNames())
+		count += len(success.ModifiedTests())
+	}
+	return count
+}
+
+// AlreadyQuarantinedTestsCount returns the number of tests that were already quarantined and left untouched.
+func (q QuarantinePackageResults) AlreadyQuarantinedTestsCount() int {
+	count := 0
+	for _, success := range q.Successes {
+		count += len(success.AlreadyQuarantinedTests())
 	}
 	return count
 }
@@ -164,7 +350,10 @@ type QuarantinedFile struct {
 	File               string            // Relative path to the file where the tests were found and quarantined (if any)
 	FileAbs            string            // Absolute path to the file where the tests were found and quarantined on the local filesystem (if any)
 	Tests              []QuarantinedTest // All the test functions successfully quarantined in this file
+	OriginalSourceCode string            // Source code of the file before it was modified
 	ModifiedSourceCode string            // Modified source code to quarantine the tests (if any)
+	Created            bool              // True if the file didn't exist before, e.g. a go.sum file added for the quarantine module
+	Deleted            bool              // True if the file is deleted, e.g. a test file that only held a TestMain added by branch-out
 }
 
 // TestNames returns the names of the test functions that were quarantined in this file.
@@ -176,12 +365,41 @@ func (q QuarantinedFile) TestNames() []string {
 	return names
 }
 
+// ModifiedTests returns the tests that were changed in this file, i.e. not already quarantined.
+func (q QuarantinedFile) ModifiedTests() []QuarantinedTest {
+	tests := make([]QuarantinedTest, 0, len(q.Tests))
+	for _, test := range q.Tests {
+		if !test.AlreadyQuarantined {
+			tests = append(tests, test)
+		}
+	}
+	return tests
+}
+
+// AlreadyQuarantinedTests returns the tests in this file that were already quarantined and left untouched.
+func (q QuarantinedFile) AlreadyQuarantinedTests() []QuarantinedTest {
+	tests := make([]QuarantinedTest, 0, len(q.Tests))
+	for _, test := range q.Tests {
+		if test.AlreadyQuarantined {
+			tests = append(tests, test)
+		}
+	}
+	return tests
+}
+
+// Modified returns true if the source code of this file was changed, and the file needs to be committed.
+func (q QuarantinedFile) Modified() bool {
+	return q.ModifiedSourceCode != q.OriginalSourceCode
+}
+
 // QuarantinedTest describes a test function that was quarantined.
 type QuarantinedTest struct {
-	Name         string // Name of the test function that was quarantined
-	JiraTicket   string // Jira ticket of the test function that was quarantined
-	OriginalLine int    // Line number of the test function that was quarantined
-	ModifiedLine int    // Line number of the test function that was quarantined after modification of the file
+	Name               string // Name of the test function that was quarantined
+	JiraTicket         string // Jira ticket of the test function that was quarantined
+	PreviousJiraTicket string // Jira ticket the test was quarantined with before, if the ticket was updated
+	AlreadyQuarantined bool   // True if the test was already quarantined with the same ticket, or skipped manually, and was left untouched
+	OriginalLine       int    // Line number of the test function that was quarantined
+	ModifiedLine       int    // Line number of the test function that was quarantined after modification of the file
 }
 
 // QuarantineOption is a function that can be used to configure the quarantine process.
@@ -189,7 +407,26 @@ type QuarantineOption func(*quarantineOptions)
 
 // quarantineOptions describes the options for the quarantine process.
 type quarantineOptions struct {
-	buildFlags []string
+	buildFlags              []string
+	quarantineModuleVersion string
+	checksumDBURL           string
+	generator               Generator
+	packageQuarantine       bool
+	expiry                  time.Duration
+}
+
+// newQuarantineOptions applies the options on top of the defaults.
+func newQuarantineOptions(options ...QuarantineOption) *quarantineOptions {
+	quarantineOptions := &quarantineOptions{
+		buildFlags:              []string{},
+		quarantineModuleVersion: defaultQuarantineModuleVersion(),
+		checksumDBURL:           defaultChecksumDBURL,
+		generator:               FlakyGenerator(),
+	}
+	for _, option := range options {
+		option(quarantineOptions)
+	}
+	return quarantineOptions
 }
 
 // WithBuildFlags sets the build flags to use when loading packages.
@@ -199,40 +436,104 @@ func WithBuildFlags(buildFlags []string) QuarantineOption {
 	}
 }
 
+// WithGenerator sets the generator of the code that quarantines tests, see Generator. Defaults to FlakyGenerator.
+// When un-quarantining, the code of the built-in generators is recognized on top of the configured one's.
+func WithGenerator(generator Generator) QuarantineOption {
+	return func(options *quarantineOptions) {
+		options.generator = generator
+	}
+}
+
+// WithExpiry makes quarantines expire after the given duration, for tests that don't have an expiry of their own,
+// see TestToQuarantine.Until. Quarantines never expire by default.
+func WithExpiry(expiry time.Duration) QuarantineOption {
+	return func(options *quarantineOptions) {
+		options.expiry = expiry
+	}
+}
+
 // QuarantineTests looks through a Go project to find and quarantine any tests that match the given targets.
 // It returns a list of results for each target, including whether it was able to be quarantined, and the modified source code to quarantine the test.
 // The modified source code is returned so that it can be committed to the repository.
 // You must do something with it, as the code is not edited or committed by this function.
 //
 // Tests quarantined by this process will use t.Skip() to skip the test, unless the environment variable RUN_QUARANTINED_TESTS is set to "true".
+// Subtests, e.g. "TestFoo/subtest_1", are quarantined inside their t.Run closure. If the subtest's name is only known
+// at runtime, quarantine.IfNamed is used to skip only the matching subtest.
+//
+// The go.mod and go.sum files of modules that don't require the quarantine module yet are updated to require it,
+// see QuarantinePackageResults.ModuleFiles and WithQuarantineModuleVersion.
 func QuarantineTests(
 	l zerolog.Logger,
 	repoPath string,
 	quarantineTargets []QuarantineTarget,
 	options ...QuarantineOption,
 ) (QuarantineResults, error) {
-	quarantineOptions := &quarantineOptions{
-		buildFlags: []string{},
+	results, err := processTargets(l, repoPath, quarantineTargets, "quarantine", quarantinePackage, options...)
+	if err != nil {
+		return nil, err
 	}
-	for _, option := range options {
-		option(quarantineOptions)
+	quarantineOptions := newQuarantineOptions(options...)
+	if quarantineOptions.generator.ImportPath() != quarantineImportPath {
+		return results, nil // Only the quarantine package needs to be required, other generators are up to the repo
+	}
+	if err := requireQuarantineModule(
+		l,
+		repoPath,
+		results,
+		quarantineOptions.quarantineModuleVersion,
+		quarantineOptions.checksumDBURL,
+	); err != nil {
+		return nil, err
 	}
+	return results, nil
+}
 
-	packages, err := Packages(l, repoPath, quarantineOptions.buildFlags)
+// packageProcessor modifies the test files of a single package according to the target.
+type packageProcessor func(
+	l zerolog.Logger,
+	repoPath string,
+	pkg PackageInfo,
+	target QuarantineTarget,
+	options *quarantineOptions,
+) (QuarantinePackageResults, error)
+
+// processTargets loads the packages of a Go project and runs the processor on every targeted package in parallel.
+// action is used for logging, e.g. "quarantine" or "unquarantine".
+func processTargets(
+	l zerolog.Logger,
+	repoPath string,
+	targets []QuarantineTarget,
+	action string,
+	processor packageProcessor,
+	options ...QuarantineOption,
+) (QuarantineResults, error) {
+	quarantineOptions := newQuarantineOptions(options...)
+	packages, err := targetPackages(l, repoPath, targets, quarantineOptions.buildFlags)
 	if err != nil {
 		return nil, err
 	}
 
+	// Resolve external test packages, e.g. foo_test, to the package they test, so that their tests are processed together
+	resolvedTargets := make([]QuarantineTarget, 0, len(targets))
+	for _, target := range targets {
+		if pkg, err := packages.Get(target.Package); err == nil {
+			target.Package = pkg.ImportPath
+		}
+		resolvedTargets = append(resolvedTargets, target)
+	}
+
 	var (
-		sanitizedTargets  = sanitizeQuarantineTargets(quarantineTargets)
-		testsToQuarantine int
+		sanitizedTargets = sanitizeQuarantineTargets(resolvedTargets)
+		testsToProcess   int
 	)
-	for _, target := range sanitizedTargets { // Calculate the largest possible amount of results by how many tests we have to quarantine
-		testsToQuarantine += len(target.Tests)
+	for _, target := range sanitizedTargets { // Calculate the largest possible amount of results by how many tests we have to process
+		testsToProcess += len(target.Tests)
 	}
 
 	start := time.Now()
-	l.Info().Msg("Quarantining tests")
+	l = l.With().Str("action", action).Logger()
+	l.Info().Msg("Processing tests")
 	var (
 		packageResultsChan = make(chan QuarantinePackageResults, len(sanitizedTargets))
 		eg                 = errgroup.Group{}
@@ -244,7 +545,7 @@ func QuarantineTests(
 			if err != nil {
 				return fmt.Errorf("failed to get package %s: %w", target.Package, err)
 			}
-			results, err := quarantinePackage(l, repoPath, pkg, target)
+			results, err := processor(l, repoPath, pkg, target, quarantineOptions)
 			packageResultsChan <- results
 			return err
 		})
@@ -257,62 +558,86 @@ func QuarantineTests(
 	close(packageResultsChan)
 
 	var (
-		successfullyQuarantined = make([]string, 0, testsToQuarantine)
-		failedToQuarantine      = make([]string, 0, testsToQuarantine)
-		results                 = make(QuarantineResults, len(sanitizedTargets))
+		successes          = make([]string, 0, testsToProcess)
+		alreadyQuarantined = make([]string, 0, testsToProcess)
+		failures           = make([]string, 0, testsToProcess)
+		results            = make(QuarantineResults, len(sanitizedTargets))
 	)
 	for result := range packageResultsChan {
 		results[result.Package] = result
 		for _, success := range result.Successes {
-			for _, test := range success.TestNames() {
-				successfullyQuarantined = append(successfullyQuarantined, fmt.Sprintf("%s.%s", success.Package, test))
+			for _, test := range success.ModifiedTests() {
+				successes = append(successes, fmt.Sprintf("%s.%s", success.Package, test.Name))
+			}
+			for _, test := range success.AlreadyQuarantinedTests() {
+				alreadyQuarantined = append(alreadyQuarantined, fmt.Sprintf("%s.%s", success.Package, test.Name))
 			}
 		}
 		for _, failure := range result.Failures {
-			failedToQuarantine = append(failedToQuarantine, fmt.Sprintf("%s/%s", result.Package, failure))
+			failures = append(failures, fmt.Sprintf("%s/%s", result.Package, failure))
 		}
 	}
 
 	l.Info().
-		Strs("successfully_quarantined", successfullyQuarantined).
-		Strs("failed_to_quarantine", failedToQuarantine).
+		Strs("successes", successes).
+		Strs("already_quarantined", alreadyQuarantined).
+		Strs("failures", failures).
 		Str("duration", time.Since(start).String()).
-		Msg("Quarantine results")
+		Msg("Processing results")
 
 	return results, nil
 }
 
-// WriteQuarantineResultsToFiles writes successfully quarantined tests to the file system.
+// targetPackages loads only the packages of the targets, falling back to loading every package in the project
+// if they can't all be resolved that way.
+func targetPackages(
+	l zerolog.Logger,
+	repoPath string,
+	targets []QuarantineTarget,
+	buildFlags []string,
+) (*PackagesInfo, error) {
+	importPaths := make([]string, 0, len(targets))
+	for _, target := range targets {
+		importPaths = appendUnique(importPaths, []string{target.Package})
+	}
+
+	packages, err := PackagesFor(l, repoPath, buildFlags, importPaths)
+	if err == nil {
+		return packages, nil
+	}
+	l.Warn().Err(err).Msg("Unable to load only the targeted packages, loading all packages")
+	return Packages(l, repoPath, buildFlags)
+}
+
+// WriteQuarantineResultsToFiles writes successfully quarantined tests, and any updated go.mod and go.sum files,
+// to the file system, and deletes the files that were emptied.
+// Only files whose source code was actually modified are written.
 func WriteQuarantineResultsToFiles(l zerolog.Logger, results QuarantineResults) error {
 	for _, result := range results {
-		for _, success := range result.Successes {
-			if len(success.Tests) == 0 {
+		for _, file := range slices.Concat(result.Successes, result.ModuleFiles) {
+			if !file.Modified() {
 				continue
 			}
 
-			if err := os.WriteFile(success.FileAbs, []byte(success.ModifiedSourceCode), 0600); err != nil {
-				return fmt.Errorf("failed to write quarantine results to %s: %w", success.FileAbs, err)
+			if file.Deleted {
+				if err := os.Remove(file.FileAbs); err != nil {
+					return fmt.Errorf("failed to delete %s: %w", file.FileAbs, err)
+				}
+				l.Trace().Str("file", file.FileAbs).Str("package", result.Package).Msg("Deleted file")
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(file.FileAbs), 0750); err != nil {
+				return fmt.Errorf("failed to create directory of %s: %w", file.FileAbs, err)
+			}
+			if err := os.WriteFile(file.FileAbs, []byte(file.ModifiedSourceCode), 0600); err != nil {
+				return fmt.Errorf("failed to write quarantine results to %s: %w", file.FileAbs, err)
 			}
 			l.Trace().
-				Str("file", success.FileAbs).
-				Str("package", success.Package).
-				Strs("quarantined_tests", success.TestNames()).
+				Str("file", file.FileAbs).
+				Str("package", result.Package).
+				Strs("quarantined_tests", file.TestNames()).
 				Msg("Wrote quarantine results")
 		}
-
-		// Tidy the go.mod file to ensure the quarantine package is added to the module's dependencies.
-		goTidy := exec.Command("go", "mod", "tidy")
-		goTidy.Dir = result.GoModDir
-		output, err := goTidy.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf(
-				"failed to tidy go.mod file: %w\nCommand: %s\nDirectory: %s\nOutput:\n%s",
-				err,
-				goTidy.String(),
-				goTidy.Dir,
-				string(output),
-			)
-		}
 	}
 
 	return nil
@@ -348,12 +673,14 @@ func quarantinePackage(
 	l zerolog.Logger,
 	repoPath string,
 	pkg PackageInfo,
-	quarantineTarget QuarantineTarget,
+	target QuarantineTarget,
+	options *quarantineOptions,
 ) (QuarantinePackageResults, error) {
+	quarantineTarget := target.withDefaultExpiry(options.expiry)
 	testNames := quarantineTarget.TestNames()
 	l = l.With().
 		Str("package", pkg.ImportPath).
-		Strs("test_files", pkg.TestGoFiles).
+		Strs("test_files", pkg.AllTestGoFiles()).
 		Strs("tests_to_quarantine", testNames).
 		Logger()
 	l.Debug().Msg("Quarantining tests in package")
@@ -368,261 +695,457 @@ func quarantinePackage(
 		}
 	)
 
+	testFiles, suites, err := parseTestFiles(pkg, "quarantining")
+	if err != nil {
+		return results, err
+	}
+	quarantineTarget, results.PatternMatches = expandPatterns(l, testFiles, suites, quarantineTarget)
+
 	// Look through each test file in the package to see if we can find any of our target tests to quarantine.
-	for _, testFile := range pkg.TestGoFiles {
-		l := l.With().Str("test_file", testFile).Logger()
+	for _, testFile := range testFiles {
+		l := l.With().Str("test_file", testFile.Path).Logger()
 
-		// Parse the Go file using AST
-		fset := token.NewFileSet()
-		node, err := parser.ParseFile(fset, testFile, nil, parser.ParseComments)
-		if err != nil {
-			return results, fmt.Errorf(
-				"failed to parse %s while quarantining tests in %s: %w",
-				testFile,
-				pkg.ImportPath,
-				err,
-			)
+		foundTests := testsInFile(testFile.Node, quarantineTarget, suites)
+		if len(foundTests) == 0 {
+			continue
 		}
-
-		foundTests := testsInFile(node, quarantineTarget)
 		foundTestNames := make([]string, 0, len(foundTests))
 		for _, test := range foundTests {
 			haveQuarantined[test.Name] = true
 			foundTestNames = append(foundTestNames, test.Name)
 		}
 
-		modifiedSource, quarantinedTests, err := skipTests(fset, node, foundTests)
+		modifiedSource, quarantinedTests, err := skipTests(testFile, foundTests, suites, options.generator)
 		if err != nil {
-			return results, fmt.Errorf("failed to quarantine tests in file %s: %w", testFile, err)
+			return results, fmt.Errorf("failed to quarantine tests in file %s: %w", testFile.Path, err)
 		}
 		l.Debug().Strs("newly_quarantined_tests", foundTestNames).Msg("Successfully quarantined tests in file")
 
-		absRepoPath, err := filepath.Abs(repoPath)
+		relativeFilePath, err := relativePath(repoPath, testFile.Path)
 		if err != nil {
-			return results, fmt.Errorf("failed to get absolute path of repo %s: %w", repoPath, err)
+			return results, err
 		}
-		relativeFilePath := strings.TrimPrefix(testFile, absRepoPath)
-		relativeFilePath = strings.TrimPrefix(relativeFilePath, string(filepath.Separator))
 		results.Successes = append(results.Successes, QuarantinedFile{
 			Package:            pkg.ImportPath,
 			File:               relativeFilePath,
-			FileAbs:            testFile,
+			FileAbs:            testFile.Path,
 			Tests:              quarantinedTests,
+			OriginalSourceCode: string(testFile.Src),
 			ModifiedSourceCode: modifiedSource,
 		})
 	}
 
-	// Add any tests that were not found to the failures
-	for _, test := range quarantineTarget.Tests {
-		if !haveQuarantined[test.Name] {
-			results.Failures = append(results.Failures, test.Name)
+	if test, ok := quarantineTarget.packageTest(); ok && options.packageQuarantine {
+		quarantined, err := processPackageTest(repoPath, pkg, testFiles, &results,
+			func(testFile parsedTestFile, testMain *ast.FuncDecl) (string, QuarantinedTest, bool, error) {
+				modifiedSource, quarantinedTest, err := skipPackage(testFile, testMain, test, options.generator)
+				return modifiedSource, quarantinedTest, err == nil, err
+			},
+		)
+		if err != nil {
+			return results, fmt.Errorf("failed to quarantine package %s: %w", pkg.ImportPath, err)
 		}
+		haveQuarantined[PackageTestName] = quarantined
+		l.Debug().Bool("quarantined", quarantined).Msg("Quarantined whole package")
 	}
 
+	// Add any tests that were not found, and patterns that didn't match any tests, to the failures
+	results.Failures = append(results.Failures, targetFailures(target, results.PatternMatches, haveQuarantined)...)
+
 	return results, nil
 }
 
-// foundTest describes a test function that was found in a file and matches a test to quarantine.
+// parsedTestFile is a parsed test file of a package.
+// The source is kept alongside the AST so that modifications can be made as small text edits, see textEdit.
+type parsedTestFile struct {
+	Path string
+	Src  []byte
+	Fset *token.FileSet
+	Node *ast.File
+}
+
+// parseTestFile parses the source of a single test file.
+func parseTestFile(path string, src []byte) (parsedTestFile, error) {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return parsedTestFile{}, err
+	}
+	return parsedTestFile{Path: path, Src: src, Fset: fset, Node: node}, nil
+}
+
+// tokenFile returns the position information of the file, used to convert AST positions to byte offsets.
+func (f parsedTestFile) tokenFile() *token.File {
+	return f.Fset.File(f.Node.Pos())
+}
+
+// parseTestFiles parses all the test files of a package, including its external test package, and finds the testify
+// suites they run.
+// action is used for error messages, e.g. "quarantining".
+func parseTestFiles(pkg PackageInfo, action string) ([]parsedTestFile, suiteRunners, error) {
+	var (
+		allTestFiles = pkg.AllTestGoFiles()
+		testFiles    = make([]parsedTestFile, 0, len(allTestFiles))
+		nodes        = make([]*ast.File, 0, len(allTestFiles))
+	)
+	for _, testFile := range allTestFiles {
+		src, err := os.ReadFile(testFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s while %s tests in %s: %w", testFile, action, pkg.ImportPath, err)
+		}
+
+		// Parse the Go file using AST
+		parsed, err := parseTestFile(testFile, src)
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"failed to parse %s while %s tests in %s: %w",
+				testFile,
+				action,
+				pkg.ImportPath,
+				err,
+			)
+		}
+		testFiles = append(testFiles, parsed)
+		nodes = append(nodes, parsed.Node)
+	}
+	return testFiles, findSuiteRunners(nodes...), nil
+}
+
+// relativePath returns the path of a file relative to the root of the repository.
+func relativePath(repoPath, file string) (string, error) {
+	absRepoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path of repo %s: %w", repoPath, err)
+	}
+	relativeFilePath := strings.TrimPrefix(file, absRepoPath)
+	return strings.TrimPrefix(relativeFilePath, string(filepath.Separator)), nil
+}
+
+// foundTest describes a test function or subtest that was found in a file and matches a test to quarantine.
 type foundTest struct {
-	FuncDecl *ast.FuncDecl
+	FuncDecl *ast.FuncDecl // Test function, or the parent test function of a subtest
+	Scopes   []testScope   // Where the quarantine goes: the test function itself, or the closures of matching subtests
 	TestToQuarantine
 }
 
 // testsInFile searches for all test functions in the Go test file's AST that match the given test names.
-func testsInFile(node *ast.File, quarantineTarget QuarantineTarget) []foundTest {
+// Subtests, e.g. "TestFoo/subtest_1", are resolved to the t.Run calls that run them,
+// and testify suite methods, e.g. "TestMySuite/TestFoo", to the method declarations using the package's suites.
+func testsInFile(node *ast.File, quarantineTarget QuarantineTarget, suites suiteRunners) []foundTest {
 	testNames := quarantineTarget.TestNames()
 	found := make([]foundTest, 0, len(testNames))
 	for _, decl := range node.Decls {
-		// Check if this declaration is a function
-		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
-			// Check if it's a test function with the right name
-			if isTestFunction(funcDecl) && slices.Contains(testNames, funcDecl.Name.Name) {
-				found = append(found, foundTest{
-					FuncDecl: funcDecl,
-					TestToQuarantine: TestToQuarantine{
-						Name:       funcDecl.Name.Name,
-						JiraTicket: quarantineTarget.JiraTicketForTestName(funcDecl.Name.Name),
-					},
-				})
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Body == nil {
+			continue
+		}
+
+		for _, testName := range testNames {
+			if slices.ContainsFunc(found, func(f foundTest) bool { return f.Name == testName }) {
+				continue
+			}
+
+			var scopes []testScope
+			if funcDecl.Recv != nil {
+				scopes = suiteMethodScopes(funcDecl, testName, suites)
+			} else {
+				scopes = testFunctionScopes(node, funcDecl, testName)
+			}
+			if len(scopes) == 0 {
+				continue
 			}
+			found = append(found, foundTest{
+				FuncDecl:         funcDecl,
+				Scopes:           scopes,
+				TestToQuarantine: quarantineTarget.testForName(testName),
+			})
 		}
 	}
 	return found
 }
 
-// isTestFunction checks if a function declaration is a test function
-func isTestFunction(funcDecl *ast.FuncDecl) bool {
-	if funcDecl.Name == nil {
+// testFunctionScopes returns where to quarantine the test in the test function,
+// if the test function or one of its subtests matches the test name.
+func testFunctionScopes(node *ast.File, funcDecl *ast.FuncDecl, testName string) []testScope {
+	funcName, subtestPath, isSubtest := strings.Cut(testName, "/")
+	if funcName != funcDecl.Name.Name || !isTestFunction(node, funcDecl) {
+		return nil
+	}
+	if isSubtest {
+		return findSubtestScopes(node, funcDecl, strings.Split(subtestPath, "/"))
+	}
+	return []testScope{{
+		Pos:          funcDecl.Pos(),
+		Body:         funcDecl.Body,
+		TestingParam: testingParamName(funcDecl.Type),
+	}}
+}
+
+// isTestFunction checks if a function declaration is a test, benchmark or fuzz test: a Test*, Benchmark* or Fuzz*
+// function taking a single *testing.T, *testing.B, *testing.F or testing.TB parameter.
+// The testing package may be imported under another name in the file.
+func isTestFunction(node *ast.File, funcDecl *ast.FuncDecl) bool {
+	if funcDecl.Name == nil || funcDecl.Recv != nil {
 		return false
 	}
 
-	if !strings.HasPrefix(funcDecl.Name.Name, "Test") && !strings.HasPrefix(funcDecl.Name.Name, "Fuzz") {
+	name := funcDecl.Name.Name
+	if !strings.HasPrefix(name, "Test") && !strings.HasPrefix(name, "Benchmark") && !strings.HasPrefix(name, "Fuzz") {
 		return false
 	}
 
 	// Check the function signature
-	if funcDecl.Type.Params == nil || len(funcDecl.Type.Params.List) != 1 {
+	if funcDecl.Type.Params == nil || len(funcDecl.Type.Params.List) != 1 || len(funcDecl.Type.Params.List[0].Names) > 1 {
 		return false
 	}
 
-	param := funcDecl.Type.Params.List[0]
+	switch testingTypeName(node, funcDecl.Type.Params.List[0].Type) {
+	case "*T", "*B", "*F", "TB":
+		return true
+	default:
+		return false
+	}
+}
 
-	// Check if parameter is *testing.T or *testing.F
-	if starExpr, ok := param.Type.(*ast.StarExpr); ok {
-		if selectorExpr, ok := starExpr.X.(*ast.SelectorExpr); ok {
-			if ident, ok := selectorExpr.X.(*ast.Ident); ok {
-				return (ident.Name == "testing" && (selectorExpr.Sel.Name == "T" || selectorExpr.Sel.Name == "F"))
-			}
-		}
+// testingTypeName returns the name of the testing package type the expression refers to, prefixed with * for pointers,
+// e.g. "*T" for *testing.T. Returns the empty string if the expression isn't a type of the testing package.
+func testingTypeName(node *ast.File, expr ast.Expr) string {
+	prefix := ""
+	if starExpr, ok := expr.(*ast.StarExpr); ok {
+		prefix, expr = "*", starExpr.X
 	}
 
-	return false
+	testingName := importLocalName(node, "testing")
+	switch typeExpr := expr.(type) {
+	case *ast.SelectorExpr:
+		if ident, ok := typeExpr.X.(*ast.Ident); ok && testingName != "." && ident.Name == testingName {
+			return prefix + typeExpr.Sel.Name
+		}
+	case *ast.Ident:
+		if testingName == "." { // Dot import, e.g. func TestFoo(t *T)
+			return prefix + typeExpr.Name
+		}
+	}
+	return ""
 }
 
-// skipTests adds conditional quarantine logic to the beginning of the test function using quarantine.Flaky().
+// skipTests adds conditional quarantine logic to the beginning of the test function, generated by the generator.
+// Subtests are quarantined inside their t.Run closure, see quarantineStmt.
+// Tests that are already quarantined are left alone, other than updating the ticket if it changed.
+// Tests that are skipped manually with t.Skip() are also considered already quarantined.
+// Only the quarantine statements and the import are added to the source, the rest of the file is left untouched.
 func skipTests(
-	fset *token.FileSet,
-	fileRootNode *ast.File,
+	testFile parsedTestFile,
 	testsToSkip []foundTest,
+	suites suiteRunners,
+	generator Generator,
 ) (string, []QuarantinedTest, error) {
-	// Ensure quarantine package is imported for the conditional logic
-	if len(testsToSkip) > 0 && !hasImport(fileRootNode, "github.com/smartcontractkit/branch-out/quarantine") {
-		addImport(fileRootNode, "github.com/smartcontractkit/branch-out/quarantine")
-	}
-
-	// Store original line numbers and test names
-	originalPositions := make(map[string]int)
-	for _, testToSkip := range testsToSkip {
-		originalPositions[testToSkip.Name] = fset.Position(testToSkip.FuncDecl.Pos()).Line
-	}
+	var (
+		fset, fileRootNode   = testFile.Fset, testFile.Node
+		tokenFile            = testFile.tokenFile()
+		importName, imported = generatorImport(fileRootNode, generator)
+	)
 
-	quarantinedTests := make([]QuarantinedTest, 0, len(testsToSkip))
-	// Apply modifications
+	var (
+		quarantinedTests = make([]QuarantinedTest, 0, len(testsToSkip))
+		edits            = make([]textEdit, 0, len(testsToSkip))
+		bodies           []*ast.BlockStmt                      // Bodies in the order we first insert into them
+		inserted         = make(map[*ast.BlockStmt][]ast.Stmt) // Keep quarantines sharing a body in the order of the targets
+	)
 	for _, testToSkip := range testsToSkip {
-		paramName := "t" // default fallback for testing.T param name
-		funcDecl := testToSkip.FuncDecl
-		if funcDecl.Type.Params != nil && len(funcDecl.Type.Params.List) > 0 {
-			param := funcDecl.Type.Params.List[0]
-			if len(param.Names) > 0 && param.Names[0] != nil {
-				paramName = param.Names[0].Name
+		quarantinedTest := QuarantinedTest{
+			Name:               testToSkip.Name,
+			JiraTicket:         testToSkip.JiraTicket,
+			OriginalLine:       fset.Position(testToSkip.Scopes[0].Pos).Line,
+			AlreadyQuarantined: true,
+		}
+		for _, scope := range testToSkip.Scopes {
+			existing, manuallySkipped := existingQuarantine(generator, scope, imported, importName, testToSkip.Name)
+			switch {
+			case existing.Stmt != nil:
+				// Already quarantined, only update the ticket if it changed
+				if existing.Ticket == testToSkip.JiraTicket {
+					continue
+				}
+				site := quarantineSite(importName, scope, testToSkip.Name, testToSkip.JiraTicket)
+				edit, err := updateTicketEdit(testFile.Src, tokenFile, generator, site, existing)
+				if err != nil {
+					return "", nil, err
+				}
+				edits = append(edits, edit)
+				quarantinedTest.PreviousJiraTicket = existing.Ticket
+				quarantinedTest.AlreadyQuarantined = false
+			case manuallySkipped:
+				continue
+			default:
+				if _, ok := inserted[scope.Body]; !ok {
+					bodies = append(bodies, scope.Body)
+				}
+				inserted[scope.Body] = append(
+					inserted[scope.Body],
+					quarantineStmt(generator, importName, scope, testToSkip.TestToQuarantine),
+				)
+				quarantinedTest.AlreadyQuarantined = false
 			}
 		}
+		quarantinedTests = append(quarantinedTests, quarantinedTest)
+	}
 
-		quarantineCall := &ast.CallExpr{
-			Fun: &ast.SelectorExpr{
-				X:   &ast.Ident{Name: "quarantine"},
-				Sel: &ast.Ident{Name: "Flaky"},
-			},
-			Args: []ast.Expr{
-				&ast.BasicLit{Kind: token.STRING, Value: paramName},
-				&ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf(`"%s"`, testToSkip.JiraTicket)},
-			},
+	for _, body := range bodies {
+		bodyEdits, err := insertStmtsEdits(testFile.Src, tokenFile, body, inserted[body])
+		if err != nil {
+			return "", nil, err
 		}
-
-		funcDecl.Body.List = append(
-			[]ast.Stmt{&ast.ExprStmt{X: quarantineCall}},
-			funcDecl.Body.List...,
-		)
-		quarantinedTests = append(quarantinedTests, QuarantinedTest{
-			Name:         testToSkip.Name,
-			JiraTicket:   testToSkip.JiraTicket,
-			OriginalLine: originalPositions[testToSkip.Name],
-		})
+		edits = append(edits, bodyEdits...)
 	}
 
-	// Format the modified AST
-	var modifiedNode bytes.Buffer
-	if err := format.Node(&modifiedNode, fset, fileRootNode); err != nil {
-		return "", nil, fmt.Errorf("failed to format modified source: %w", err)
+	// Ensure the generator's package is imported for the conditional logic
+	if len(inserted) > 0 && !imported {
+		edits = append(edits, addImportEdit(testFile.Src, tokenFile, fileRootNode, generator.ImportPath()))
 	}
 
-	// Parse the formatted result to get exact line numbers
-	modifiedSource := modifiedNode.String()
-	newFset := token.NewFileSet()
-	newNode, err := parser.ParseFile(newFset, "", modifiedSource, parser.ParseComments)
+	modifiedSource, err := applyEdits(testFile.Src, edits)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to parse modified source: %w", err)
+		return "", nil, fmt.Errorf("failed to edit source: %w", err)
+	}
+	if err := setModifiedLines(modifiedSource, quarantinedTests, suites); err != nil {
+		return "", nil, err
 	}
+	return modifiedSource, quarantinedTests, nil
+}
 
-	// Find the modified line numbers and update the quarantinedTests slice
-	for _, decl := range newNode.Decls {
-		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
-			if isTestFunction(funcDecl) {
-				for index, quarantinedTest := range quarantinedTests {
-					if quarantinedTest.Name == funcDecl.Name.Name {
-						quarantinedTests[index].ModifiedLine = newFset.Position(funcDecl.Pos()).Line
-					}
-				}
-			}
-		}
+// generatorImport returns the name the generator's import path is referred to by in the file, and whether the file
+// already imports it. If it doesn't, the name it will be imported as is returned.
+// Generators without an import path are always considered imported.
+func generatorImport(node *ast.File, generator Generator) (importName string, imported bool) {
+	importPath := generator.ImportPath()
+	if importPath == "" {
+		return "", true
+	}
+	importName = importLocalName(node, importPath)
+	if importName != "" && importName != "_" {
+		return importName, true
 	}
+	return importPath[strings.LastIndex(importPath, "/")+1:], false
+}
 
-	return modifiedSource, quarantinedTests, nil
+// existingQuarantineStmt is a quarantine statement that was generated before.
+type existingQuarantineStmt struct {
+	Stmt       ast.Stmt // The generated statement, without any table test guard
+	Ticket     string   // Ticket the test is quarantined for
+	TicketExpr ast.Expr // Expression holding the ticket in the statement, if any
 }
 
-// hasImport checks if the given import path is already imported in the file
-func hasImport(node *ast.File, importPath string) bool {
-	for _, imp := range node.Imports {
-		if imp.Path != nil && strings.Trim(imp.Path.Value, `"`) == importPath {
-			return true
+// existingQuarantine looks through the top level statements of the scope for a quarantine of the test generated by
+// the generator. It returns the existing quarantine statement if there is one,
+// or true if the test is skipped manually, e.g. t.Skip().
+func existingQuarantine(
+	generator Generator,
+	scope testScope,
+	imported bool,
+	importName, testName string,
+) (existing existingQuarantineStmt, manuallySkipped bool) {
+	for _, stmt := range scope.Body.List {
+		if imported {
+			if quarantine, ticket, ticketExpr, ok := scopeQuarantine(generator, importName, stmt, scope, testName); ok {
+				return existingQuarantineStmt{Stmt: quarantine, Ticket: ticket, TicketExpr: ticketExpr}, false
+			}
+		}
+		if isSkipCall(stmt, scope) {
+			manuallySkipped = true
 		}
 	}
-	return false
+	return existingQuarantineStmt{}, manuallySkipped
 }
 
-// addImport adds an import to the file's import list
-func addImport(fileRootNode *ast.File, importPath string) {
-	// Create the import spec
-	importSpec := &ast.ImportSpec{
-		Path: &ast.BasicLit{
-			Kind:  token.STRING,
-			Value: `"` + importPath + `"`,
-		},
+// updateTicketEdit changes the ticket of an existing quarantine statement to the site's ticket.
+// Only the expression holding the ticket is replaced if there is one, otherwise the whole statement is regenerated.
+func updateTicketEdit(
+	src []byte,
+	tokenFile *token.File,
+	generator Generator,
+	site QuarantineSite,
+	existing existingQuarantineStmt,
+) (textEdit, error) {
+	replacement := generator.Generate(site)
+	if existing.TicketExpr == nil {
+		return replaceStmtEdit(src, tokenFile, existing.Stmt, replacement)
 	}
-
-	// If there are no imports yet, create a new import declaration
-	if len(fileRootNode.Decls) == 0 || fileRootNode.Imports == nil {
-		importDecl := &ast.GenDecl{
-			Tok:   token.IMPORT,
-			Specs: []ast.Spec{importSpec},
-		}
-		fileRootNode.Decls = append([]ast.Decl{importDecl}, fileRootNode.Decls...)
-		fileRootNode.Imports = []*ast.ImportSpec{importSpec}
-		return
+	_, ticketExpr, ok := generator.Match(replacement, site)
+	if !ok || ticketExpr == nil {
+		return replaceStmtEdit(src, tokenFile, existing.Stmt, replacement)
 	}
+	return replaceExprEdit(tokenFile, existing.TicketExpr, ticketExpr)
+}
 
-	// Find the first import declaration and add to it
-	for i, decl := range fileRootNode.Decls {
-		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.IMPORT {
-			genDecl.Specs = append(genDecl.Specs, importSpec)
-			fileRootNode.Imports = append(fileRootNode.Imports, importSpec)
-			return
+// isSkipCall checks if the statement is a t.Skip(), t.Skipf() or t.SkipNow() call on the testing parameter,
+// or on s.T() for testify suite methods.
+func isSkipCall(stmt ast.Stmt, scope testScope) bool {
+	exprStmt, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return false
+	}
+	call, ok := exprStmt.X.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	receiver := selector.X
+	if scope.Suite {
+		suiteT, ok := receiver.(*ast.CallExpr)
+		if !ok || len(suiteT.Args) != 0 {
+			return false
 		}
-		// If we hit a non-import declaration, insert a new import declaration before it
-		if i == 0 || (i == 1 && isPackageDeclaration(fileRootNode.Decls[0])) {
-			importDecl := &ast.GenDecl{
-				Tok:   token.IMPORT,
-				Specs: []ast.Spec{importSpec},
-			}
-			fileRootNode.Decls = append(
-				fileRootNode.Decls[:i],
-				append([]ast.Decl{importDecl}, fileRootNode.Decls[i:]...)...)
-			fileRootNode.Imports = append(fileRootNode.Imports, importSpec)
-			return
+		tSelector, ok := suiteT.Fun.(*ast.SelectorExpr)
+		if !ok || tSelector.Sel.Name != "T" {
+			return false
 		}
+		receiver = tSelector.X
+	}
+	if ident, ok := receiver.(*ast.Ident); !ok || ident.Name != scope.TestingParam {
+		return false
 	}
+	switch selector.Sel.Name {
+	case "Skip", "Skipf", "SkipNow":
+		return true
+	}
+	return false
+}
 
-	// Fallback: add at the beginning
-	importDecl := &ast.GenDecl{
-		Tok:   token.IMPORT,
-		Specs: []ast.Spec{importSpec},
+// copyNameExpr copies an expression naming a subtest, e.g. tc.name, without its positions,
+// so that the printer doesn't try to keep it at its original place in the file.
+func copyNameExpr(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return ast.NewIdent(e.Name)
+	case *ast.SelectorExpr:
+		return &ast.SelectorExpr{X: copyNameExpr(e.X), Sel: ast.NewIdent(e.Sel.Name)}
+	default:
+		return expr
 	}
-	fileRootNode.Decls = append([]ast.Decl{importDecl}, fileRootNode.Decls...)
-	fileRootNode.Imports = append(fileRootNode.Imports, importSpec)
 }
 
-// isPackageDeclaration checks if a declaration is a package declaration
-func isPackageDeclaration(decl ast.Decl) bool {
-	_, ok := decl.(*ast.GenDecl)
-	return !ok // Package declarations are not GenDecl
+// setModifiedLines parses the modified source to find the exact line numbers of the tests after modification.
+func setModifiedLines(modifiedSource string, tests []QuarantinedTest, suites suiteRunners) error {
+	newFset := token.NewFileSet()
+	newNode, err := parser.ParseFile(newFset, "", modifiedSource, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("failed to parse modified source: %w", err)
+	}
+
+	target := QuarantineTarget{Tests: make([]TestToQuarantine, 0, len(tests))}
+	for _, test := range tests {
+		target.Tests = append(target.Tests, TestToQuarantine{Name: test.Name})
+	}
+	modifiedLines := make(map[string]int, len(tests))
+	for _, found := range testsInFile(newNode, target, suites) {
+		modifiedLines[found.Name] = newFset.Position(found.Scopes[0].Pos).Line
+	}
+	for index, test := range tests {
+		tests[index].ModifiedLine = modifiedLines[test.Name]
+	}
+	return nil
 }
@@ -6,7 +6,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"slices"
 	"strings"
 	"testing"
 
@@ -141,7 +140,13 @@ func TestQuarantineTests_Integration(t *testing.T) {
 		{name: "sub tests nested", quarantineTargets: []golang.QuarantineTarget{
 			{
 				Package: nestedProjectPackage,
-				Tests:   subTestNames,
+				Tests:   nestedSubTestNames,
+			},
+		}},
+		{name: "suite tests", quarantineTargets: []golang.QuarantineTarget{
+			{
+				Package: baseProjectPackage,
+				Tests:   suiteTestNames,
 			},
 		}},
 		{name: "unusual tests", quarantineTargets: []golang.QuarantineTarget{
@@ -162,6 +167,12 @@ func TestQuarantineTests_Integration(t *testing.T) {
 				Tests:   testPackageTestNames,
 			},
 		}},
+		{name: "test package tests by external test package", quarantineTargets: []golang.QuarantineTarget{
+			{
+				Package: baseProjectTestPackage + "_test",
+				Tests:   testPackageTestNames,
+			},
+		}},
 		{name: "test package tests nested", quarantineTargets: []golang.QuarantineTarget{
 			{
 				Package: nestedProjectTestPackage,
@@ -185,16 +196,6 @@ func TestQuarantineTests_Integration(t *testing.T) {
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
 			t.Parallel()
-			casesToSkip := []string{ // These test cases are knowingly broken for now
-				"sub tests",
-				"sub tests nested",
-				"test package tests",
-				"test package tests nested",
-			}
-			if slices.Contains(casesToSkip, testCase.name) {
-				t.Skip("skipping known broken test case")
-			}
-
 			l := testhelpers.Logger(t)
 			dir := setupDir(t)
 			_, successfullyQuarantinedTests := quarantineTests(t, l, dir, testCase.quarantineTargets)
@@ -241,6 +242,107 @@ func TestQuarantineTests_Integration(t *testing.T) {
 
 }
 
+func TestQuarantineTests_Integration_AlreadyQuarantined(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping integration tests in short mode")
+	}
+
+	targets := []golang.QuarantineTarget{
+		{
+			Package: baseProjectPackage,
+			Tests:   standardTestNames,
+		},
+	}
+
+	l := testhelpers.Logger(t)
+	dir := setupDir(t)
+	standardTestFile := filepath.Join(dir, "standard_test.go")
+	quarantineTests(t, l, dir, targets)
+	quarantinedSource, err := os.ReadFile(standardTestFile)
+	require.NoError(t, err, "failed to read quarantined test file")
+
+	requarantineResults, err := golang.QuarantineTests(
+		l,
+		dir,
+		targets,
+		golang.WithBuildFlags(exampleProjectBuildFlags),
+	)
+	require.NoError(t, err, "failed to run quarantine function a second time")
+	assert.Equal(t, 0, requarantineResults.SuccessfulTestsCount(), "no tests should be quarantined a second time")
+	assert.Equal(t, len(standardTestNames), requarantineResults.AlreadyQuarantinedTestsCount())
+
+	err = golang.WriteQuarantineResultsToFiles(l, requarantineResults)
+	require.NoError(t, err, "failed to write quarantine results to files")
+	requarantinedSource, err := os.ReadFile(standardTestFile)
+	require.NoError(t, err, "failed to read re-quarantined test file")
+	assert.Equal(t, string(quarantinedSource), string(requarantinedSource), "quarantining twice should not change the file")
+}
+
+func TestUnquarantineTests_Integration(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping integration tests in short mode")
+	}
+
+	targets := []golang.QuarantineTarget{
+		{
+			Package: baseProjectPackage,
+			Tests:   standardTestNames,
+		},
+	}
+
+	l := testhelpers.Logger(t)
+	dir := setupDir(t)
+	standardTestFile := filepath.Join(dir, "standard_test.go")
+	originalSource, err := os.ReadFile(standardTestFile)
+	require.NoError(t, err, "failed to read original test file")
+
+	quarantineTests(t, l, dir, targets)
+
+	unquarantineResults, err := golang.UnquarantineTests(
+		l,
+		dir,
+		targets,
+		golang.WithBuildFlags(exampleProjectBuildFlags),
+	)
+	require.NoError(t, err, "failed to run unquarantine function")
+	require.True(t, unquarantineResults.Unquarantine(), "results should be marked as un-quarantine results")
+	require.Equal(t, len(standardTestNames), unquarantineResults.SuccessfulTestsCount())
+	for _, result := range unquarantineResults {
+		assert.Empty(t, result.Failures, "failed to un-quarantine these tests in package '%s'", result.Package)
+		for _, success := range result.Successes {
+			for _, test := range success.Tests {
+				assert.NotEmpty(t, test.JiraTicket, "ticket should be read from the quarantine call of %s", test.Name)
+			}
+		}
+	}
+
+	err = golang.WriteQuarantineResultsToFiles(l, unquarantineResults)
+	require.NoError(t, err, "failed to write unquarantine results to files")
+
+	unquarantinedSource, err := os.ReadFile(standardTestFile)
+	require.NoError(t, err, "failed to read un-quarantined test file")
+	assert.Equal(t, string(originalSource), string(unquarantinedSource), "un-quarantining should restore the original file")
+
+	testOutput, _ := runExampleTests( //nolint:testifylint // If there's an error here, it's likely because the tests failed, which doesn't stop us from checking the results
+		t,
+		dir,
+		map[string]string{},
+		standardTestNames[0].Name,
+		standardTestNames[1].Name,
+		standardTestNames[2].Name,
+	)
+	testResults, err := testhelpers.ParseTestOutputs(testOutput)
+	require.NoError(t, err, "failed to parse test output")
+
+	pkgResults, ok := testResults[baseProjectPackage]
+	require.True(t, ok, "package %s not found in test results", baseProjectPackage)
+	for _, test := range standardTestNames {
+		assert.NotContains(t, pkgResults.Skipped, test.Name, "'%s' should run after being un-quarantined", test.Name)
+	}
+}
+
 // runExampleTestsWithEnv runs go test for the example_project with additional env vars
 // and returns the test results.
 // It returns the test output and any error that occurred while running the tests.
@@ -319,6 +421,7 @@ func quarantineTests(
 	successfullyQuarantinedTests := []golang.QuarantineTarget{}
 	for _, result := range quarantineResults {
 		for _, success := range result.Successes {
+			assert.NotEmpty(t, success.Tests, "file %s has no quarantined tests and should not be in the results", success.File)
 			tests := []golang.TestToQuarantine{}
 			for _, test := range success.Tests {
 				tests = append(tests, golang.TestToQuarantine{
@@ -365,6 +468,16 @@ var (
 		{Name: "TestSubTestsTableStatic/subtest_2", JiraTicket: "JIRA-SUB-4"},
 		{Name: "TestSubTestsTableDynamic/subtest_1", JiraTicket: "JIRA-SUB-5"},
 		{Name: "TestSubTestsTableDynamic/subtest_2", JiraTicket: "JIRA-SUB-6"},
+		{Name: "TestSubSubTestsStatic/parent_subtest/sub-subtest_1", JiraTicket: "JIRA-SUB-7"},
+	}
+	nestedSubTestNames = []golang.TestToQuarantine{
+		{Name: "TestPassSubTestsStatic/subtest_1", JiraTicket: "JIRA-NESTED-SUB-1"},
+		{Name: "TestPassSubTestsTableStatic/subtest_2", JiraTicket: "JIRA-NESTED-SUB-2"},
+		{Name: "TestSubTestsTableDynamic/subtest_1", JiraTicket: "JIRA-NESTED-SUB-3"},
+		{Name: "TestSubSubTestsStatic/parent_subtest/sub-subtest_2", JiraTicket: "JIRA-NESTED-SUB-4"},
+	}
+	suiteTestNames = []golang.TestToQuarantine{
+		{Name: "TestExampleSuite/TestSuiteMethod1", JiraTicket: "JIRA-SUITE-1"},
 	}
 	unusualTestNames = []golang.TestToQuarantine{
 		{Name: "FuzzExampleProject", JiraTicket: "JIRA-UNUSUAL-1"},
@@ -1,9 +1,13 @@
 package golang
 
 import (
+	"go/ast"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSanitizeQuarantineTargets(t *testing.T) {
@@ -48,3 +52,208 @@ func TestSanitizeQuarantineTargets(t *testing.T) {
 		})
 	}
 }
+
+func TestSkipTests_AlreadyQuarantined(t *testing.T) {
+	t.Parallel()
+
+	source := `package example
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestQuarantined(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-1")
+	t.Parallel()
+}
+
+func TestSkipped(t *testing.T) {
+	t.Skip("flaky")
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+}
+`
+
+	tests := []struct {
+		name                       string
+		target                     TestToQuarantine
+		expectedAlreadyQuarantined bool
+		expectedPreviousTicket     string
+		expectedSourceContains     string
+	}{
+		{
+			name:                       "same ticket",
+			target:                     TestToQuarantine{Name: "TestQuarantined", JiraTicket: "JIRA-1"},
+			expectedAlreadyQuarantined: true,
+			expectedSourceContains:     `quarantine.Flaky(t, "JIRA-1")`,
+		},
+		{
+			name:                   "different ticket",
+			target:                 TestToQuarantine{Name: "TestQuarantined", JiraTicket: "JIRA-2"},
+			expectedPreviousTicket: "JIRA-1",
+			expectedSourceContains: `quarantine.Flaky(t, "JIRA-2")`,
+		},
+		{
+			name:                       "manually skipped",
+			target:                     TestToQuarantine{Name: "TestSkipped", JiraTicket: "JIRA-3"},
+			expectedAlreadyQuarantined: true,
+			expectedSourceContains:     `t.Skip("flaky")`,
+		},
+		{
+			name:                   "not quarantined",
+			target:                 TestToQuarantine{Name: "TestNew", JiraTicket: "JIRA-4"},
+			expectedSourceContains: `quarantine.Flaky(t, "JIRA-4")`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testFile, err := parseTestFile("example_test.go", []byte(source))
+			require.NoError(t, err)
+
+			target := QuarantineTarget{Tests: []TestToQuarantine{test.target}}
+			modifiedSource, quarantinedTests, err := skipTests(
+				testFile,
+				testsInFile(testFile.Node, target, nil),
+				nil,
+				FlakyGenerator(),
+			)
+			require.NoError(t, err)
+			require.Len(t, quarantinedTests, 1)
+
+			quarantinedTest := quarantinedTests[0]
+			assert.Equal(t, test.target.JiraTicket, quarantinedTest.JiraTicket)
+			assert.Equal(t, test.expectedAlreadyQuarantined, quarantinedTest.AlreadyQuarantined)
+			assert.Equal(t, test.expectedPreviousTicket, quarantinedTest.PreviousJiraTicket)
+			assert.Equal(
+				t,
+				1,
+				strings.Count(modifiedSource, test.expectedSourceContains),
+				"should have exactly one quarantine for the test",
+			)
+			if test.expectedPreviousTicket != "" {
+				assert.NotContains(t, modifiedSource, test.expectedPreviousTicket, "previous ticket should be replaced")
+			}
+			if test.expectedAlreadyQuarantined {
+				assert.Equal(t, source, modifiedSource, "already quarantined tests should not change the source")
+			}
+		})
+	}
+}
+
+func TestIsTestFunction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		imports  string
+		decl     string
+		expected bool
+	}{
+		{name: "test", imports: `"testing"`, decl: "func TestA(t *testing.T) {}", expected: true},
+		{name: "benchmark", imports: `"testing"`, decl: "func BenchmarkA(b *testing.B) {}", expected: true},
+		{name: "fuzz test", imports: `"testing"`, decl: "func FuzzA(f *testing.F) {}", expected: true},
+		{name: "testing.TB", imports: `"testing"`, decl: "func TestA(tb testing.TB) {}", expected: true},
+		{name: "aliased import", imports: `tst "testing"`, decl: "func TestA(t *tst.T) {}", expected: true},
+		{name: "dot import", imports: `. "testing"`, decl: "func BenchmarkA(b *B) {}", expected: true},
+		{name: "TestMain", imports: `"testing"`, decl: "func TestMain(m *testing.M) {}", expected: false},
+		{name: "wrong package", imports: `testing "example.com/testing"`, decl: "func TestA(t *testing.T) {}"},
+		{name: "shadowed alias", imports: `tst "testing"`, decl: "func TestA(t *testing.T) {}"},
+		{name: "wrong prefix", imports: `"testing"`, decl: "func CheckA(t *testing.T) {}"},
+		{name: "method", imports: `"testing"`, decl: "func (s) TestA(t *testing.T) {}"},
+		{name: "two params", imports: `"testing"`, decl: "func TestA(t *testing.T, n int) {}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testFile, err := parseTestFile("a_test.go", []byte("package a\n\nimport "+test.imports+"\n\n"+test.decl+"\n"))
+			require.NoError(t, err)
+			funcDecl, ok := testFile.Node.Decls[len(testFile.Node.Decls)-1].(*ast.FuncDecl)
+			require.True(t, ok)
+			assert.Equal(t, test.expected, isTestFunction(testFile.Node, funcDecl))
+		})
+	}
+}
+
+func TestQuarantineResults_Get(t *testing.T) {
+	t.Parallel()
+
+	results := QuarantineResults{
+		"github.com/example/pkg": {Package: "github.com/example/pkg"},
+	}
+
+	result, ok := results.Get("github.com/example/pkg")
+	require.True(t, ok)
+	assert.Equal(t, "github.com/example/pkg", result.Package)
+
+	result, ok = results.Get("github.com/example/pkg_test")
+	require.True(t, ok, "external test packages should get the results of the package they test")
+	assert.Equal(t, "github.com/example/pkg", result.Package)
+
+	_, ok = results.Get("github.com/example/other")
+	assert.False(t, ok)
+	_, ok = results.Get("github.com/example/other_test")
+	assert.False(t, ok)
+}
+
+func TestQuarantineResults_Markdown(t *testing.T) {
+	t.Parallel()
+
+	results := QuarantineResults{
+		"github.com/example/pkg": {
+			Package: "github.com/example/pkg",
+			Successes: []QuarantinedFile{
+				{
+					Package: "github.com/example/pkg",
+					File:    "pkg/a_test.go",
+					Tests: []QuarantinedTest{
+						{Name: "TestNew", JiraTicket: "JIRA-1", ModifiedLine: 10},
+						{Name: "TestUpdated", JiraTicket: "JIRA-2", PreviousJiraTicket: "JIRA-0", ModifiedLine: 20},
+						{Name: "TestOld", JiraTicket: "JIRA-3", AlreadyQuarantined: true, ModifiedLine: 30},
+					},
+				},
+			},
+			PatternMatches: map[string][]string{"TestN*": {"TestNew"}},
+		},
+	}
+
+	assert.Equal(t, 2, results.SuccessfulTestsCount())
+	assert.Equal(t, 1, results.AlreadyQuarantinedTestsCount())
+
+	md := results.Markdown("owner", "repo", "branch")
+	successes, alreadyQuarantined, found := strings.Cut(md, "### 1 tests were already quarantined, no changes needed")
+	require.True(t, found, "already quarantined tests should have their own section")
+	assert.Contains(t, successes, "### Successfully Quarantined 2 tests")
+	assert.Contains(t, successes, "[TestNew](https://github.com/owner/repo/blob/branch/pkg/a_test.go#L10)")
+	assert.Contains(t, successes, "(ticket updated from JIRA-0 to JIRA-2)")
+	assert.Contains(t, successes, "`TestN*` matched `TestNew`")
+	assert.NotContains(t, successes, "TestOld")
+	assert.Contains(t, alreadyQuarantined, "[TestOld](https://github.com/owner/repo/blob/branch/pkg/a_test.go#L30)")
+	assert.NotContains(t, alreadyQuarantined, "TestNew")
+}
+
+func TestWithDefaultExpiry(t *testing.T) {
+	t.Parallel()
+
+	until := time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC)
+	target := QuarantineTarget{Tests: []TestToQuarantine{
+		{Name: "TestA", JiraTicket: "JIRA-A"},
+		{Name: "TestB", JiraTicket: "JIRA-B", Until: until},
+	}}
+
+	assert.Equal(t, target, target.withDefaultExpiry(0), "no expiry should leave the tests alone")
+
+	expiring := target.withDefaultExpiry(30 * 24 * time.Hour)
+	expected := time.Now().UTC().AddDate(0, 0, 30).Truncate(24 * time.Hour)
+	assert.Equal(t, expected, expiring.Tests[0].Until, "tests without an expiry should get the default one")
+	assert.Equal(t, until, expiring.Tests[1].Until, "tests with an expiry should keep theirs")
+	assert.True(t, target.Tests[0].Until.IsZero(), "the original target should be left alone")
+}
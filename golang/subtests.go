@@ -0,0 +1,442 @@
+package golang
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// subtestMatch describes how a test name was matched to the place the quarantine is added.
+type subtestMatch int
+
+const (
+	// matchStatic is a test function, or a subtest with a static string name. The whole scope is quarantined.
+	matchStatic subtestMatch = iota
+	// matchTable is a table test entry with a static string name. The quarantine is guarded by comparing the table entry's name.
+	matchTable
+	// matchRuntime is a subtest whose name can only be known at runtime. The quarantine compares t.Name() when the test runs.
+	matchRuntime
+)
+
+// testScope is a place a quarantine statement can be added to: a test function, or the closure of a t.Run call.
+type testScope struct {
+	Pos          token.Pos      // Position of the test function or t.Run call, used for line numbers
+	Body         *ast.BlockStmt // Body to add the quarantine statement to
+	TestingParam string         // Name of the *testing.T parameter in the body, or the receiver of a suite method
+	Suite        bool           // True if the scope is a testify suite method, where the test is reached through s.T()
+	Match        subtestMatch   // How the test name was matched to this scope
+	Guard        ast.Expr       // For table matches: the expression holding the subtest name, e.g. tc.name
+	GuardValue   string         // For table matches: the name of the table entry to quarantine
+}
+
+// findSubtestScopes resolves the slash-separated subtest path, e.g. ["subtest_1"] of "TestFoo/subtest_1",
+// to the t.Run closures inside the test function that run it.
+// Static string names are matched exactly, table test entries are matched by their name field,
+// and anything else falls back to being matched at runtime.
+// Returns nil if the subtest could not be found.
+func findSubtestScopes(file *ast.File, funcDecl *ast.FuncDecl, subtestPath []string) []testScope {
+	resolver := newNameResolver(file, funcDecl)
+	scopes := resolveSubtests(resolver, funcDecl.Body, testingParamName(funcDecl.Type), subtestPath)
+
+	// Only the innermost scope can be guarded by a table lookup, anything above it has to be matched at runtime.
+	for i, scope := range scopes {
+		if scope.Match == matchRuntime {
+			scopes[i].Guard, scopes[i].GuardValue = nil, ""
+		}
+	}
+	return scopes
+}
+
+// resolveSubtests finds the t.Run closures in the body matching the first segment of the subtest path,
+// and recurses into them for the rest of the path.
+func resolveSubtests(
+	resolver *nameResolver,
+	body *ast.BlockStmt,
+	testingParam string,
+	subtestPath []string,
+) []testScope {
+	if len(subtestPath) == 0 || body == nil || testingParam == "" || testingParam == "_" {
+		return nil
+	}
+
+	var (
+		matched []testScope
+		runtime []testScope
+		stack   []ast.Node // Nodes enclosing the current node, used to find the range statements of table tests
+	)
+	ast.Inspect(body, func(node ast.Node) bool {
+		if node == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		call, isCall := node.(*ast.CallExpr)
+		var closure *ast.FuncLit
+		if isCall {
+			closure, isCall = runCall(call, testingParam)
+		}
+		if !isCall {
+			stack = append(stack, node)
+			return true
+		}
+
+		scope := testScope{
+			Pos:          call.Pos(),
+			Body:         closure.Body,
+			TestingParam: testingParamName(closure.Type),
+		}
+		switch match, value := resolver.matchSubtestName(call.Args[0], subtestPath[0], stack); match {
+		case nameMatched:
+			if len(subtestPath) == 1 {
+				matched = append(matched, scope)
+				return false
+			}
+			matched = append(
+				matched,
+				resolveSubtests(resolver, scope.Body, scope.TestingParam, subtestPath[1:])...,
+			)
+		case nameTableMatched:
+			scope.Match, scope.Guard, scope.GuardValue = matchTable, call.Args[0], value
+			if len(subtestPath) == 1 {
+				matched = append(matched, scope)
+				return false
+			}
+			// Nested subtests of a table entry can only be told apart at runtime.
+			matched = append(
+				matched,
+				asRuntime(resolveSubtests(resolver, scope.Body, scope.TestingParam, subtestPath[1:]))...,
+			)
+		case nameUnknown:
+			scope.Match = matchRuntime
+			if len(subtestPath) == 1 {
+				runtime = append(runtime, scope)
+				return false
+			}
+			runtime = append(
+				runtime,
+				asRuntime(resolveSubtests(resolver, scope.Body, scope.TestingParam, subtestPath[1:]))...,
+			)
+		case nameNotMatched:
+		}
+		// Nested t.Run calls are handled by recursing into the closure with its own testing parameter.
+		return false
+	})
+
+	if len(matched) > 0 {
+		return matched
+	}
+	return runtime
+}
+
+// asRuntime marks all scopes as needing to be matched at runtime.
+func asRuntime(scopes []testScope) []testScope {
+	for i := range scopes {
+		scopes[i].Match = matchRuntime
+	}
+	return scopes
+}
+
+// runCall checks if the call is a t.Run("name", func(t *testing.T) {...}) call on the given testing parameter,
+// and returns the subtest closure if it is.
+func runCall(call *ast.CallExpr, testingParam string) (*ast.FuncLit, bool) {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != "Run" || len(call.Args) != 2 {
+		return nil, false
+	}
+	receiver, ok := selector.X.(*ast.Ident)
+	if !ok || receiver.Name != testingParam {
+		return nil, false
+	}
+	closure, ok := call.Args[1].(*ast.FuncLit)
+	if !ok || closure.Body == nil {
+		return nil, false
+	}
+	return closure, true
+}
+
+// testingParamName returns the name of the first parameter of the function, usually "t".
+func testingParamName(funcType *ast.FuncType) string {
+	if funcType.Params == nil || len(funcType.Params.List) == 0 || len(funcType.Params.List[0].Names) == 0 {
+		return ""
+	}
+	return funcType.Params.List[0].Names[0].Name
+}
+
+// subtestName returns the name Go gives a subtest, e.g. "subtest 1" becomes "subtest_1".
+// This mirrors the rewriting the testing package does to subtest names.
+func subtestName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r):
+			b.WriteRune('_')
+		case !strconv.IsPrint(r):
+			quoted := strconv.QuoteRune(r)
+			b.WriteString(quoted[1 : len(quoted)-1])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// nameMatch describes whether an expression used as a subtest name matches the subtest we're looking for.
+type nameMatch int
+
+const (
+	nameNotMatched   nameMatch = iota // The name is known, and doesn't match
+	nameMatched                       // The name is a static string that matches
+	nameTableMatched                  // The name comes from a table entry that matches
+	nameUnknown                       // The name can't be determined statically
+)
+
+// nameResolver looks up the values of variables used to name subtests, e.g. the table in a table test.
+// It is deliberately simple: it only understands composite literals assigned to a variable in the test function or
+// at the package level of the file, and ranged over directly.
+type nameResolver struct {
+	values    map[string]ast.Expr      // Variable name -> value it was assigned
+	rangeVars map[string]rangeVariable // Variable name -> range statement it is declared by
+}
+
+// rangeVariable is a variable declared by a range statement.
+type rangeVariable struct {
+	Over ast.Expr // Expression being ranged over
+	Key  bool     // True if the variable is the key of the range, false if it's the value
+}
+
+// newNameResolver collects the variables declared in the file's package level and in the test function.
+func newNameResolver(file *ast.File, funcDecl *ast.FuncDecl) *nameResolver {
+	resolver := &nameResolver{
+		values:    make(map[string]ast.Expr),
+		rangeVars: make(map[string]rangeVariable),
+	}
+	for _, decl := range file.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.VAR {
+			resolver.addValueSpecs(genDecl)
+		}
+	}
+
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE || len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, lhs := range n.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					resolver.values[ident.Name] = n.Rhs[i]
+				}
+			}
+		case *ast.GenDecl:
+			if n.Tok == token.VAR {
+				resolver.addValueSpecs(n)
+			}
+		case *ast.RangeStmt:
+			if key, ok := n.Key.(*ast.Ident); ok {
+				resolver.rangeVars[key.Name] = rangeVariable{Over: n.X, Key: true}
+			}
+			if value, ok := n.Value.(*ast.Ident); ok {
+				resolver.rangeVars[value.Name] = rangeVariable{Over: n.X}
+			}
+		}
+		return true
+	})
+	return resolver
+}
+
+// addValueSpecs records the values of a var declaration.
+func (r *nameResolver) addValueSpecs(genDecl *ast.GenDecl) {
+	for _, spec := range genDecl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok || len(valueSpec.Names) != len(valueSpec.Values) {
+			continue
+		}
+		for i, name := range valueSpec.Names {
+			r.values[name.Name] = valueSpec.Values[i]
+		}
+	}
+}
+
+// matchSubtestName checks if the expression passed as the name of a t.Run call produces the wanted subtest name.
+// For table matches, the raw name of the matching table entry is returned.
+// enclosing are the nodes enclosing the t.Run call, innermost last.
+func (r *nameResolver) matchSubtestName(nameExpr ast.Expr, want string, enclosing []ast.Node) (nameMatch, string) {
+	if name, ok := stringLiteral(nameExpr); ok {
+		if subtestName(name) == want {
+			return nameMatched, ""
+		}
+		return nameNotMatched, ""
+	}
+
+	names, ok := r.tableNames(nameExpr, enclosing)
+	if !ok {
+		return nameUnknown, ""
+	}
+	for _, name := range names {
+		if subtestName(name) == want {
+			return nameTableMatched, name
+		}
+	}
+	return nameNotMatched, ""
+}
+
+// tableNames returns all the names a table test can give a subtest,
+// e.g. the name fields of `for _, tc := range tests { t.Run(tc.name, ...) }`,
+// or the keys of `for name, tc := range tests { t.Run(name, ...) }` when ranging over a map.
+// Returns false if any of the names can't be determined statically.
+func (r *nameResolver) tableNames(nameExpr ast.Expr, enclosing []ast.Node) ([]string, bool) {
+	var (
+		rangeVarName string
+		field        string
+	)
+	switch n := nameExpr.(type) {
+	case *ast.Ident:
+		rangeVarName = n.Name
+	case *ast.SelectorExpr:
+		ident, ok := n.X.(*ast.Ident)
+		if !ok {
+			return nil, false
+		}
+		rangeVarName, field = ident.Name, n.Sel.Name
+	default:
+		return nil, false
+	}
+
+	rangeVar, ok := r.rangeVariable(rangeVarName, enclosing)
+	if !ok {
+		return nil, false
+	}
+	table, ok := r.compositeLit(rangeVar.Over)
+	if !ok {
+		return nil, false
+	}
+	_, isMap := table.Type.(*ast.MapType)
+
+	names := make([]string, 0, len(table.Elts))
+	for _, elt := range table.Elts {
+		var entry ast.Expr = elt
+		if kv, ok := elt.(*ast.KeyValueExpr); ok && isMap {
+			if rangeVar.Key {
+				entry = kv.Key
+			} else {
+				entry = kv.Value
+			}
+		} else if rangeVar.Key {
+			return nil, false // Ranging over a slice gives an index as the key, not a name
+		}
+
+		if field != "" {
+			entry, ok = structField(table, entry, field)
+			if !ok {
+				return nil, false
+			}
+		}
+		name, ok := stringLiteral(entry)
+		if !ok {
+			return nil, false
+		}
+		names = append(names, name)
+	}
+	return names, true
+}
+
+// rangeVariable finds the range statement declaring the variable, preferring the innermost enclosing one.
+func (r *nameResolver) rangeVariable(name string, enclosing []ast.Node) (rangeVariable, bool) {
+	for i := len(enclosing) - 1; i >= 0; i-- {
+		rangeStmt, ok := enclosing[i].(*ast.RangeStmt)
+		if !ok {
+			continue
+		}
+		if key, ok := rangeStmt.Key.(*ast.Ident); ok && key.Name == name {
+			return rangeVariable{Over: rangeStmt.X, Key: true}, true
+		}
+		if value, ok := rangeStmt.Value.(*ast.Ident); ok && value.Name == name {
+			return rangeVariable{Over: rangeStmt.X}, true
+		}
+	}
+	rangeVar, ok := r.rangeVars[name]
+	return rangeVar, ok
+}
+
+// compositeLit resolves an expression to the composite literal it refers to.
+func (r *nameResolver) compositeLit(expr ast.Expr) (*ast.CompositeLit, bool) {
+	for range 10 { // Guard against cycles of variables referring to each other
+		switch e := expr.(type) {
+		case *ast.CompositeLit:
+			return e, true
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			value, ok := r.values[e.Name]
+			if !ok {
+				return nil, false
+			}
+			expr = value
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// structField returns the value of the named field in a table entry.
+// Keyed fields are always supported, positional fields only when the table's element type is an inline struct.
+func structField(table *ast.CompositeLit, entry ast.Expr, field string) (ast.Expr, bool) {
+	if unary, ok := entry.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		entry = unary.X
+	}
+	entryLit, ok := entry.(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+
+	if len(entryLit.Elts) == 0 {
+		return nil, false
+	}
+	if _, keyed := entryLit.Elts[0].(*ast.KeyValueExpr); keyed {
+		for _, elt := range entryLit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			if key, ok := kv.Key.(*ast.Ident); ok && key.Name == field {
+				return kv.Value, true
+			}
+		}
+		return nil, false // The field is left as its zero value
+	}
+
+	var elementType ast.Expr
+	switch t := table.Type.(type) {
+	case *ast.ArrayType:
+		elementType = t.Elt
+	case *ast.MapType:
+		elementType = t.Value
+	}
+	if star, ok := elementType.(*ast.StarExpr); ok {
+		elementType = star.X
+	}
+	structType, ok := elementType.(*ast.StructType)
+	if !ok {
+		return nil, false
+	}
+	index := 0
+	for _, structField := range structType.Fields.List {
+		names := structField.Names
+		if len(names) == 0 { // Embedded field
+			index++
+			continue
+		}
+		for _, name := range names {
+			if name.Name == field {
+				if index >= len(entryLit.Elts) {
+					return nil, false
+				}
+				return entryLit.Elts[index], true
+			}
+			index++
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,315 @@
+package golang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubtestName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "subtest 1", expected: "subtest_1"},
+		{name: "sub-subtest\t2", expected: "sub-subtest_2"},
+		{name: "no_spaces", expected: "no_spaces"},
+		{name: "bell\a", expected: `bell\a`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, subtestName(test.name))
+		})
+	}
+}
+
+func TestSkipTests_Subtests(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		source           string
+		target           QuarantineTarget
+		expectedSource   string
+		expectedNotFound []string
+	}{
+		{
+			name: "static name",
+			source: `package example
+
+import "testing"
+
+func TestA(t *testing.T) {
+	t.Run("subtest 1", func(t *testing.T) {
+		t.Parallel()
+	})
+	t.Run("subtest 2", func(t *testing.T) {
+		t.Parallel()
+	})
+}
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA/subtest_2", JiraTicket: "JIRA-A"}}},
+			expectedSource: `package example
+
+import "testing"
+import "github.com/smartcontractkit/branch-out/quarantine"
+
+func TestA(t *testing.T) {
+	t.Run("subtest 1", func(t *testing.T) {
+		t.Parallel()
+	})
+	t.Run("subtest 2", func(t *testing.T) {
+		quarantine.Flaky(t, "JIRA-A")
+		t.Parallel()
+	})
+}
+`,
+		},
+		{
+			name: "nested static names",
+			source: `package example
+
+import "testing"
+
+func TestA(x *testing.T) {
+	x.Run("parent", func(y *testing.T) {
+		y.Run("child", func(z *testing.T) {
+			z.Parallel()
+		})
+	})
+}
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA/parent/child", JiraTicket: "JIRA-A"}}},
+			expectedSource: `package example
+
+import "testing"
+import "github.com/smartcontractkit/branch-out/quarantine"
+
+func TestA(x *testing.T) {
+	x.Run("parent", func(y *testing.T) {
+		y.Run("child", func(z *testing.T) {
+			quarantine.Flaky(z, "JIRA-A")
+			z.Parallel()
+		})
+	})
+}
+`,
+		},
+		{
+			name: "table entries",
+			source: `package example
+
+import "testing"
+
+var tests = []struct {
+	name string
+	want int
+}{
+	{"first", 1},
+	{"second entry", 2},
+}
+
+func TestA(t *testing.T) {
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+		})
+	}
+}
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA/second_entry", JiraTicket: "JIRA-A"}}},
+			expectedSource: `package example
+
+import "testing"
+import "github.com/smartcontractkit/branch-out/quarantine"
+
+var tests = []struct {
+	name string
+	want int
+}{
+	{"first", 1},
+	{"second entry", 2},
+}
+
+func TestA(t *testing.T) {
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.name == "second entry" {
+				quarantine.Flaky(t, "JIRA-A")
+			}
+			t.Parallel()
+		})
+	}
+}
+`,
+		},
+		{
+			name: "map keys",
+			source: `package example
+
+import "testing"
+
+func TestA(t *testing.T) {
+	tests := map[string]int{
+		"first":  1,
+		"second": 2,
+	}
+	for name := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+		})
+	}
+}
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA/first", JiraTicket: "JIRA-A"}}},
+			expectedSource: `package example
+
+import "testing"
+import "github.com/smartcontractkit/branch-out/quarantine"
+
+func TestA(t *testing.T) {
+	tests := map[string]int{
+		"first":  1,
+		"second": 2,
+	}
+	for name := range tests {
+		t.Run(name, func(t *testing.T) {
+			if name == "first" {
+				quarantine.Flaky(t, "JIRA-A")
+			}
+			t.Parallel()
+		})
+	}
+}
+`,
+		},
+		{
+			name: "dynamic names",
+			source: `package example
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestA(t *testing.T) {
+	for i := range 3 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+		})
+	}
+}
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA/1", JiraTicket: "JIRA-A"}}},
+			expectedSource: `package example
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {
+	for i := range 3 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			quarantine.Flaky(t, "JIRA-A", quarantine.IfNamed("TestA/1"))
+			t.Parallel()
+		})
+	}
+}
+`,
+		},
+		{
+			name: "static child of a table entry",
+			source: `package example
+
+import "testing"
+
+func TestA(t *testing.T) {
+	for _, name := range []string{"a", "b"} {
+		t.Run(name, func(t *testing.T) {
+			t.Run("child", func(t *testing.T) {
+				t.Parallel()
+			})
+		})
+	}
+}
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA/b/child", JiraTicket: "JIRA-A"}}},
+			expectedSource: `package example
+
+import "testing"
+import "github.com/smartcontractkit/branch-out/quarantine"
+
+func TestA(t *testing.T) {
+	for _, name := range []string{"a", "b"} {
+		t.Run(name, func(t *testing.T) {
+			t.Run("child", func(t *testing.T) {
+				quarantine.Flaky(t, "JIRA-A", quarantine.IfNamed("TestA/b/child"))
+				t.Parallel()
+			})
+		})
+	}
+}
+`,
+		},
+		{
+			name: "subtest not found",
+			source: `package example
+
+import "testing"
+
+func TestA(t *testing.T) {
+	t.Run("subtest 1", func(t *testing.T) {
+		t.Parallel()
+	})
+}
+`,
+			target:           QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA/subtest_2", JiraTicket: "JIRA-A"}}},
+			expectedNotFound: []string{"TestA/subtest_2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testFile, err := parseTestFile("example_test.go", []byte(test.source))
+			require.NoError(t, err)
+
+			foundTests := testsInFile(testFile.Node, test.target, nil)
+			if len(test.expectedNotFound) > 0 {
+				assert.Empty(t, foundTests, "subtests should not have been found")
+				return
+			}
+
+			modifiedSource, quarantinedTests, err := skipTests(testFile, foundTests, nil, FlakyGenerator())
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSource, modifiedSource)
+			require.Len(t, quarantinedTests, len(test.target.Tests))
+			for _, quarantinedTest := range quarantinedTests {
+				assert.NotZero(t, quarantinedTest.ModifiedLine, "modified line of %s not found", quarantinedTest.Name)
+			}
+
+			// Un-quarantining should get us back to exactly where we started
+			testFile, err = parseTestFile("example_test.go", []byte(modifiedSource))
+			require.NoError(t, err)
+			unquarantinedSource, unquarantinedTests, err := unskipTests(
+				testFile,
+				testsInFile(testFile.Node, test.target, nil),
+				nil,
+				builtinGenerators(),
+			)
+			require.NoError(t, err)
+			require.Len(t, unquarantinedTests, len(test.target.Tests))
+			assert.Equal(t, test.source, unquarantinedSource)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package golang
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// testifySuiteImportPath is the import path of testify's suite package.
+const testifySuiteImportPath = "github.com/stretchr/testify/suite"
+
+// suiteRunners maps the names of test functions that run testify suites to the name of the suite type they run,
+// e.g. TestMySuite -> MySuite for `func TestMySuite(t *testing.T) { suite.Run(t, new(MySuite)) }`.
+// Go reports the suite's test methods as subtests of the runner, e.g. "TestMySuite/TestFoo".
+type suiteRunners map[string]string
+
+// findSuiteRunners looks through all the files of a package for test functions that run testify suites.
+// The runner and the suite's methods are often in different files, so this needs to be done for the whole package.
+func findSuiteRunners(files ...*ast.File) suiteRunners {
+	runners := make(suiteRunners)
+	for _, file := range files {
+		suiteImportName := importLocalName(file, testifySuiteImportPath)
+		if suiteImportName == "" || suiteImportName == "_" {
+			continue
+		}
+
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || !isTestFunction(file, funcDecl) || funcDecl.Body == nil {
+				continue
+			}
+			if suiteType := runSuiteType(file, funcDecl, suiteImportName); suiteType != "" {
+				runners[funcDecl.Name.Name] = suiteType
+			}
+		}
+	}
+	return runners
+}
+
+// runSuiteType returns the name of the suite type run by a suite.Run(t, ...) call in the test function.
+// Returns the empty string if the test function doesn't run a suite defined in the same package.
+func runSuiteType(file *ast.File, funcDecl *ast.FuncDecl, suiteImportName string) string {
+	var (
+		resolver  = newNameResolver(file, funcDecl)
+		suiteType string
+	)
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || suiteType != "" || len(call.Args) != 2 {
+			return suiteType == ""
+		}
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || selector.Sel.Name != "Run" {
+			return true
+		}
+		if ident, ok := selector.X.(*ast.Ident); !ok || ident.Name != suiteImportName {
+			return true
+		}
+		suiteType = suiteTypeName(resolver, call.Args[1])
+		return false
+	})
+	return suiteType
+}
+
+// suiteTypeName resolves the suite passed to suite.Run to the name of its type,
+// e.g. new(MySuite), &MySuite{} or a variable holding one of those.
+func suiteTypeName(resolver *nameResolver, expr ast.Expr) string {
+	for range 10 { // Guard against cycles of variables referring to each other
+		switch e := expr.(type) {
+		case *ast.Ident:
+			value, ok := resolver.values[e.Name]
+			if !ok {
+				return ""
+			}
+			expr = value
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.UnaryExpr:
+			if e.Op != token.AND {
+				return ""
+			}
+			expr = e.X
+		case *ast.CompositeLit:
+			return typeName(e.Type)
+		case *ast.CallExpr:
+			if fun, ok := e.Fun.(*ast.Ident); !ok || fun.Name != "new" || len(e.Args) != 1 {
+				return ""
+			}
+			return typeName(e.Args[0])
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// typeName returns the name of a type declared in the same package, e.g. MySuite for *MySuite or MySuite[T].
+// Returns the empty string for types from other packages.
+func typeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return typeName(e.X)
+	case *ast.IndexExpr:
+		return typeName(e.X)
+	case *ast.IndexListExpr:
+		return typeName(e.X)
+	default:
+		return ""
+	}
+}
+
+// suiteMethodScopes returns where to quarantine a testify suite test method, e.g. "TestMySuite/TestFoo"
+// for `func (s *MySuite) TestFoo()`, if the method declaration matches the test name.
+func suiteMethodScopes(funcDecl *ast.FuncDecl, testName string, suites suiteRunners) []testScope {
+	runner, method, isSubtest := strings.Cut(testName, "/")
+	if !isSubtest || strings.Contains(method, "/") || funcDecl.Name.Name != method {
+		return nil
+	}
+	suiteType, ok := suites[runner]
+	if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) != 1 {
+		return nil
+	}
+	receiver := funcDecl.Recv.List[0]
+	if typeName(receiver.Type) != suiteType || len(receiver.Names) != 1 {
+		return nil
+	}
+	if receiverName := receiver.Names[0].Name; receiverName != "_" && funcDecl.Type.Params.NumFields() == 0 {
+		return []testScope{{
+			Pos:          funcDecl.Pos(),
+			Body:         funcDecl.Body,
+			TestingParam: receiverName,
+			Suite:        true,
+		}}
+	}
+	return nil
+}
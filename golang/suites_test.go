@@ -0,0 +1,156 @@
+package golang
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindSuiteRunners(t *testing.T) {
+	t.Parallel()
+
+	source := `package example
+
+import (
+	"testing"
+
+	testifysuite "github.com/stretchr/testify/suite"
+)
+
+func TestNew(t *testing.T) {
+	testifysuite.Run(t, new(NewSuite))
+}
+
+func TestAddress(t *testing.T) {
+	testifysuite.Run(t, &AddressSuite{name: "address"})
+}
+
+func TestVariable(t *testing.T) {
+	s := &VariableSuite{}
+	testifysuite.Run(t, s)
+}
+
+func TestOtherPackage(t *testing.T) {
+	testifysuite.Run(t, new(other.Suite))
+}
+
+func TestNotASuite(t *testing.T) {
+	t.Run("subtest", func(t *testing.T) {})
+}
+`
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, "example_test.go", source, parser.ParseComments)
+	require.NoError(t, err)
+
+	assert.Equal(t, suiteRunners{
+		"TestNew":      "NewSuite",
+		"TestAddress":  "AddressSuite",
+		"TestVariable": "VariableSuite",
+	}, findSuiteRunners(node))
+}
+
+func TestSkipTests_SuiteMethods(t *testing.T) {
+	t.Parallel()
+
+	// The runner and the suite's methods live in different files
+	runnerSource := `package example
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMySuite(t *testing.T) {
+	suite.Run(t, new(MySuite))
+}
+`
+	methodsSource := `package example
+
+import (
+	"github.com/stretchr/testify/suite"
+)
+
+type MySuite struct {
+	suite.Suite
+}
+
+func (s *MySuite) TestFoo() {
+	s.Equal(1, 1)
+}
+
+func (s *MySuite) TestBar() {
+	s.T().Skip("already skipped")
+}
+
+func (s *OtherSuite) TestFoo() {
+	s.Equal(1, 1)
+}
+`
+	expectedSource := `package example
+
+import (
+	"github.com/smartcontractkit/branch-out/quarantine"
+	"github.com/stretchr/testify/suite"
+)
+
+type MySuite struct {
+	suite.Suite
+}
+
+func (s *MySuite) TestFoo() {
+	quarantine.FlakySuite(s, "JIRA-FOO")
+	s.Equal(1, 1)
+}
+
+func (s *MySuite) TestBar() {
+	s.T().Skip("already skipped")
+}
+
+func (s *OtherSuite) TestFoo() {
+	s.Equal(1, 1)
+}
+`
+
+	runnerFile, err := parseTestFile("runner_test.go", []byte(runnerSource))
+	require.NoError(t, err)
+	testFile, err := parseTestFile("methods_test.go", []byte(methodsSource))
+	require.NoError(t, err)
+	suites := findSuiteRunners(runnerFile.Node, testFile.Node)
+
+	target := QuarantineTarget{Tests: []TestToQuarantine{
+		{Name: "TestMySuite/TestFoo", JiraTicket: "JIRA-FOO"},
+		{Name: "TestMySuite/TestBar", JiraTicket: "JIRA-BAR"},
+	}}
+	assert.Empty(t, testsInFile(runnerFile.Node, target, suites), "runner file should not contain any suite methods")
+
+	modifiedSource, quarantinedTests, err := skipTests(
+		testFile,
+		testsInFile(testFile.Node, target, suites),
+		suites,
+		FlakyGenerator(),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, expectedSource, modifiedSource)
+	require.Len(t, quarantinedTests, 2)
+	assert.False(t, quarantinedTests[0].AlreadyQuarantined, "TestFoo should be newly quarantined")
+	assert.Equal(t, 12, quarantinedTests[0].ModifiedLine)
+	assert.True(t, quarantinedTests[1].AlreadyQuarantined, "TestBar is already skipped with s.T().Skip()")
+
+	// Un-quarantining should remove the suite quarantine again
+	testFile, err = parseTestFile("methods_test.go", []byte(modifiedSource))
+	require.NoError(t, err)
+	unquarantinedSource, unquarantinedTests, err := unskipTests(
+		testFile,
+		testsInFile(testFile.Node, target, suites),
+		suites,
+		builtinGenerators(),
+	)
+	require.NoError(t, err)
+	require.Len(t, unquarantinedTests, 1)
+	assert.Equal(t, "JIRA-FOO", unquarantinedTests[0].JiraTicket)
+	assert.Equal(t, methodsSource, unquarantinedSource)
+}
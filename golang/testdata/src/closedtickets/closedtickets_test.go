@@ -0,0 +1,19 @@
+package closedtickets
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestOpenTicket(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-1")
+}
+
+func TestClosedTicket(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-2") // want `ticket JIRA-2 of quarantine.Flaky is Done, un-quarantine the test`
+}
+
+func TestUnknownTicket(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-3")
+}
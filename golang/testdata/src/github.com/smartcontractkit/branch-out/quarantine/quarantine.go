@@ -0,0 +1,14 @@
+// Package quarantine is a stub of the quarantine package for the analyzer tests.
+package quarantine
+
+import "testing"
+
+type Option func()
+
+func Flaky(tb testing.TB, ticket string, opts ...Option) {}
+
+func IfNamed(name string) Option { return nil }
+
+func Variant(variant string) Option { return nil }
+
+func Until(date string) Option { return nil }
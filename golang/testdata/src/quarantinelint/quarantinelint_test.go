@@ -0,0 +1,82 @@
+package quarantinelint
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+const ticket = "JIRA-1"
+
+func TestQuarantined(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-1")
+	t.Parallel()
+}
+
+func TestAfterParallel(t *testing.T) {
+	t.Parallel()
+	quarantine.Flaky(t, "JIRA-2", quarantine.Until("2026-12-01"))
+}
+
+func TestEmptyTicket(t *testing.T) {
+	quarantine.Flaky(t, "") // want `quarantine.Flaky is called with an empty ticket`
+}
+
+func TestMalformedTicket(t *testing.T) {
+	quarantine.Flaky(t, "jira 3") // want `ticket "jira 3" of quarantine.Flaky doesn't match`
+}
+
+func TestNonLiteralTicket(t *testing.T) {
+	quarantine.Flaky(t, ticket) // want `ticket of quarantine.Flaky should be a string literal`
+}
+
+func TestDuplicate(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-5")
+	quarantine.Flaky(t, "JIRA-6") // want `quarantine.Flaky quarantines a test that is already quarantined on line 34`
+}
+
+func TestVariants(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-7", quarantine.Variant("linux"))
+	quarantine.Flaky(t, "JIRA-8", quarantine.Variant("windows"))
+}
+
+func TestNotAtTop(t *testing.T) {
+	t.Log("setting up")
+	quarantine.Flaky(t, "JIRA-9") // want `quarantine.Flaky should be called at the top of the test`
+}
+
+func TestNested(t *testing.T) {
+	for range 2 {
+		quarantine.Flaky(t, "JIRA-10") // want `quarantine.Flaky should be called at the top of the test`
+	}
+}
+
+func TestSubtests(t *testing.T) {
+	t.Run("subtest", func(t *testing.T) {
+		quarantine.Flaky(t, "JIRA-11")
+	})
+
+	tests := []struct{ name string }{{name: "a"}, {name: "b"}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.name == "a" {
+				quarantine.Flaky(t, "JIRA-12")
+			}
+			if tc.name == "b" {
+				quarantine.Flaky(t, "JIRA-13")
+			}
+			if tc.name == "b" {
+				quarantine.Flaky(t, "JIRA-14") // want `already quarantined on line 66`
+			}
+		})
+	}
+}
+
+func TestRuntimeNames(t *testing.T) {
+	for _, name := range []string{"a", "b"} {
+		t.Run(name, func(t *testing.T) {
+			quarantine.Flaky(t, "JIRA-15", quarantine.IfNamed("TestRuntimeNames/a"))
+			quarantine.Flaky(t, "JIRA-16", quarantine.IfNamed("TestRuntimeNames/b"))
+		})
+	}
+}
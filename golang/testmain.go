@@ -0,0 +1,404 @@
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// PackageTestName is the name of the test that stands for a whole package.
+// Test reporters like gotestsum report package-level failures, e.g. a panic in TestMain, as a failure of this test.
+// See WithPackageQuarantine.
+const PackageTestName = "TestMain"
+
+// testMainFileNames are the names tried, in order, for the test file a TestMain is added to when none of the package's
+// test files can hold one, see testMainFile.
+var testMainFileNames = []string{"main_test.go", "testmain_test.go"}
+
+// ErrPackageQuarantineUnsupported is returned when the generator can't generate code that quarantines a whole package.
+var ErrPackageQuarantineUnsupported = errors.New("generator can't quarantine whole packages")
+
+// WithPackageQuarantine enables quarantining whole packages when PackageTestName is one of a package's target tests,
+// by adding a guard to the top of the package's TestMain that returns before any of its tests run.
+// If the package doesn't have a TestMain, one is added to one of its test files.
+// Off by default, as a TestMain test is otherwise reported as not found.
+// Un-quarantining always removes the guard, along with the TestMain if it was added.
+func WithPackageQuarantine(enabled bool) QuarantineOption {
+	return func(options *quarantineOptions) {
+		options.packageQuarantine = enabled
+	}
+}
+
+// packageTest returns the target's test standing for the whole package, if there is one.
+func (q QuarantineTarget) packageTest() (TestToQuarantine, bool) {
+	for _, test := range q.Tests {
+		if test.Name == PackageTestName {
+			return test, true
+		}
+	}
+	return TestToQuarantine{}, false
+}
+
+// packageTestProcessor quarantines or un-quarantines a whole package in a test file.
+// testMain is the file's TestMain function, nil if the package doesn't have one.
+// It returns the modified source, and false if there was nothing to do.
+type packageTestProcessor func(testFile parsedTestFile, testMain *ast.FuncDecl) (string, QuarantinedTest, bool, error)
+
+// processPackageTest runs the processor on the test file with the package's TestMain, or the one a TestMain can be
+// added to if there is none, and adds the modified file to the results. If none of the test files can hold a
+// TestMain, the processor runs on a new file, see newTestMainFile. Files left with nothing but their package clause,
+// like those new files once un-quarantined, are deleted.
+// Files that were already modified for other tests are processed on top of those modifications.
+// Returns false if the processor had nothing to do.
+func processPackageTest(
+	repoPath string,
+	pkg PackageInfo,
+	testFiles []parsedTestFile,
+	results *QuarantinePackageResults,
+	processor packageTestProcessor,
+) (bool, error) {
+	if len(testFiles) == 0 {
+		return false, nil
+	}
+	fileIndex, _ := findTestMain(testFiles)
+	if fileIndex < 0 {
+		fileIndex = testMainFile(testFiles)
+	}
+
+	var (
+		testFile       parsedTestFile
+		originalSource string
+		created        = fileIndex < 0
+		err            error
+	)
+	if created {
+		if testFile, err = newTestMainFile(testFiles); err != nil {
+			return false, err
+		}
+	} else {
+		testFile = testFiles[fileIndex]
+		originalSource = string(testFile.Src)
+	}
+	resultIndex := slices.IndexFunc(results.Successes, func(file QuarantinedFile) bool {
+		return file.FileAbs == testFile.Path
+	})
+	if resultIndex >= 0 {
+		modifiedFile, err := parseTestFile(testFile.Path, []byte(results.Successes[resultIndex].ModifiedSourceCode))
+		if err != nil {
+			return false, fmt.Errorf("failed to parse modified source of %s: %w", testFile.Path, err)
+		}
+		testFile = modifiedFile
+	}
+	_, testMain := findTestMain([]parsedTestFile{testFile})
+
+	modifiedSource, test, ok, err := processor(testFile, testMain)
+	if err != nil || !ok {
+		return false, err
+	}
+	deleted, err := isEmptyFile(testFile.Path, modifiedSource)
+	if err != nil {
+		return false, err
+	}
+	if deleted {
+		modifiedSource = ""
+	}
+
+	if resultIndex >= 0 {
+		results.Successes[resultIndex].ModifiedSourceCode = modifiedSource
+		results.Successes[resultIndex].Tests = append(results.Successes[resultIndex].Tests, test)
+		results.Successes[resultIndex].Deleted = deleted
+		return true, nil
+	}
+	relativeFilePath, err := relativePath(repoPath, testFile.Path)
+	if err != nil {
+		return false, err
+	}
+	results.Successes = append(results.Successes, QuarantinedFile{
+		Package:            pkg.ImportPath,
+		File:               relativeFilePath,
+		FileAbs:            testFile.Path,
+		Tests:              []QuarantinedTest{test},
+		OriginalSourceCode: originalSource,
+		ModifiedSourceCode: modifiedSource,
+		Created:            created,
+		Deleted:            deleted,
+	})
+	return true, nil
+}
+
+// isEmptyFile checks if the source only has a package clause, without any declaration or comment.
+func isEmptyFile(path, source string) (bool, error) {
+	file, err := parseTestFile(path, []byte(source))
+	if err != nil {
+		return false, fmt.Errorf("failed to parse modified source of %s: %w", path, err)
+	}
+	return len(file.Node.Decls) == 0 && len(file.Node.Comments) == 0, nil
+}
+
+// findTestMain returns the index of the test file declaring TestMain, and the function.
+// Returns -1 if none of the files declare it.
+func findTestMain(testFiles []parsedTestFile) (int, *ast.FuncDecl) {
+	for index, testFile := range testFiles {
+		for _, decl := range testFile.Node.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if ok && isTestMain(testFile.Node, funcDecl) {
+				return index, funcDecl
+			}
+		}
+	}
+	return -1, nil
+}
+
+// isTestMain checks if a function declaration is TestMain, taking a single *testing.M parameter.
+func isTestMain(node *ast.File, funcDecl *ast.FuncDecl) bool {
+	if funcDecl.Name == nil || funcDecl.Name.Name != PackageTestName || funcDecl.Recv != nil || funcDecl.Body == nil {
+		return false
+	}
+	params := funcDecl.Type.Params
+	if params == nil || len(params.List) != 1 || len(params.List[0].Names) > 1 {
+		return false
+	}
+	return testingTypeName(node, params.List[0].Type) == "*M"
+}
+
+// testMainFile returns the index of the first test file where a TestMain can be added: one that imports the testing
+// package and is built in every configuration, so that the TestMain isn't left out of some of them.
+// Returns -1 if none of the files qualify.
+func testMainFile(testFiles []parsedTestFile) int {
+	return slices.IndexFunc(testFiles, func(testFile parsedTestFile) bool {
+		name := importLocalName(testFile.Node, "testing")
+		return name != "" && name != "_" && !isConstrained(testFile)
+	})
+}
+
+// newTestMainFile returns a new file next to the test files, only importing the testing package, for a TestMain to be
+// added to. It's in the package of the first test file, and named after the first of testMainFileNames not taken yet.
+func newTestMainFile(testFiles []parsedTestFile) (parsedTestFile, error) {
+	dir := filepath.Dir(testFiles[0].Path)
+	for _, name := range testMainFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		src := fmt.Sprintf("package %s\n\nimport (\n\t\"testing\"\n)\n", testFiles[0].Node.Name.Name)
+		return parseTestFile(path, []byte(src))
+	}
+	return parsedTestFile{}, fmt.Errorf("can't add a TestMain to %s, all of %v are taken", dir, testMainFileNames)
+}
+
+// skipPackage quarantines every test of the package by adding a guard generated by the generator to the top of
+// TestMain, which returns before m.Run() runs the tests. If testMain is nil, a TestMain running the tests after the
+// guard is added to the end of the file.
+// Packages that are already quarantined are left alone, other than updating the ticket if it changed.
+func skipPackage(
+	testFile parsedTestFile,
+	testMain *ast.FuncDecl,
+	test TestToQuarantine,
+	generator Generator,
+) (string, QuarantinedTest, error) {
+	var (
+		tokenFile            = testFile.tokenFile()
+		importName, imported = generatorImport(testFile.Node, generator)
+		quarantinedTest      = QuarantinedTest{Name: PackageTestName, JiraTicket: test.JiraTicket}
+		site                 = QuarantineSite{
+			ImportName:   importName,
+			TestingParam: "m",
+			TestName:     PackageTestName,
+			Ticket:       test.JiraTicket,
+			Until:        test.Until,
+			Variant:      test.Variant,
+			Package:      true,
+		}
+	)
+	guard := generator.Generate(site)
+	if guard == nil {
+		return "", quarantinedTest, fmt.Errorf("%w: %T", ErrPackageQuarantineUnsupported, generator)
+	}
+
+	var edits []textEdit
+	if testMain != nil {
+		quarantinedTest.OriginalLine = testFile.Fset.Position(testMain.Pos()).Line
+		site.TestingParam = testingParamName(testMain.Type)
+		for _, stmt := range testMain.Body.List {
+			if !imported {
+				break
+			}
+			ticket, ticketExpr, ok := generator.Match(stmt, site)
+			if !ok {
+				continue
+			}
+			if ticket == test.JiraTicket {
+				quarantinedTest.AlreadyQuarantined = true
+				quarantinedTest.ModifiedLine = quarantinedTest.OriginalLine
+				return string(testFile.Src), quarantinedTest, nil
+			}
+			existing := existingQuarantineStmt{Stmt: stmt, Ticket: ticket, TicketExpr: ticketExpr}
+			edit, err := updateTicketEdit(testFile.Src, tokenFile, generator, site, existing)
+			if err != nil {
+				return "", quarantinedTest, err
+			}
+			edits = append(edits, edit)
+			quarantinedTest.PreviousJiraTicket = ticket
+			break
+		}
+		if len(edits) == 0 {
+			bodyEdits, err := insertStmtsEdits(testFile.Src, tokenFile, testMain.Body, []ast.Stmt{guard})
+			if err != nil {
+				return "", quarantinedTest, err
+			}
+			edits = append(edits, bodyEdits...)
+		}
+	} else {
+		edit, err := addTestMainEdit(testFile, guard)
+		if err != nil {
+			return "", quarantinedTest, err
+		}
+		edits = append(edits, edit)
+	}
+
+	// Ensure the generator's package is imported for the guard
+	if !imported {
+		edits = append(edits, addImportEdit(testFile.Src, tokenFile, testFile.Node, generator.ImportPath()))
+	}
+
+	modifiedSource, err := applyEdits(testFile.Src, edits)
+	if err != nil {
+		return "", quarantinedTest, fmt.Errorf("failed to edit source: %w", err)
+	}
+	if quarantinedTest.ModifiedLine, err = testMainLine(testFile.Path, modifiedSource); err != nil {
+		return "", quarantinedTest, err
+	}
+	return modifiedSource, quarantinedTest, nil
+}
+
+// addTestMainEdit adds a TestMain to the end of the file that runs the tests after the guard.
+func addTestMainEdit(testFile parsedTestFile, guard ast.Stmt) (textEdit, error) {
+	guardText, err := formatStmt(guard, "\t")
+	if err != nil {
+		return textEdit{}, err
+	}
+	testingType := importLocalName(testFile.Node, "testing") + ".M"
+	if testingType == "..M" { // Dot import
+		testingType = "M"
+	}
+
+	text := fmt.Sprintf("\nfunc %s(m *%s) {\n%s\tm.Run()\n}\n", PackageTestName, testingType, guardText)
+	if len(testFile.Src) > 0 && testFile.Src[len(testFile.Src)-1] != '\n' {
+		text = "\n" + text
+	}
+	return textEdit{Start: len(testFile.Src), End: len(testFile.Src), Text: text}, nil
+}
+
+// unskipPackage removes the guard generated by any of the generators from TestMain.
+// If all that is left of TestMain is running the tests, as in the ones skipPackage adds, TestMain is removed entirely.
+// Returns false if the package isn't quarantined.
+func unskipPackage(
+	testFile parsedTestFile,
+	testMain *ast.FuncDecl,
+	generators []Generator,
+) (string, QuarantinedTest, bool, error) {
+	if testMain == nil {
+		return "", QuarantinedTest{}, false, nil
+	}
+
+	var (
+		tokenFile         = testFile.tokenFile()
+		testingParam      = testingParamName(testMain.Type)
+		unquarantinedTest = QuarantinedTest{Name: PackageTestName, OriginalLine: testFile.Fset.Position(testMain.Pos()).Line}
+		keptStmts         = make([]ast.Stmt, 0, len(testMain.Body.List))
+		edits             []textEdit
+		usedImports       []string
+	)
+	for _, stmt := range testMain.Body.List {
+		removed := false
+		for _, generator := range generators {
+			importName, imported := generatorImport(testFile.Node, generator)
+			if !imported {
+				continue
+			}
+			site := QuarantineSite{ImportName: importName, TestingParam: testingParam, TestName: PackageTestName, Package: true}
+			ticket, _, ok := generator.Match(stmt, site)
+			if !ok {
+				continue
+			}
+			removed = true
+			unquarantinedTest.JiraTicket = ticket
+			edits = append(edits, removeStmtEdit(testFile.Src, tokenFile, stmt))
+			if importPath := generator.ImportPath(); importPath != "" {
+				usedImports = appendUnique(usedImports, []string{importPath})
+			}
+			break
+		}
+		if !removed {
+			keptStmts = append(keptStmts, stmt)
+		}
+	}
+	if len(edits) == 0 {
+		return "", QuarantinedTest{}, false, nil
+	}
+	testMain.Body.List = keptStmts
+
+	// Remove a TestMain that only runs the tests, along with the blank line separating it from the rest of the file
+	if len(keptStmts) == 1 && isRunCall(keptStmts[0], testingParam) && testMain.Doc == nil {
+		var (
+			start = lineStart(testFile.Src, tokenFile.Offset(testMain.Pos()))
+			end   = lineEnd(testFile.Src, tokenFile.Offset(testMain.End()))
+		)
+		if start >= 2 && testFile.Src[start-2] == '\n' {
+			start--
+		}
+		edits = []textEdit{{Start: start, End: end}}
+		testFile.Node.Decls = slices.DeleteFunc(testFile.Node.Decls, func(decl ast.Decl) bool {
+			return decl == testMain
+		})
+		// TestMain may have been the only use of the testing package, e.g. in a file added by newTestMainFile
+		usedImports = appendUnique(usedImports, []string{"testing"})
+	}
+
+	modifiedSource, err := applyEdits(testFile.Src, edits)
+	if err != nil {
+		return "", unquarantinedTest, false, fmt.Errorf("failed to edit source: %w", err)
+	}
+	modifiedSource, err = removeUnusedImports(testFile.Path, testFile.Node, modifiedSource, usedImports)
+	if err != nil {
+		return "", unquarantinedTest, false, err
+	}
+	if unquarantinedTest.ModifiedLine, err = testMainLine(testFile.Path, modifiedSource); err != nil {
+		return "", unquarantinedTest, false, err
+	}
+	return modifiedSource, unquarantinedTest, true, nil
+}
+
+// isRunCall checks if the statement is a m.Run() call on the *testing.M parameter.
+func isRunCall(stmt ast.Stmt, testingParam string) bool {
+	exprStmt, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return false
+	}
+	call, ok := exprStmt.X.(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != "Run" {
+		return false
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	return ok && ident.Name == testingParam
+}
+
+// testMainLine parses the modified source to find the line of TestMain after modification.
+// Returns 0 if it was removed.
+func testMainLine(path, modifiedSource string) (int, error) {
+	modifiedFile, err := parseTestFile(path, []byte(modifiedSource))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse modified source: %w", err)
+	}
+	if _, testMain := findTestMain([]parsedTestFile{modifiedFile}); testMain != nil {
+		return modifiedFile.Fset.Position(testMain.Pos()).Line, nil
+	}
+	return 0, nil
+}
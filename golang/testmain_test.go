@@ -0,0 +1,339 @@
+package golang
+
+import (
+	"go/ast"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipPackage(t *testing.T) {
+	t.Parallel()
+
+	const withTestMain = `package example
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	setup()
+	os.Exit(m.Run())
+}
+`
+	const withoutTestMain = `package example
+
+import (
+	"testing"
+)
+
+func TestA(t *testing.T) {}
+`
+
+	tests := []struct {
+		name           string
+		source         string
+		generator      Generator
+		expectedSource string
+	}{
+		{
+			name:      "existing TestMain",
+			source:    withTestMain,
+			generator: FlakyGenerator(),
+			expectedSource: `package example
+
+import (
+	"os"
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestMain(m *testing.M) {
+	if quarantine.FlakyPackage("JIRA-A") {
+		return
+	}
+	setup()
+	os.Exit(m.Run())
+}
+`,
+		},
+		{
+			name:      "existing TestMain inline skip",
+			source:    withTestMain,
+			generator: InlineSkipGenerator(),
+			expectedSource: `package example
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv("RUN_QUARANTINED_TESTS") != "true" {
+		println("Known flaky package. Ticket JIRA-A. To run quarantined tests, set the RUN_QUARANTINED_TESTS environment variable to true.")
+		return
+	}
+	setup()
+	os.Exit(m.Run())
+}
+`,
+		},
+		{
+			name:      "generated TestMain",
+			source:    withoutTestMain,
+			generator: FlakyGenerator(),
+			expectedSource: `package example
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {}
+
+func TestMain(m *testing.M) {
+	if quarantine.FlakyPackage("JIRA-A") {
+		return
+	}
+	m.Run()
+}
+`,
+		},
+		{
+			name:      "generated TestMain inline skip",
+			source:    withoutTestMain,
+			generator: InlineSkipGenerator(),
+			expectedSource: `package example
+
+import (
+	"os"
+	"testing"
+)
+
+func TestA(t *testing.T) {}
+
+func TestMain(m *testing.M) {
+	if os.Getenv("RUN_QUARANTINED_TESTS") != "true" {
+		println("Known flaky package. Ticket JIRA-A. To run quarantined tests, set the RUN_QUARANTINED_TESTS environment variable to true.")
+		return
+	}
+	m.Run()
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testFile, err := parseTestFile("example_test.go", []byte(test.source))
+			require.NoError(t, err)
+			_, testMain := findTestMain([]parsedTestFile{testFile})
+			modifiedSource, quarantinedTest, err := skipPackage(
+				testFile,
+				testMain,
+				TestToQuarantine{Name: PackageTestName, JiraTicket: "JIRA-A"},
+				test.generator,
+			)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSource, modifiedSource)
+			assert.False(t, quarantinedTest.AlreadyQuarantined)
+			assert.NotZero(t, quarantinedTest.ModifiedLine)
+
+			// Quarantining again is a no-op, and a new ticket only changes the ticket
+			testFile, err = parseTestFile("example_test.go", []byte(modifiedSource))
+			require.NoError(t, err)
+			_, testMain = findTestMain([]parsedTestFile{testFile})
+			require.NotNil(t, testMain)
+			requarantinedSource, quarantinedTest, err := skipPackage(
+				testFile,
+				testMain,
+				TestToQuarantine{Name: PackageTestName, JiraTicket: "JIRA-A"},
+				test.generator,
+			)
+			require.NoError(t, err)
+			assert.Equal(t, modifiedSource, requarantinedSource)
+			assert.True(t, quarantinedTest.AlreadyQuarantined)
+
+			newTicketSource, quarantinedTest, err := skipPackage(
+				testFile,
+				testMain,
+				TestToQuarantine{Name: PackageTestName, JiraTicket: "JIRA-NEW"},
+				test.generator,
+			)
+			require.NoError(t, err)
+			assert.Equal(t, "JIRA-A", quarantinedTest.PreviousJiraTicket)
+			assert.Contains(t, newTicketSource, "JIRA-NEW")
+			assert.NotContains(t, newTicketSource, "JIRA-A")
+
+			// Un-quarantining restores the original, removing a generated TestMain
+			unquarantinedSource, unquarantinedTest, ok, err := unskipPackage(
+				testFile,
+				testMain,
+				unquarantineGenerators(test.generator),
+			)
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, test.source, unquarantinedSource)
+			assert.Equal(t, "JIRA-A", unquarantinedTest.JiraTicket)
+		})
+	}
+}
+
+func TestSkipPackage_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	testFile, err := parseTestFile("example_test.go", []byte("package example\n\nimport \"testing\"\n"))
+	require.NoError(t, err)
+	_, _, err = skipPackage(
+		testFile,
+		nil,
+		TestToQuarantine{Name: PackageTestName, JiraTicket: "JIRA-A"},
+		FuncGenerator("example.com/testutil", "Quarantine"),
+	)
+	require.ErrorIs(t, err, ErrPackageQuarantineUnsupported)
+}
+
+func TestProcessPackageTest_Constraints(t *testing.T) {
+	t.Parallel()
+
+	const (
+		constrainedSource   = "//go:build integration\n\npackage example\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n"
+		windowsSource       = "package example\n\nimport \"testing\"\n\nfunc TestB(t *testing.T) {}\n"
+		unconstrainedSource = "package example\n\nimport (\n\t\"testing\"\n)\n\nfunc TestC(t *testing.T) {}\n"
+	)
+
+	tests := []struct {
+		name            string
+		files           map[string]string
+		expectedFile    string
+		expectedCreated bool
+		expectedSource  string
+	}{
+		{
+			name: "unconstrained file",
+			files: map[string]string{
+				"a_test.go":         constrainedSource,
+				"b_windows_test.go": windowsSource,
+				"c_test.go":         unconstrainedSource,
+			},
+			expectedFile: "c_test.go",
+			expectedSource: `package example
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestC(t *testing.T) {}
+
+func TestMain(m *testing.M) {
+	if quarantine.FlakyPackage("JIRA-A") {
+		return
+	}
+	m.Run()
+}
+`,
+		},
+		{
+			name: "only constrained files",
+			files: map[string]string{
+				"a_test.go":         constrainedSource,
+				"b_windows_test.go": windowsSource,
+			},
+			expectedFile:    "main_test.go",
+			expectedCreated: true,
+			expectedSource: `package example
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestMain(m *testing.M) {
+	if quarantine.FlakyPackage("JIRA-A") {
+		return
+	}
+	m.Run()
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				repoPath  = t.TempDir()
+				testFiles []parsedTestFile
+				results   = QuarantinePackageResults{Package: "example.com/example"}
+			)
+			for _, name := range []string{"a_test.go", "b_windows_test.go", "c_test.go"} {
+				source, ok := test.files[name]
+				if !ok {
+					continue
+				}
+				testFile, err := parseTestFile(filepath.Join(repoPath, name), []byte(source))
+				require.NoError(t, err)
+				testFiles = append(testFiles, testFile)
+			}
+
+			quarantined, err := processPackageTest(
+				repoPath,
+				PackageInfo{ImportPath: "example.com/example"},
+				testFiles,
+				&results,
+				func(testFile parsedTestFile, testMain *ast.FuncDecl) (string, QuarantinedTest, bool, error) {
+					modifiedSource, quarantinedTest, err := skipPackage(
+						testFile,
+						testMain,
+						TestToQuarantine{Name: PackageTestName, JiraTicket: "JIRA-A"},
+						FlakyGenerator(),
+					)
+					return modifiedSource, quarantinedTest, err == nil, err
+				},
+			)
+			require.NoError(t, err)
+			require.True(t, quarantined)
+			require.Len(t, results.Successes, 1)
+			assert.Equal(t, test.expectedFile, results.Successes[0].File)
+			assert.Equal(t, test.expectedCreated, results.Successes[0].Created)
+			assert.Equal(t, test.expectedSource, results.Successes[0].ModifiedSourceCode)
+
+			// Un-quarantining a new file deletes it, as nothing but its package clause is left
+			testFile, err := parseTestFile(
+				results.Successes[0].FileAbs,
+				[]byte(results.Successes[0].ModifiedSourceCode),
+			)
+			require.NoError(t, err)
+			unquarantineResults := QuarantinePackageResults{Package: "example.com/example", Unquarantine: true}
+			unquarantined, err := processPackageTest(
+				repoPath,
+				PackageInfo{ImportPath: "example.com/example"},
+				[]parsedTestFile{testFile},
+				&unquarantineResults,
+				func(testFile parsedTestFile, testMain *ast.FuncDecl) (string, QuarantinedTest, bool, error) {
+					return unskipPackage(testFile, testMain, unquarantineGenerators(FlakyGenerator()))
+				},
+			)
+			require.NoError(t, err)
+			require.True(t, unquarantined)
+			require.Len(t, unquarantineResults.Successes, 1)
+			assert.Equal(t, test.expectedCreated, unquarantineResults.Successes[0].Deleted)
+			if test.expectedCreated {
+				assert.Empty(t, unquarantineResults.Successes[0].ModifiedSourceCode)
+			} else {
+				assert.Equal(t, results.Successes[0].OriginalSourceCode, unquarantineResults.Successes[0].ModifiedSourceCode)
+			}
+		})
+	}
+}
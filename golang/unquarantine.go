@@ -0,0 +1,277 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// UnquarantineTests looks through a Go project to find any tests that match the given targets and removes their quarantine.
+// It is the counterpart of QuarantineTests: the quarantine.Flaky call is removed from each test, and the quarantine import
+// is dropped from any file that no longer uses it.
+// Like QuarantineTests, the modified source code is returned rather than written, see WriteQuarantineResultsToFiles.
+// Only files that were actually modified are included in the results.
+func UnquarantineTests(
+	l zerolog.Logger,
+	repoPath string,
+	unquarantineTargets []QuarantineTarget,
+	options ...QuarantineOption,
+) (QuarantineResults, error) {
+	return processTargets(l, repoPath, unquarantineTargets, "unquarantine", unquarantinePackage, options...)
+}
+
+// unquarantinePackage looks for test functions in all test files in a package and removes their quarantine.
+func unquarantinePackage(
+	l zerolog.Logger,
+	repoPath string,
+	pkg PackageInfo,
+	target QuarantineTarget,
+	options *quarantineOptions,
+) (QuarantinePackageResults, error) {
+	unquarantineTarget := target
+	testNames := unquarantineTarget.TestNames()
+	l = l.With().
+		Str("package", pkg.ImportPath).
+		Strs("test_files", pkg.AllTestGoFiles()).
+		Strs("tests_to_unquarantine", testNames).
+		Logger()
+	l.Debug().Msg("Un-quarantining tests in package")
+
+	var (
+		haveUnquarantined = make(map[string]bool)
+		results           = QuarantinePackageResults{
+			Package:      pkg.ImportPath,
+			GoModDir:     pkg.Module.Dir,
+			Successes:    make([]QuarantinedFile, 0, len(testNames)),
+			Failures:     make([]string, 0, len(testNames)),
+			Unquarantine: true,
+		}
+	)
+
+	testFiles, suites, err := parseTestFiles(pkg, "un-quarantining")
+	if err != nil {
+		return results, err
+	}
+	unquarantineTarget, results.PatternMatches = expandPatterns(l, testFiles, suites, unquarantineTarget)
+
+	for _, testFile := range testFiles {
+		l := l.With().Str("test_file", testFile.Path).Logger()
+
+		foundTests := testsInFile(testFile.Node, unquarantineTarget, suites)
+		if len(foundTests) == 0 {
+			continue
+		}
+
+		modifiedSource, unquarantinedTests, err := unskipTests(
+			testFile,
+			foundTests,
+			suites,
+			unquarantineGenerators(options.generator),
+		)
+		if err != nil {
+			return results, fmt.Errorf("failed to un-quarantine tests in file %s: %w", testFile.Path, err)
+		}
+		if len(unquarantinedTests) == 0 {
+			l.Debug().Msg("Found tests in file, but none of them were quarantined")
+			continue
+		}
+
+		unquarantinedNames := make([]string, 0, len(unquarantinedTests))
+		for _, test := range unquarantinedTests {
+			haveUnquarantined[test.Name] = true
+			unquarantinedNames = append(unquarantinedNames, test.Name)
+		}
+		l.Debug().Strs("unquarantined_tests", unquarantinedNames).Msg("Successfully un-quarantined tests in file")
+
+		relativeFilePath, err := relativePath(repoPath, testFile.Path)
+		if err != nil {
+			return results, err
+		}
+		results.Successes = append(results.Successes, QuarantinedFile{
+			Package:            pkg.ImportPath,
+			File:               relativeFilePath,
+			FileAbs:            testFile.Path,
+			Tests:              unquarantinedTests,
+			OriginalSourceCode: string(testFile.Src),
+			ModifiedSourceCode: modifiedSource,
+		})
+	}
+
+	if _, ok := unquarantineTarget.packageTest(); ok {
+		generators := unquarantineGenerators(options.generator)
+		unquarantined, err := processPackageTest(repoPath, pkg, testFiles, &results,
+			func(testFile parsedTestFile, testMain *ast.FuncDecl) (string, QuarantinedTest, bool, error) {
+				return unskipPackage(testFile, testMain, generators)
+			},
+		)
+		if err != nil {
+			return results, fmt.Errorf("failed to un-quarantine package %s: %w", pkg.ImportPath, err)
+		}
+		haveUnquarantined[PackageTestName] = unquarantined
+	}
+
+	// Add any tests that were not found, or were not quarantined, and patterns that didn't match any quarantined tests,
+	// to the failures
+	results.Failures = append(results.Failures, targetFailures(target, results.PatternMatches, haveUnquarantined)...)
+
+	return results, nil
+}
+
+// unquarantineGenerators returns the generators whose quarantines are removed: the configured one,
+// and the built-in ones, so that tests are un-quarantined no matter how they were quarantined.
+func unquarantineGenerators(configured Generator) []Generator {
+	generators := []Generator{configured}
+	for _, builtin := range builtinGenerators() {
+		if builtin != configured {
+			generators = append(generators, builtin)
+		}
+	}
+	return generators
+}
+
+// unskipTests removes the quarantine statements generated by any of the generators from the given test functions
+// and subtests. If a generator's package is no longer used by the file, its import is removed as well.
+// Tests that are not quarantined are not included in the returned list.
+// Only the removed statements and the imports are cut from the source, the rest of the file is left untouched.
+func unskipTests(
+	testFile parsedTestFile,
+	testsToUnskip []foundTest,
+	suites suiteRunners,
+	generators []Generator,
+) (string, []QuarantinedTest, error) {
+	fset, fileRootNode, tokenFile := testFile.Fset, testFile.Node, testFile.tokenFile()
+	var (
+		importNames = make([]string, len(generators))
+		imported    = make([]bool, len(generators))
+	)
+	for i, generator := range generators {
+		importNames[i], imported[i] = generatorImport(fileRootNode, generator)
+	}
+
+	var (
+		unquarantinedTests = make([]QuarantinedTest, 0, len(testsToUnskip))
+		usedImports        []string
+		edits              []textEdit
+	)
+	for _, testToUnskip := range testsToUnskip {
+		var (
+			ticket  string
+			removed bool
+		)
+		for _, scope := range testToUnskip.Scopes {
+			keptStmts := make([]ast.Stmt, 0, len(scope.Body.List))
+			for _, stmt := range scope.Body.List {
+				generatorIndex := -1
+				for i, generator := range generators {
+					if !imported[i] {
+						continue
+					}
+					if _, t, _, ok := scopeQuarantine(generator, importNames[i], stmt, scope, testToUnskip.Name); ok {
+						generatorIndex = i
+						if t != "" {
+							ticket = t
+						}
+						break
+					}
+				}
+				if generatorIndex < 0 {
+					keptStmts = append(keptStmts, stmt)
+					continue
+				}
+				removed = true
+				edits = append(edits, removeStmtEdit(testFile.Src, tokenFile, stmt))
+				if importPath := generators[generatorIndex].ImportPath(); importPath != "" {
+					usedImports = appendUnique(usedImports, []string{importPath})
+				}
+			}
+			scope.Body.List = keptStmts
+		}
+		if !removed {
+			continue
+		}
+
+		if ticket == "" {
+			ticket = testToUnskip.JiraTicket
+		}
+		unquarantinedTests = append(unquarantinedTests, QuarantinedTest{
+			Name:         testToUnskip.Name,
+			JiraTicket:   ticket,
+			OriginalLine: fset.Position(testToUnskip.Scopes[0].Pos).Line,
+		})
+	}
+
+	if len(unquarantinedTests) == 0 {
+		return "", nil, nil
+	}
+
+	modifiedSource, err := applyEdits(testFile.Src, edits)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to edit source: %w", err)
+	}
+
+	// The removed statements are also dropped from the AST, so we can check if the imports are still used.
+	modifiedSource, err = removeUnusedImports(testFile.Path, fileRootNode, modifiedSource, usedImports)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := setModifiedLines(modifiedSource, unquarantinedTests, suites); err != nil {
+		return "", nil, err
+	}
+	return modifiedSource, unquarantinedTests, nil
+}
+
+// removeUnusedImports removes the imports that the file's AST no longer uses from the modified source of the file.
+// Imports are removed one at a time, as removing one can change the import declaration around the next.
+func removeUnusedImports(path string, node *ast.File, modifiedSource string, importPaths []string) (string, error) {
+	for _, importPath := range importPaths {
+		if astutil.UsesImport(node, importPath) {
+			continue
+		}
+		modifiedFile, err := parseTestFile(path, []byte(modifiedSource))
+		if err != nil {
+			return "", fmt.Errorf("failed to parse modified source: %w", err)
+		}
+		edit, ok := removeImportEdit(modifiedFile.Src, modifiedFile.tokenFile(), modifiedFile.Node, importPath)
+		if !ok {
+			continue
+		}
+		if modifiedSource, err = applyEdits(modifiedFile.Src, []textEdit{edit}); err != nil {
+			return "", fmt.Errorf("failed to edit source: %w", err)
+		}
+	}
+	return modifiedSource, nil
+}
+
+// importLocalName returns the name the import path is referred to by in the file.
+// Returns the empty string if the file does not import the path.
+func importLocalName(node *ast.File, importPath string) string {
+	for _, imp := range node.Imports {
+		if imp.Path == nil || strings.Trim(imp.Path.Value, `"`) != importPath {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return importPath[strings.LastIndex(importPath, "/")+1:]
+	}
+	return ""
+}
+
+// stringLiteral returns the value of a string literal expression.
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
@@ -0,0 +1,163 @@
+package golang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnskipTests(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		source          string
+		target          QuarantineTarget
+		expectedSource  string
+		expectedTickets map[string]string
+	}{
+		{
+			name: "removes call and unused import",
+			source: `package example
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-A")
+	t.Parallel()
+}
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA"}}},
+			expectedSource: `package example
+
+import (
+	"testing"
+)
+
+func TestA(t *testing.T) {
+	t.Parallel()
+}
+`,
+			expectedTickets: map[string]string{"TestA": "JIRA-A"},
+		},
+		{
+			name: "keeps import still in use",
+			source: `package example
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-A")
+	t.Parallel()
+}
+
+func TestB(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-B")
+	t.Parallel()
+}
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA"}}},
+			expectedSource: `package example
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {
+	t.Parallel()
+}
+
+func TestB(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-B")
+	t.Parallel()
+}
+`,
+			expectedTickets: map[string]string{"TestA": "JIRA-A"},
+		},
+		{
+			name: "aliased import",
+			source: `package example
+
+import (
+	"testing"
+
+	q "github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(x *testing.T) {
+	q.Flaky(x, "JIRA-A")
+	x.Parallel()
+}
+`,
+			target: QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA"}}},
+			expectedSource: `package example
+
+import (
+	"testing"
+)
+
+func TestA(x *testing.T) {
+	x.Parallel()
+}
+`,
+			expectedTickets: map[string]string{"TestA": "JIRA-A"},
+		},
+		{
+			name: "test not quarantined",
+			source: `package example
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestA(t *testing.T) {
+	t.Parallel()
+}
+
+func TestB(t *testing.T) {
+	quarantine.Flaky(t, "JIRA-B")
+}
+`,
+			target:          QuarantineTarget{Tests: []TestToQuarantine{{Name: "TestA"}}},
+			expectedSource:  "",
+			expectedTickets: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testFile, err := parseTestFile("example_test.go", []byte(test.source))
+			require.NoError(t, err)
+
+			modifiedSource, unquarantinedTests, err := unskipTests(
+				testFile,
+				testsInFile(testFile.Node, test.target, nil),
+				nil,
+				builtinGenerators(),
+			)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSource, modifiedSource)
+
+			tickets := make(map[string]string, len(unquarantinedTests))
+			for _, unquarantinedTest := range unquarantinedTests {
+				tickets[unquarantinedTest.Name] = unquarantinedTest.JiraTicket
+			}
+			assert.Equal(t, test.expectedTickets, tickets)
+		})
+	}
+}
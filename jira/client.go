@@ -28,6 +28,7 @@ import (
 type issueService interface {
 	Create(issue *go_jira.Issue) (*go_jira.Issue, *go_jira.Response, error)
 	Update(issue *go_jira.Issue) (*go_jira.Issue, *go_jira.Response, error)
+	Get(issueID string, options *go_jira.GetQueryOptions) (*go_jira.Issue, *go_jira.Response, error)
 	Search(jql string, options *go_jira.SearchOptions) ([]go_jira.Issue, *go_jira.Response, error)
 }
 
@@ -38,6 +39,9 @@ type fieldService interface {
 }
 
 const (
+	// searchPageSize is how many issues are requested per page when searching for all matching issues.
+	searchPageSize = 100
+
 	// BranchOutLabel is the label used for any issues created by branch-out.
 	BranchOutLabel = "branch-out"
 	// FlakyTestLabel is the label used for any issues referencing a flaky test.
@@ -67,6 +71,8 @@ var (
 	ErrJiraTransition = errors.New("jira transition operation failed")
 	// ErrJiraGetTransitions is returned when we fail to get the Jira transition statuses.
 	ErrJiraGetTransitions = errors.New("jira get transitions operation failed")
+	// ErrJiraGetIssue is returned when we fail to get a Jira issue, e.g. to get its status.
+	ErrJiraGetIssue = errors.New("jira get issue operation failed")
 	// ErrNoTransitionFound is returned when we fail to find a transition for a Jira issue.
 	ErrNoTransitionFound = errors.New("no transition found")
 )
@@ -396,7 +402,7 @@ func (c *Client) buildCustomFields(req FlakyTestIssueRequest) tcontainer.Marshal
 	return customFields
 }
 
-// GetOpenFlakyTestIssues returns all open flaky test tickets.
+// GetOpenFlakyTestIssues returns all open flaky test tickets, with the package and test they're for.
 func (c *Client) GetOpenFlakyTestIssues() ([]FlakyTestIssue, error) {
 	jql := fmt.Sprintf(
 		`project = "%s" AND labels = "%s" AND status != "Closed"`,
@@ -404,25 +410,41 @@ func (c *Client) GetOpenFlakyTestIssues() ([]FlakyTestIssue, error) {
 		FlakyTestLabel,
 	)
 	c.logger.Debug().Str("jql", jql).Msg("Searching for all open flaky test issues")
-	issues, resp, err := c.IssueService.Search(
-		jql,
-		&go_jira.SearchOptions{
-			Fields: []string{"key", "id", "self"},
-		},
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search for flaky test tickets: %w", err)
-	}
-	if err := checkResponse(resp); err != nil {
-		return nil, err
+
+	// The package and test are read from the custom fields if they're configured, or from the summary
+	searchFields := []string{"key", "id", "self", "summary"}
+	for _, field := range []string{c.config.TestFieldID, c.config.PackageFieldID, c.config.TrunkIDFieldID} {
+		if field != "" {
+			searchFields = append(searchFields, field)
+		}
 	}
-	c.logger.Debug().Int("num_issues", len(issues)).Msg("Finished searching for open flaky test issues")
 
 	flakyTestIssues := []FlakyTestIssue{}
-	for _, issue := range issues {
-		flakyTestIssue := c.wrapFlakyTestIssue(&issue)
-		flakyTestIssues = append(flakyTestIssues, flakyTestIssue)
+	for {
+		issues, resp, err := c.IssueService.Search(
+			jql,
+			&go_jira.SearchOptions{
+				StartAt:    len(flakyTestIssues),
+				MaxResults: searchPageSize,
+				Fields:     searchFields,
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search for flaky test tickets: %w", err)
+		}
+		if err := checkResponse(resp); err != nil {
+			return nil, err
+		}
+
+		for _, issue := range issues {
+			flakyTestIssue := c.wrapFlakyTestIssue(&issue)
+			flakyTestIssues = append(flakyTestIssues, flakyTestIssue)
+		}
+		if len(issues) < searchPageSize {
+			break
+		}
 	}
+	c.logger.Debug().Int("num_issues", len(flakyTestIssues)).Msg("Finished searching for open flaky test issues")
 
 	return flakyTestIssues, nil
 }
@@ -453,6 +475,21 @@ func (c *Client) GetOpenFlakyTestIssue(packageName, testName string) (FlakyTestI
 	return *issue, nil
 }
 
+// GetIssueStatus returns the name of the status of a Jira issue, e.g. "In Progress" or "Done".
+func (c *Client) GetIssueStatus(issueKey string) (string, error) {
+	issue, resp, err := c.IssueService.Get(issueKey, &go_jira.GetQueryOptions{Fields: "status"})
+	if err != nil {
+		return "", fmt.Errorf("%w for issue %s: %w", ErrJiraGetIssue, issueKey, err)
+	}
+	if err := checkResponse(resp); err != nil {
+		return "", fmt.Errorf("%w for issue %s: %w", ErrJiraGetIssue, issueKey, err)
+	}
+	if issue == nil || issue.Fields == nil || issue.Fields.Status == nil {
+		return "", fmt.Errorf("%w for issue %s: no status in response", ErrJiraGetIssue, issueKey)
+	}
+	return issue.Fields.Status.Name, nil
+}
+
 // GetProjectKey returns the project key for the Jira client.
 // Note: this is likely to be deprecated in the future as we'll be using multiple projects in Jira.
 func (c *Client) GetProjectKey() string {
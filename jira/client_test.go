@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 	"testing"
 
@@ -303,6 +304,54 @@ func TestGetOpenFlakyTestIssues(t *testing.T) {
 	}
 }
 
+func TestGetOpenFlakyTestIssues_Pages(t *testing.T) {
+	t.Parallel()
+
+	mockIssueService := newMockIssueService(t)
+	mockFieldService := newMockFieldService(t)
+
+	firstPage := make([]go_jira.Issue, searchPageSize)
+	for i := range firstPage {
+		firstPage[i] = go_jira.Issue{
+			Key:    "TEST-1",
+			Fields: &go_jira.IssueFields{Summary: "Flaky Test: github.com/org/repo/pkg.TestA"},
+		}
+	}
+	mockIssueService.EXPECT().
+		Search(mock.Anything, mock.MatchedBy(func(options *go_jira.SearchOptions) bool {
+			return options.StartAt == 0 && slices.Contains(options.Fields, "summary")
+		})).
+		Return(firstPage, nil, nil).
+		Once()
+	mockIssueService.EXPECT().
+		Search(mock.Anything, mock.MatchedBy(func(options *go_jira.SearchOptions) bool {
+			return options.StartAt == searchPageSize
+		})).
+		Return([]go_jira.Issue{{Key: "TEST-2"}}, nil, nil).
+		Once()
+
+	client, err := NewClient(
+		WithLogger(testhelpers.Logger(t)),
+		WithConfig(config.Config{
+			Jira: config.Jira{
+				ProjectKey: "TEST",
+				BaseDomain: "test.atlassian.net",
+				Username:   "test",
+				Token:      "test",
+			},
+		}),
+		WithServices(mockIssueService, mockFieldService),
+	)
+	require.NoError(t, err, "error creating client")
+
+	flakyTestIssues, err := client.GetOpenFlakyTestIssues()
+	require.NoError(t, err)
+	require.Len(t, flakyTestIssues, searchPageSize+1)
+	require.Equal(t, "github.com/org/repo/pkg", flakyTestIssues[0].Package)
+	require.Equal(t, "TestA", flakyTestIssues[0].Test)
+	require.Equal(t, "TEST-2", flakyTestIssues[searchPageSize].Key)
+}
+
 func TestGetOpenFlakyTestIssue(t *testing.T) {
 	t.Parallel()
 
@@ -419,6 +468,43 @@ func TestGetOpenFlakyTestIssue(t *testing.T) {
 		})
 	}
 }
+func TestGetIssueStatus(t *testing.T) {
+	t.Parallel()
+
+	mockIssueService := newMockIssueService(t)
+	mockFieldService := newMockFieldService(t)
+	mockIssueService.EXPECT().
+		Get("TEST-1", &go_jira.GetQueryOptions{Fields: "status"}).
+		Return(&go_jira.Issue{
+			Key:    "TEST-1",
+			Fields: &go_jira.IssueFields{Status: &go_jira.Status{Name: "In Progress"}},
+		}, nil, nil)
+	mockIssueService.EXPECT().
+		Get("TEST-2", &go_jira.GetQueryOptions{Fields: "status"}).
+		Return(nil, nil, errors.New("issue does not exist"))
+
+	client, err := NewClient(
+		WithLogger(testhelpers.Logger(t)),
+		WithConfig(config.Config{
+			Jira: config.Jira{
+				ProjectKey: "TEST",
+				BaseDomain: "test.atlassian.net",
+				Username:   "test",
+				Token:      "test",
+			},
+		}),
+		WithServices(mockIssueService, mockFieldService),
+	)
+	require.NoError(t, err, "error creating client")
+
+	status, err := client.GetIssueStatus("TEST-1")
+	require.NoError(t, err)
+	require.Equal(t, "In Progress", status)
+
+	_, err = client.GetIssueStatus("TEST-2")
+	require.ErrorIs(t, err, ErrJiraGetIssue)
+}
+
 func TestExtractFromSummary(t *testing.T) {
 	t.Parallel()
 
@@ -106,6 +106,82 @@ func (_c *mockIssueService_Create_Call) RunAndReturn(run func(issue *jira.Issue)
 	return _c
 }
 
+// Get provides a mock function for the type mockIssueService
+func (_mock *mockIssueService) Get(issueID string, options *jira.GetQueryOptions) (*jira.Issue, *jira.Response, error) {
+	ret := _mock.Called(issueID, options)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 *jira.Issue
+	var r1 *jira.Response
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(string, *jira.GetQueryOptions) (*jira.Issue, *jira.Response, error)); ok {
+		return returnFunc(issueID, options)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, *jira.GetQueryOptions) *jira.Issue); ok {
+		r0 = returnFunc(issueID, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*jira.Issue)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, *jira.GetQueryOptions) *jira.Response); ok {
+		r1 = returnFunc(issueID, options)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*jira.Response)
+		}
+	}
+	if returnFunc, ok := ret.Get(2).(func(string, *jira.GetQueryOptions) error); ok {
+		r2 = returnFunc(issueID, options)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// mockIssueService_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type mockIssueService_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - issueID string
+//   - options *jira.GetQueryOptions
+func (_e *mockIssueService_Expecter) Get(issueID interface{}, options interface{}) *mockIssueService_Get_Call {
+	return &mockIssueService_Get_Call{Call: _e.mock.On("Get", issueID, options)}
+}
+
+func (_c *mockIssueService_Get_Call) Run(run func(issueID string, options *jira.GetQueryOptions)) *mockIssueService_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 *jira.GetQueryOptions
+		if args[1] != nil {
+			arg1 = args[1].(*jira.GetQueryOptions)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockIssueService_Get_Call) Return(issue *jira.Issue, response *jira.Response, err error) *mockIssueService_Get_Call {
+	_c.Call.Return(issue, response, err)
+	return _c
+}
+
+func (_c *mockIssueService_Get_Call) RunAndReturn(run func(issueID string, options *jira.GetQueryOptions) (*jira.Issue, *jira.Response, error)) *mockIssueService_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Search provides a mock function for the type mockIssueService
 func (_mock *mockIssueService) Search(jql string, options *jira.SearchOptions) ([]jira.Issue, *jira.Response, error) {
 	ret := _mock.Called(jql, options)
@@ -0,0 +1,83 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// background manages the lifecycle of a loop running in the background until it's stopped,
+// shared by the Worker and the Syncer.
+type background struct {
+	logger zerolog.Logger
+	name   string // Name of what runs in the background, for errors and logs, e.g. "SQS worker"
+
+	// State management
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	running bool
+	mu      sync.RWMutex
+}
+
+// newBackground creates the lifecycle of a loop that isn't running yet.
+func newBackground(logger zerolog.Logger, name string) *background {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &background{
+		logger: logger,
+		name:   name,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// start runs the loop in a goroutine. The loop should return once the context is cancelled.
+func (b *background) start(loop func()) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.running {
+		return fmt.Errorf("%s is already running", b.name)
+	}
+
+	b.running = true
+	b.wg.Add(1)
+
+	go func() {
+		defer b.wg.Done()
+		loop()
+	}()
+
+	return nil
+}
+
+// Stop gracefully stops the loop and waits for its current iteration to complete.
+func (b *background) Stop() error {
+	b.mu.Lock()
+	if !b.running {
+		b.mu.Unlock()
+		return nil
+	}
+	b.running = false
+	b.mu.Unlock()
+
+	b.logger.Info().Msgf("Stopping %s", b.name)
+
+	// Cancel the context to signal shutdown
+	b.cancel()
+
+	// Wait for the loop's goroutine to finish
+	b.wg.Wait()
+
+	b.logger.Info().Msgf("Stopped %s", b.name)
+	return nil
+}
+
+// IsRunning returns whether the loop is currently running.
+func (b *background) IsRunning() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.running
+}
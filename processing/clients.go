@@ -37,6 +37,7 @@ type JiraClient interface {
 	CreateFlakyTestIssue(req jira.FlakyTestIssueRequest) (jira.FlakyTestIssue, error)
 	GetOpenFlakyTestIssues() ([]jira.FlakyTestIssue, error)
 	GetOpenFlakyTestIssue(packageName, testName string) (jira.FlakyTestIssue, error)
+	GetIssueStatus(issueKey string) (string, error)
 	GetProjectKey() string
 	AddCommentToFlakyTestIssue(issue jira.FlakyTestIssue, statusChange trunk.TestCaseStatusChange) error
 	CloseIssue(issueKey, comment string) error
@@ -51,7 +52,7 @@ type TrunkClient interface {
 
 // GithubClient interacts with GitHub.
 type GithubClient interface {
-	GetBranchNames(ctx context.Context, owner, repo string) (string, string, error)
+	GetBranchNames(ctx context.Context, owner, repo string, unquarantine bool) (string, string, error)
 	GetOrCreateRemoteBranch(ctx context.Context, owner, repo, branchName string) (string, error)
 	GitCloneRepo(owner, repoName string) (*git.Repository, string, error)
 	GitCheckoutBranch(repo *git.Repository, branchName string) error
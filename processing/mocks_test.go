@@ -495,6 +495,66 @@ func (_c *MockJiraClient_CreateFlakyTestIssue_Call) RunAndReturn(run func(req ji
 	return _c
 }
 
+// GetIssueStatus provides a mock function for the type MockJiraClient
+func (_mock *MockJiraClient) GetIssueStatus(issueKey string) (string, error) {
+	ret := _mock.Called(issueKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetIssueStatus")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return returnFunc(issueKey)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) string); ok {
+		r0 = returnFunc(issueKey)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(issueKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockJiraClient_GetIssueStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetIssueStatus'
+type MockJiraClient_GetIssueStatus_Call struct {
+	*mock.Call
+}
+
+// GetIssueStatus is a helper method to define mock.On call
+//   - issueKey string
+func (_e *MockJiraClient_Expecter) GetIssueStatus(issueKey interface{}) *MockJiraClient_GetIssueStatus_Call {
+	return &MockJiraClient_GetIssueStatus_Call{Call: _e.mock.On("GetIssueStatus", issueKey)}
+}
+
+func (_c *MockJiraClient_GetIssueStatus_Call) Run(run func(issueKey string)) *MockJiraClient_GetIssueStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockJiraClient_GetIssueStatus_Call) Return(s string, err error) *MockJiraClient_GetIssueStatus_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockJiraClient_GetIssueStatus_Call) RunAndReturn(run func(issueKey string) (string, error)) *MockJiraClient_GetIssueStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetOpenFlakyTestIssue provides a mock function for the type MockJiraClient
 func (_mock *MockJiraClient) GetOpenFlakyTestIssue(packageName string, testName string) (jira.FlakyTestIssue, error) {
 	ret := _mock.Called(packageName, testName)
@@ -1032,8 +1092,8 @@ func (_c *MockGithubClient_GenerateCommitAndPush_Call) RunAndReturn(run func(ctx
 }
 
 // GetBranchNames provides a mock function for the type MockGithubClient
-func (_mock *MockGithubClient) GetBranchNames(ctx context.Context, owner string, repo string) (string, string, error) {
-	ret := _mock.Called(ctx, owner, repo)
+func (_mock *MockGithubClient) GetBranchNames(ctx context.Context, owner string, repo string, unquarantine bool) (string, string, error) {
+	ret := _mock.Called(ctx, owner, repo, unquarantine)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetBranchNames")
@@ -1042,21 +1102,21 @@ func (_mock *MockGithubClient) GetBranchNames(ctx context.Context, owner string,
 	var r0 string
 	var r1 string
 	var r2 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (string, string, error)); ok {
-		return returnFunc(ctx, owner, repo)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, bool) (string, string, error)); ok {
+		return returnFunc(ctx, owner, repo, unquarantine)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
-		r0 = returnFunc(ctx, owner, repo)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, bool) string); ok {
+		r0 = returnFunc(ctx, owner, repo, unquarantine)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) string); ok {
-		r1 = returnFunc(ctx, owner, repo)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, bool) string); ok {
+		r1 = returnFunc(ctx, owner, repo, unquarantine)
 	} else {
 		r1 = ret.Get(1).(string)
 	}
-	if returnFunc, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
-		r2 = returnFunc(ctx, owner, repo)
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, string, bool) error); ok {
+		r2 = returnFunc(ctx, owner, repo, unquarantine)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -1072,11 +1132,12 @@ type MockGithubClient_GetBranchNames_Call struct {
 //   - ctx context.Context
 //   - owner string
 //   - repo string
-func (_e *MockGithubClient_Expecter) GetBranchNames(ctx interface{}, owner interface{}, repo interface{}) *MockGithubClient_GetBranchNames_Call {
-	return &MockGithubClient_GetBranchNames_Call{Call: _e.mock.On("GetBranchNames", ctx, owner, repo)}
+//   - unquarantine bool
+func (_e *MockGithubClient_Expecter) GetBranchNames(ctx interface{}, owner interface{}, repo interface{}, unquarantine interface{}) *MockGithubClient_GetBranchNames_Call {
+	return &MockGithubClient_GetBranchNames_Call{Call: _e.mock.On("GetBranchNames", ctx, owner, repo, unquarantine)}
 }
 
-func (_c *MockGithubClient_GetBranchNames_Call) Run(run func(ctx context.Context, owner string, repo string)) *MockGithubClient_GetBranchNames_Call {
+func (_c *MockGithubClient_GetBranchNames_Call) Run(run func(ctx context.Context, owner string, repo string, unquarantine bool)) *MockGithubClient_GetBranchNames_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1090,10 +1151,15 @@ func (_c *MockGithubClient_GetBranchNames_Call) Run(run func(ctx context.Context
 		if args[2] != nil {
 			arg2 = args[2].(string)
 		}
+		var arg3 bool
+		if args[3] != nil {
+			arg3 = args[3].(bool)
+		}
 		run(
 			arg0,
 			arg1,
 			arg2,
+			arg3,
 		)
 	})
 	return _c
@@ -1104,7 +1170,7 @@ func (_c *MockGithubClient_GetBranchNames_Call) Return(s string, s1 string, err
 	return _c
 }
 
-func (_c *MockGithubClient_GetBranchNames_Call) RunAndReturn(run func(ctx context.Context, owner string, repo string) (string, string, error)) *MockGithubClient_GetBranchNames_Call {
+func (_c *MockGithubClient_GetBranchNames_Call) RunAndReturn(run func(ctx context.Context, owner string, repo string, unquarantine bool) (string, string, error)) *MockGithubClient_GetBranchNames_Call {
 	_c.Call.Return(run)
 	return _c
 }
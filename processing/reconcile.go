@@ -0,0 +1,334 @@
+package processing
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/branch-out/golang"
+	"github.com/smartcontractkit/branch-out/jira"
+	"github.com/smartcontractkit/branch-out/trunk"
+)
+
+// Kinds of drift between the quarantines in code, Trunk.io's quarantined tests and the open Jira tickets,
+// found by Reconcile.
+const (
+	// DriftClosedTicket is a test quarantined in code for a ticket of the Jira project that isn't open anymore.
+	// Fixed by un-quarantining the test.
+	DriftClosedTicket = "closed ticket"
+	// DriftDeletedTest is an open ticket for a test that isn't in the repository anymore.
+	// Fixed by closing the ticket.
+	DriftDeletedTest = "deleted test"
+	// DriftNotQuarantined is a test quarantined by Trunk.io that isn't quarantined in code.
+	// Fixed by getting or creating its ticket and quarantining the test.
+	DriftNotQuarantined = "not quarantined"
+	// DriftNotInTrunk is a test quarantined in code that Trunk.io doesn't quarantine. It's only reported,
+	// as the test may have been quarantined by hand.
+	DriftNotInTrunk = "not quarantined by Trunk.io"
+)
+
+// OutcomeTicketClosed is the outcome of fixing a DriftDeletedTest.
+const OutcomeTicketClosed = "ticket closed"
+
+// closedTicketStatuses are the statuses of closed tickets, compared regardless of case.
+var closedTicketStatuses = []string{"Closed", "Done", "Resolved"}
+
+// Drift is a disagreement between the quarantines in code, Trunk.io and Jira about a test, found by Reconcile.
+type Drift struct {
+	Kind     string // What disagrees, see the Drift constants
+	Package  string
+	Test     string
+	Variant  string
+	Ticket   string // Ticket of the test, from the code or Jira, empty if it doesn't have one
+	Location string // File and line of the test's quarantine in code, empty if it isn't quarantined in code
+	Outcome  string // What fixing the drift did, see the Outcome constants, empty if it wasn't fixed
+	Err      error  // Why the drift failed to be fixed, if it did
+
+	testCase trunk.TestCase // Trunk.io's test case, for DriftNotQuarantined
+}
+
+// Reconcile compares three sources of quarantined tests: the tests quarantined in the code of the repository's
+// default branch, Trunk.io's quarantined tests, and the open flaky test tickets of the Jira project.
+// It returns their drift, see the Drift constants, sorted by kind, package and test.
+//
+// With fix, the drift is fixed: tests quarantined for closed tickets are un-quarantined in a single PR,
+// tests quarantined by Trunk.io are quarantined in another, with their tickets created if needed,
+// and the tickets of deleted tests are closed.
+func (w *WebhookProcessor) Reconcile(
+	ctx context.Context,
+	repoURL, orgURLSlug string,
+	fix bool,
+	options ...QuarantineOption,
+) ([]Drift, error) {
+	if err := w.verifyClients(); err != nil {
+		return nil, err
+	}
+	opts := &quarantineTestsOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	_, owner, repo, err := trunk.ParseRepoURL(repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse repo URL: %w", err)
+	}
+	l := w.logger.With().
+		Str("repo_url", repoURL).
+		Str("owner", owner).
+		Str("repo", repo).
+		Bool("fix", fix).
+		Logger()
+
+	_, repoPath, err := w.githubClient.GitCloneRepo(owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone repository: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(repoPath); err != nil {
+			l.Error().Err(err).Msg("Failed to remove temporary repository directory")
+		}
+	}()
+
+	tests, err := golang.FindTests(l, repoPath, golang.WithBuildFlags(opts.buildFlags))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tests in code: %w", err)
+	}
+	trunkTests, err := w.trunkClient.QuarantinedTests(repoURL, orgURLSlug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined tests from Trunk.io: %w", err)
+	}
+	issues, err := w.jiraClient.GetOpenFlakyTestIssues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open flaky test issues from Jira: %w", err)
+	}
+
+	closedTickets := w.closedTickets(l, tests.Quarantined, issues)
+	drifts := findDrift(tests, trunkTests, issues, closedTickets)
+	l.Info().
+		Int("code_quarantined", len(tests.Quarantined)).
+		Int("trunk_quarantined", len(trunkTests)).
+		Int("open_tickets", len(issues)).
+		Int("drifts", len(drifts)).
+		Msg("Reconciled quarantined tests")
+	if fix {
+		w.fixDrift(ctx, l, repoURL, drifts, options...)
+	}
+	return drifts, nil
+}
+
+// closedTickets returns the closed tickets of the Jira project that tests are quarantined for in code.
+// Open tickets may lack the flaky test label, so the status of every ticket that isn't among the open flaky test
+// issues is looked up. Tickets whose status fails to be looked up aren't considered closed.
+func (w *WebhookProcessor) closedTickets(
+	l zerolog.Logger,
+	quarantined golang.Inventory,
+	issues []jira.FlakyTestIssue,
+) map[string]bool {
+	projectKey := w.jiraClient.GetProjectKey()
+	openTickets := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		openTickets[issue.Key] = true
+	}
+
+	closedTickets := map[string]bool{}
+	for _, entry := range quarantined {
+		ticket := entry.Ticket
+		if !strings.HasPrefix(ticket, projectKey+"-") || openTickets[ticket] {
+			continue
+		}
+		if _, checked := closedTickets[ticket]; checked {
+			continue
+		}
+		status, err := w.jiraClient.GetIssueStatus(ticket)
+		if err != nil {
+			l.Warn().Err(err).Str("ticket", ticket).Msg("Failed to get ticket status, assuming it's open")
+			closedTickets[ticket] = false
+			continue
+		}
+		closedTickets[ticket] = slices.ContainsFunc(closedTicketStatuses, func(closed string) bool {
+			return strings.EqualFold(closed, status)
+		})
+	}
+	return closedTickets
+}
+
+// findDrift compares the tests of the repository with Trunk.io's quarantined tests and the open tickets of the
+// Jira project, given the closed tickets that tests are quarantined for, see closedTickets.
+func findDrift(
+	tests *golang.RepositoryTests,
+	trunkTests []trunk.TestCase,
+	issues []jira.FlakyTestIssue,
+	closedTickets map[string]bool,
+) []Drift {
+	var drifts []Drift
+
+	for _, entry := range tests.Quarantined {
+		drift := Drift{
+			Package:  entry.Package,
+			Test:     entry.Test,
+			Variant:  entry.Variant,
+			Ticket:   entry.Ticket,
+			Location: fmt.Sprintf("%s:%d", entry.File, entry.Line),
+		}
+		switch {
+		case closedTickets[entry.Ticket]:
+			drift.Kind = DriftClosedTicket
+		case !slices.ContainsFunc(trunkTests, func(testCase trunk.TestCase) bool {
+			_, covered := golang.Inventory{entry}.Covering(testCase.TestSuite, testCase.Name, testCase.Variant)
+			return covered
+		}):
+			drift.Kind = DriftNotInTrunk
+		default:
+			continue
+		}
+		drifts = append(drifts, drift)
+	}
+
+	for _, issue := range issues {
+		if issue.Package == "" || issue.Test == "" || !tests.Owns(issue.Package) ||
+			tests.Exists(issue.Package, issue.Test) {
+			continue
+		}
+		drifts = append(drifts, Drift{
+			Kind:    DriftDeletedTest,
+			Package: issue.Package,
+			Test:    issue.Test,
+			Ticket:  issue.Key,
+		})
+	}
+
+	for _, testCase := range trunkTests {
+		// Quarantining deleted tests would fail, their open tickets are reported instead
+		if !tests.Exists(testCase.TestSuite, testCase.Name) {
+			continue
+		}
+		if _, covered := tests.Quarantined.Covering(testCase.TestSuite, testCase.Name, testCase.Variant); covered {
+			continue
+		}
+		drifts = append(drifts, Drift{
+			Kind:     DriftNotQuarantined,
+			Package:  testCase.TestSuite,
+			Test:     testCase.Name,
+			Variant:  testCase.Variant,
+			Ticket:   openTicket(issues, testCase.TestSuite, testCase.Name),
+			testCase: testCase,
+		})
+	}
+
+	slices.SortStableFunc(drifts, func(a, b Drift) int {
+		return cmp.Or(
+			strings.Compare(a.Kind, b.Kind),
+			strings.Compare(a.Package, b.Package),
+			strings.Compare(a.Test, b.Test),
+		)
+	})
+	return drifts
+}
+
+// openTicket returns the key of the open ticket for the test, empty if it doesn't have one.
+func openTicket(issues []jira.FlakyTestIssue, pkg, testName string) string {
+	for _, issue := range issues {
+		if issue.Package == pkg && issue.Test == testName {
+			return issue.Key
+		}
+	}
+	return ""
+}
+
+// fixDrift un-quarantines the tests quarantined for closed tickets, quarantines the tests quarantined by Trunk.io,
+// and closes the tickets of deleted tests, setting the outcome of every drift it fixes.
+func (w *WebhookProcessor) fixDrift(
+	ctx context.Context,
+	l zerolog.Logger,
+	repoURL string,
+	drifts []Drift,
+	options ...QuarantineOption,
+) {
+	var (
+		unquarantineTargets, quarantineTargets []golang.QuarantineTarget
+		unquarantineIndexes, quarantineIndexes []int
+	)
+	for index := range drifts {
+		drift := &drifts[index]
+		switch drift.Kind {
+		case DriftClosedTicket:
+			test := golang.TestToQuarantine{Name: drift.Test}
+			unquarantineTargets = addTarget(unquarantineTargets, drift.Package, test)
+			unquarantineIndexes = append(unquarantineIndexes, index)
+		case DriftNotQuarantined:
+			testCase := drift.testCase
+			if testCase.Repository.HTMLURL == "" {
+				testCase.Repository.HTMLURL = repoURL
+			}
+			issue, err := w.createJiraIssueForFlakyTest(
+				l.With().Str("name", testCase.Name).Logger(),
+				quarantinedStatusChange(testCase),
+			)
+			if err != nil {
+				drift.Outcome, drift.Err = OutcomeFailed, err
+				continue
+			}
+			drift.Ticket = issue.Key
+			quarantineTargets = addTarget(quarantineTargets, drift.Package, golang.TestToQuarantine{
+				Name:       drift.Test,
+				JiraTicket: issue.Key,
+				Variant:    drift.Variant,
+			})
+			quarantineIndexes = append(quarantineIndexes, index)
+		case DriftDeletedTest:
+			comment := fmt.Sprintf("Closing: %s no longer exists in %s.", drift.Test, drift.Package)
+			if err := w.jiraClient.CloseIssue(drift.Ticket, comment); err != nil {
+				drift.Outcome, drift.Err = OutcomeFailed, fmt.Errorf("failed to close Jira ticket: %w", err)
+				continue
+			}
+			drift.Outcome = OutcomeTicketClosed
+		}
+	}
+
+	if len(unquarantineTargets) > 0 {
+		results, err := w.updateTests(ctx, l, repoURL, unquarantineTargets, true, options...)
+		setDriftOutcomes(drifts, unquarantineIndexes, results, true, err)
+	}
+	if len(quarantineTargets) > 0 {
+		results, err := w.updateTests(ctx, l, repoURL, quarantineTargets, false, options...)
+		setDriftOutcomes(drifts, quarantineIndexes, results, false, err)
+	}
+}
+
+// setDriftOutcomes sets the outcomes of the drifts at the indexes from the results of quarantining or
+// un-quarantining their tests, or the error that prevented it.
+func setDriftOutcomes(
+	drifts []Drift,
+	indexes []int,
+	results golang.QuarantineResults,
+	unquarantine bool,
+	err error,
+) {
+	for _, index := range indexes {
+		if err != nil {
+			drifts[index].Outcome, drifts[index].Err = OutcomeFailed, err
+			continue
+		}
+		drifts[index].Outcome = testOutcome(results, drifts[index].Package, drifts[index].Test, unquarantine)
+	}
+}
+
+// quarantinedStatusChange returns a status change for a test case that Trunk.io quarantines,
+// as if Trunk.io had sent a webhook for it.
+func quarantinedStatusChange(testCase trunk.TestCase) trunk.TestCaseStatusChange {
+	currentStatus := testCase.Status
+	if currentStatus.Value == "" {
+		currentStatus.Value = trunk.TestCaseStatusFlaky
+	}
+	return trunk.TestCaseStatusChange{
+		TestCase: testCase,
+		StatusChange: trunk.StatusChange{
+			CurrentStatus: currentStatus,
+		},
+	}
+}
@@ -0,0 +1,102 @@
+package processing
+
+import (
+	"errors"
+	"testing"
+
+	go_jira "github.com/andygrunwald/go-jira"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/smartcontractkit/branch-out/golang"
+	"github.com/smartcontractkit/branch-out/internal/testhelpers"
+	"github.com/smartcontractkit/branch-out/jira"
+	"github.com/smartcontractkit/branch-out/trunk"
+)
+
+func TestFindDrift(t *testing.T) {
+	t.Parallel()
+
+	tests := &golang.RepositoryTests{
+		Modules: []string{"github.com/test/repo"},
+		Tests: map[string][]string{
+			"github.com/test/repo/a": {"TestClosed", "TestOpen", "TestUnlabeled", "TestTrunkOnly", "TestCodeOnly"},
+		},
+		Quarantined: golang.Inventory{
+			{Package: "github.com/test/repo/a", Test: "TestClosed", File: "a/a_test.go", Line: 10, Ticket: "TEST-1"},
+			{Package: "github.com/test/repo/a", Test: "TestOpen", File: "a/a_test.go", Line: 20, Ticket: "TEST-2"},
+			{Package: "github.com/test/repo/a", Test: "TestUnlabeled", File: "a/a_test.go", Line: 25, Ticket: "TEST-7"},
+			{Package: "github.com/test/repo/a", Test: "TestCodeOnly", File: "a/a_test.go", Line: 30, Ticket: "OTHER-1"},
+		},
+	}
+	trunkTests := []trunk.TestCase{
+		{TestSuite: "github.com/test/repo/a", Name: "TestOpen"},
+		{TestSuite: "github.com/test/repo/a", Name: "TestClosed"},
+		{TestSuite: "github.com/test/repo/a", Name: "TestUnlabeled"},
+		{TestSuite: "github.com/test/repo/a", Name: "TestTrunkOnly"},
+		{TestSuite: "github.com/test/repo/a", Name: "TestDeleted"},
+	}
+	issues := []jira.FlakyTestIssue{
+		{Issue: &go_jira.Issue{Key: "TEST-2"}, Package: "github.com/test/repo/a", Test: "TestOpen"},
+		{Issue: &go_jira.Issue{Key: "TEST-3"}, Package: "github.com/test/repo/a", Test: "TestTrunkOnly"},
+		{Issue: &go_jira.Issue{Key: "TEST-4"}, Package: "github.com/test/repo/a", Test: "TestDeleted"},
+		{Issue: &go_jira.Issue{Key: "TEST-5"}, Package: "github.com/test/repo/deleted", Test: "TestA"},
+		{Issue: &go_jira.Issue{Key: "TEST-6"}, Package: "github.com/test/other", Test: "TestA"},
+	}
+
+	// TEST-7 is open without the flaky test label, so it isn't among the issues
+	drifts := findDrift(tests, trunkTests, issues, map[string]bool{"TEST-1": true, "TEST-7": false})
+	for index := range drifts {
+		drifts[index].testCase = trunk.TestCase{}
+	}
+	assert.Equal(t, []Drift{
+		{
+			Kind:     DriftClosedTicket,
+			Package:  "github.com/test/repo/a",
+			Test:     "TestClosed",
+			Ticket:   "TEST-1",
+			Location: "a/a_test.go:10",
+		},
+		{Kind: DriftDeletedTest, Package: "github.com/test/repo/a", Test: "TestDeleted", Ticket: "TEST-4"},
+		{Kind: DriftDeletedTest, Package: "github.com/test/repo/deleted", Test: "TestA", Ticket: "TEST-5"},
+		{Kind: DriftNotQuarantined, Package: "github.com/test/repo/a", Test: "TestTrunkOnly", Ticket: "TEST-3"},
+		{
+			Kind:     DriftNotInTrunk,
+			Package:  "github.com/test/repo/a",
+			Test:     "TestCodeOnly",
+			Ticket:   "OTHER-1",
+			Location: "a/a_test.go:30",
+		},
+	}, drifts)
+}
+
+func TestClosedTickets(t *testing.T) {
+	t.Parallel()
+
+	quarantined := golang.Inventory{
+		{Package: "github.com/test/repo/a", Test: "TestClosed", Ticket: "TEST-1"},
+		{Package: "github.com/test/repo/a", Test: "TestLabeled", Ticket: "TEST-2"},
+		{Package: "github.com/test/repo/a", Test: "TestUnlabeled", Ticket: "TEST-3"},
+		{Package: "github.com/test/repo/a", Test: "TestUnknown", Ticket: "TEST-4"},
+		{Package: "github.com/test/repo/a", Test: "TestOtherProject", Ticket: "OTHER-1"},
+		{Package: "github.com/test/repo/b", Test: "TestClosed", Ticket: "TEST-1"},
+	}
+	issues := []jira.FlakyTestIssue{
+		{Issue: &go_jira.Issue{Key: "TEST-2"}, Package: "github.com/test/repo/a", Test: "TestLabeled"},
+	}
+
+	jiraClient := NewMockJiraClient(t)
+	jiraClient.EXPECT().GetProjectKey().Return("TEST")
+	jiraClient.EXPECT().GetIssueStatus("TEST-1").Return("done", nil).Once()
+	jiraClient.EXPECT().GetIssueStatus("TEST-3").Return("In Progress", nil).Once()
+	jiraClient.EXPECT().GetIssueStatus("TEST-4").Return("", errors.New("jira is down")).Once()
+
+	webhookProcessor := NewWebhookProcessor(
+		testhelpers.Logger(t),
+		jiraClient,
+		NewMockTrunkClient(t),
+		NewMockGithubClient(t),
+		nil,
+	)
+	closedTickets := webhookProcessor.closedTickets(testhelpers.Logger(t), quarantined, issues)
+	assert.Equal(t, map[string]bool{"TEST-1": true, "TEST-3": false, "TEST-4": false}, closedTickets)
+}
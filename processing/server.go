@@ -39,6 +39,8 @@ type Server struct {
 
 	// Background worker for processing SQS messages
 	worker *Worker
+	// Background syncer of quarantined tests from Trunk.io, nil if syncing isn't configured
+	syncer *Syncer
 
 	// Telemetry
 	metrics *telemetry.Metrics
@@ -203,8 +205,25 @@ func NewServer(options ...Option) (*Server, error) {
 		opts.githubClient,
 		opts.metrics,
 		workerConfig,
+		WithQuarantineConfig(opts.config),
 	)
 
+	var syncer *Syncer
+	if repoURLs := opts.config.SyncRepoURLs(); len(repoURLs) > 0 && opts.config.SyncIntervalHours > 0 {
+		syncer = NewSyncer(
+			opts.logger,
+			opts.jiraClient,
+			opts.trunkClient,
+			opts.githubClient,
+			opts.metrics,
+			SyncConfig{
+				RepoURLs: repoURLs,
+				Interval: time.Duration(opts.config.SyncIntervalHours) * time.Hour,
+			},
+			WithQuarantineConfig(opts.config),
+		)
+	}
+
 	return &Server{
 		Port: opts.config.Port,
 
@@ -217,6 +236,7 @@ func NewServer(options ...Option) (*Server, error) {
 		githubClient: opts.githubClient,
 		awsClient:    opts.awsClient,
 		worker:       sqsWorker,
+		syncer:       syncer,
 		metrics:      opts.metrics,
 	}, nil
 }
@@ -279,6 +299,15 @@ func (s *Server) Start(ctx context.Context) error {
 		}
 	}
 
+	// Start syncing quarantined tests from Trunk.io on a schedule, if configured
+	if s.syncer != nil {
+		if err := s.syncer.Start(); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to start syncer")
+			s.err = fmt.Errorf("failed to start syncer: %w", err)
+			return s.err
+		}
+	}
+
 	// Listen for OS signals to shutdown the server
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -357,6 +386,11 @@ func (s *Server) shutdown() error {
 			// Continue with server shutdown even if worker stop fails
 		}
 	}
+	if s.syncer != nil {
+		if err := s.syncer.Stop(); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to stop syncer")
+		}
+	}
 
 	// Create a context with timeout for graceful shutdown
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -0,0 +1,182 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/branch-out/golang"
+	"github.com/smartcontractkit/branch-out/telemetry"
+)
+
+// Sync backfills the quarantines of a repository from Trunk.io's quarantined tests, e.g. when onboarding a repository
+// whose flaky tests Trunk.io already quarantined before sending us any webhook.
+// Every quarantined test gets a Jira ticket if it doesn't have an open one already, and the tests that aren't
+// quarantined in code yet are quarantined at once, in a single PR. Syncing again doesn't make a PR if nothing changed.
+// It returns the outcome of every test Trunk.io quarantines, along with an error if they couldn't be quarantined.
+func (w *WebhookProcessor) Sync(
+	ctx context.Context,
+	repoURL, orgURLSlug string,
+	options ...QuarantineOption,
+) ([]StatusChangeOutcome, error) {
+	if err := w.verifyClients(); err != nil {
+		return nil, err
+	}
+	l := w.logger.With().Str("repo_url", repoURL).Logger()
+
+	testCases, err := w.trunkClient.QuarantinedTests(repoURL, orgURLSlug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined tests from Trunk.io: %w", err)
+	}
+	l = l.With().Int("tests", len(testCases)).Logger()
+	l.Info().Msg("Syncing quarantined tests from Trunk.io")
+
+	var (
+		outcomes = make([]StatusChangeOutcome, len(testCases))
+		targets  []golang.QuarantineTarget
+		indexes  []int // Indexes of the tests that are quarantined
+	)
+	for index, testCase := range testCases {
+		if testCase.Repository.HTMLURL == "" {
+			testCase.Repository.HTMLURL = repoURL
+		}
+		statusChange := quarantinedStatusChange(testCase)
+		outcomes[index].StatusChange = statusChange
+
+		issue, err := w.createJiraIssueForFlakyTest(l.With().Str("name", testCase.Name).Logger(), statusChange)
+		if err != nil {
+			outcomes[index].Outcome, outcomes[index].Err = OutcomeFailed, err
+			continue
+		}
+		outcomes[index].JiraTicket = issue.Key
+		targets = addTarget(targets, testCase.TestSuite, golang.TestToQuarantine{
+			Name:       testCase.Name,
+			JiraTicket: issue.Key,
+			Variant:    testCase.Variant,
+		})
+		indexes = append(indexes, index)
+	}
+	if len(targets) == 0 {
+		return outcomes, nil
+	}
+
+	results, err := w.updateTests(ctx, l, repoURL, targets, false, options...)
+	setOutcomes(outcomes, indexes, results, false, err)
+	if err != nil {
+		l.Error().Err(err).Msg("Failed to quarantine tests synced from Trunk.io")
+		return outcomes, fmt.Errorf("failed to quarantine tests synced from Trunk.io: %w", err)
+	}
+
+	l.Info().
+		Int("quarantined", results.SuccessfulTestsCount()).
+		Int("already_quarantined", results.AlreadyQuarantinedTestsCount()).
+		Int("failed", results.FailedTestsCount()).
+		Msg("Synced quarantined tests from Trunk.io")
+	return outcomes, nil
+}
+
+// Syncer syncs the quarantines of repositories from Trunk.io on a schedule, see WebhookProcessor.Sync.
+type Syncer struct {
+	logger           zerolog.Logger
+	webhookProcessor WebhookProcessor
+
+	// Configuration
+	repoURLs []string
+	interval time.Duration
+
+	*background
+}
+
+// SyncConfig holds configuration for the syncer.
+type SyncConfig struct {
+	RepoURLs []string      // URLs of the repositories to sync
+	Interval time.Duration // How often to sync the repositories
+}
+
+// NewSyncer creates a new background syncer for the repositories of the config, whose webhook processor is configured
+// with the options.
+func NewSyncer(
+	logger zerolog.Logger,
+	jiraClient JiraClient,
+	trunkClient TrunkClient,
+	githubClient GithubClient,
+	metrics *telemetry.Metrics,
+	config SyncConfig,
+	options ...WebhookProcessorOption,
+) *Syncer {
+	webhookProcessor := NewWebhookProcessor(
+		logger,
+		jiraClient,
+		trunkClient,
+		githubClient,
+		metrics,
+		options...,
+	)
+
+	logger = logger.With().Str("component", "syncer").Logger()
+	return &Syncer{
+		logger:           logger,
+		webhookProcessor: *webhookProcessor,
+		repoURLs:         config.RepoURLs,
+		interval:         config.Interval,
+		background:       newBackground(logger, "syncer"),
+	}
+}
+
+// Start begins syncing the repositories, once right away and then every interval.
+func (s *Syncer) Start() error {
+	if s.interval <= 0 {
+		return fmt.Errorf("sync interval must be positive, got %s", s.interval)
+	}
+	if err := s.start(s.run); err != nil {
+		return err
+	}
+
+	s.logger.Info().
+		Strs("repo_urls", s.repoURLs).
+		Str("interval", s.interval.String()).
+		Msg("Started syncer")
+	return nil
+}
+
+// run is the main syncer loop.
+func (s *Syncer) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.syncAll()
+		select {
+		case <-s.ctx.Done():
+			s.logger.Debug().Msg("Syncer context cancelled, stopping")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncAll syncs every repository, one after the other.
+func (s *Syncer) syncAll() {
+	for _, repoURL := range s.repoURLs {
+		if s.ctx.Err() != nil {
+			return
+		}
+		l := s.logger.With().Str("repo_url", repoURL).Logger()
+		outcomes, err := s.webhookProcessor.Sync(s.ctx, repoURL, "")
+		if err != nil {
+			l.Error().Err(err).Msg("Failed to sync quarantined tests")
+			continue
+		}
+		for _, outcome := range outcomes {
+			if outcome.Err != nil {
+				l.Error().
+					Err(outcome.Err).
+					Str("package", outcome.StatusChange.TestCase.TestSuite).
+					Str("name", outcome.StatusChange.TestCase.Name).
+					Msg("Failed to sync quarantined test")
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	go_jira "github.com/andygrunwald/go-jira"
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/branch-out/golang"
+	"github.com/smartcontractkit/branch-out/internal/testhelpers"
+	"github.com/smartcontractkit/branch-out/jira"
+	"github.com/smartcontractkit/branch-out/trunk"
+)
+
+func TestSync_TicketFailures(t *testing.T) {
+	t.Parallel()
+
+	repoURL := "https://github.com/test/repo"
+	testCase := trunk.TestCase{ID: "test_trunk_id", TestSuite: "github.com/test/repo/pkg", Name: "TestFlaky"}
+
+	trunkClient := NewMockTrunkClient(t)
+	trunkClient.EXPECT().QuarantinedTests(repoURL, "").Return([]trunk.TestCase{testCase}, nil)
+	jiraClient := NewMockJiraClient(t)
+	jiraClient.EXPECT().GetProjectKey().Return("TEST")
+	jiraClient.EXPECT().GetOpenFlakyTestIssue(testCase.TestSuite, testCase.Name).Return(
+		jira.FlakyTestIssue{},
+		errors.New("jira is down"),
+	)
+	githubClient := NewMockGithubClient(t) // No PR is made without tests to quarantine
+
+	webhookProcessor := NewWebhookProcessor(testhelpers.Logger(t), jiraClient, trunkClient, githubClient, nil)
+	outcomes, err := webhookProcessor.Sync(context.Background(), repoURL, "")
+	require.NoError(t, err)
+	require.Len(t, outcomes, 1)
+	assert.Equal(t, OutcomeFailed, outcomes[0].Outcome)
+	require.ErrorContains(t, outcomes[0].Err, "jira is down")
+	assert.Equal(t, repoURL, outcomes[0].StatusChange.TestCase.Repository.HTMLURL)
+	assert.Equal(t, trunk.TestCaseStatusFlaky, outcomes[0].StatusChange.StatusChange.CurrentStatus.Value)
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	repoURL := "https://github.com/test/repo"
+	testCases := []trunk.TestCase{
+		{ID: "existing_trunk_id", TestSuite: "github.com/test/repo/pkg", Name: "TestExisting"},
+		{ID: "new_trunk_id", TestSuite: "github.com/test/repo/other", Name: "TestNew"},
+	}
+	repoPath := t.TempDir()
+	repository, err := git.PlainInit(repoPath, false)
+	require.NoError(t, err)
+
+	trunkClient := NewMockTrunkClient(t)
+	trunkClient.EXPECT().QuarantinedTests(repoURL, "").Return(testCases, nil)
+	trunkClient.EXPECT().LinkTicketToTestCase("existing_trunk_id", "TEST-1", repoURL).Return(nil)
+	trunkClient.EXPECT().LinkTicketToTestCase("new_trunk_id", "TEST-2", repoURL).Return(nil)
+	jiraClient := NewMockJiraClient(t)
+	jiraClient.EXPECT().GetProjectKey().Return("TEST")
+	jiraClient.EXPECT().GetOpenFlakyTestIssue("github.com/test/repo/pkg", "TestExisting").Return(
+		jira.FlakyTestIssue{Issue: &go_jira.Issue{Key: "TEST-1"}},
+		nil,
+	)
+	jiraClient.EXPECT().GetOpenFlakyTestIssue("github.com/test/repo/other", "TestNew").Return(
+		jira.FlakyTestIssue{},
+		jira.ErrNoOpenFlakyTestIssueFound,
+	)
+	jiraClient.EXPECT().CreateFlakyTestIssue(mock.Anything).Return(
+		jira.FlakyTestIssue{Issue: &go_jira.Issue{Key: "TEST-2"}},
+		nil,
+	)
+
+	// Every test is quarantined in a single commit and PR
+	quarantinesAll := mock.MatchedBy(func(results *golang.QuarantineResults) bool {
+		return results.SuccessfulTestsCount() == len(testCases)
+	})
+	githubClient := NewMockGithubClient(t)
+	githubClient.EXPECT().GetBranchNames(mock.Anything, "test", "repo", false).Return("main", "branch-out/quarantine", nil)
+	githubClient.EXPECT().GitCloneRepo("test", "repo").Return(repository, repoPath, nil)
+	githubClient.EXPECT().GetOrCreateRemoteBranch(mock.Anything, "test", "repo", "branch-out/quarantine").
+		Return("head_sha", nil).Once()
+	githubClient.EXPECT().
+		GenerateCommitAndPush(mock.Anything, "test", "repo", "branch-out/quarantine", "head_sha", quarantinesAll).
+		Return("commit_sha", nil).Once()
+	githubClient.EXPECT().
+		CreateOrUpdatePullRequest(
+			mock.Anything,
+			mock.Anything,
+			"test",
+			"repo",
+			"branch-out/quarantine",
+			"main",
+			quarantinesAll,
+		).
+		Return("https://github.com/test/repo/pull/1", nil).Once()
+
+	webhookProcessor := NewWebhookProcessor(testhelpers.Logger(t), jiraClient, trunkClient, githubClient, nil)
+	outcomes, err := webhookProcessor.Sync(context.Background(), repoURL, "", WithManifest())
+	require.NoError(t, err)
+	require.Len(t, outcomes, 2)
+	for index, expectedTicket := range []string{"TEST-1", "TEST-2"} {
+		assert.Equal(t, OutcomeQuarantined, outcomes[index].Outcome, outcomes[index].StatusChange.TestCase.Name)
+		assert.Equal(t, expectedTicket, outcomes[index].JiraTicket)
+		require.NoError(t, outcomes[index].Err)
+	}
+}
+
+func TestSync_QuarantineFailure(t *testing.T) {
+	t.Parallel()
+
+	repoURL := "https://github.com/test/repo"
+	testCase := trunk.TestCase{ID: "test_trunk_id", TestSuite: "github.com/test/repo/pkg", Name: "TestFlaky"}
+
+	trunkClient := NewMockTrunkClient(t)
+	trunkClient.EXPECT().QuarantinedTests(repoURL, "").Return([]trunk.TestCase{testCase}, nil)
+	trunkClient.EXPECT().LinkTicketToTestCase(testCase.ID, "TEST-1", repoURL).Return(nil)
+	jiraClient := NewMockJiraClient(t)
+	jiraClient.EXPECT().GetProjectKey().Return("TEST")
+	jiraClient.EXPECT().GetOpenFlakyTestIssue(testCase.TestSuite, testCase.Name).Return(
+		jira.FlakyTestIssue{Issue: &go_jira.Issue{Key: "TEST-1"}},
+		nil,
+	)
+	githubClient := NewMockGithubClient(t)
+	githubClient.EXPECT().GetBranchNames(mock.Anything, "test", "repo", false).
+		Return("", "", errors.New("github is down"))
+
+	webhookProcessor := NewWebhookProcessor(testhelpers.Logger(t), jiraClient, trunkClient, githubClient, nil)
+	outcomes, err := webhookProcessor.Sync(context.Background(), repoURL, "")
+	require.ErrorContains(t, err, "github is down")
+	require.Len(t, outcomes, 1)
+	assert.Equal(t, OutcomeFailed, outcomes[0].Outcome)
+}
+
+func TestSync_TrunkFailure(t *testing.T) {
+	t.Parallel()
+
+	trunkClient := NewMockTrunkClient(t)
+	trunkClient.EXPECT().QuarantinedTests("https://github.com/test/repo", "test").Return(nil, errors.New("trunk is down"))
+
+	webhookProcessor := NewWebhookProcessor(
+		testhelpers.Logger(t),
+		NewMockJiraClient(t),
+		trunkClient,
+		NewMockGithubClient(t),
+		nil,
+	)
+	_, err := webhookProcessor.Sync(context.Background(), "https://github.com/test/repo", "test")
+	require.ErrorContains(t, err, "trunk is down")
+}
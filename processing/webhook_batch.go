@@ -0,0 +1,227 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/branch-out/golang"
+	"github.com/smartcontractkit/branch-out/trunk"
+)
+
+// Outcomes of the test status changes processed by ProcessStatusChanges.
+const (
+	OutcomeQuarantined        = "quarantined"
+	OutcomeAlreadyQuarantined = "already quarantined"
+	OutcomeUnquarantined      = "un-quarantined"
+	OutcomeNotQuarantined     = "not quarantined"
+	OutcomeNotFound           = "not found"
+	OutcomeFailed             = "failed"
+)
+
+// StatusChangeOutcome is the outcome of one of the test status changes processed by ProcessStatusChanges.
+type StatusChangeOutcome struct {
+	StatusChange trunk.TestCaseStatusChange
+	JiraTicket   string // Ticket of the flaky test, empty for healthy tests
+	Outcome      string // What happened to the test, see the Outcome constants
+	Err          error  // Why the status change failed to process, if it did
+}
+
+// ProcessStatusChanges processes test status changes as if Trunk.io had sent a webhook for each of them,
+// without any of the clients' metrics.
+// See WebhookProcessor.ProcessStatusChanges.
+func ProcessStatusChanges(
+	ctx context.Context,
+	logger zerolog.Logger,
+	jiraClient JiraClient,
+	trunkClient TrunkClient,
+	githubClient GithubClient,
+	statusChanges []trunk.TestCaseStatusChange,
+	options ...WebhookProcessorOption,
+) ([]StatusChangeOutcome, error) {
+	webhookProcessor := NewWebhookProcessor(logger, jiraClient, trunkClient, githubClient, nil, options...)
+	return webhookProcessor.ProcessStatusChanges(ctx, statusChanges)
+}
+
+// statusChangeGroup is the status changes of a single repository and status, see ProcessStatusChanges.
+type statusChangeGroup struct {
+	repoURL string
+	status  string
+	indexes []int // Indexes of the group's status changes
+}
+
+// ProcessStatusChanges processes a batch of test status changes, e.g. to replay the webhooks missed during an outage.
+// Status changes are grouped by repository and status, and each group is quarantined or un-quarantined at once,
+// in a single PR, rather than in one PR per test.
+// It returns the outcome of every status change, in their order.
+func (w *WebhookProcessor) ProcessStatusChanges(
+	ctx context.Context,
+	statusChanges []trunk.TestCaseStatusChange,
+) ([]StatusChangeOutcome, error) {
+	if err := w.verifyClients(); err != nil {
+		return nil, err
+	}
+
+	var (
+		outcomes = make([]StatusChangeOutcome, len(statusChanges))
+		groups   []*statusChangeGroup
+	)
+	for index, statusChange := range statusChanges {
+		outcomes[index].StatusChange = statusChange
+		repoURL, status := statusChange.TestCase.Repository.HTMLURL, statusChange.StatusChange.CurrentStatus.Value
+		groupIndex := slices.IndexFunc(groups, func(group *statusChangeGroup) bool {
+			return group.repoURL == repoURL && group.status == status
+		})
+		if groupIndex < 0 {
+			groupIndex = len(groups)
+			groups = append(groups, &statusChangeGroup{repoURL: repoURL, status: status})
+		}
+		groups[groupIndex].indexes = append(groups[groupIndex].indexes, index)
+	}
+
+	for _, group := range groups {
+		l := w.logger.With().
+			Str("repo_url", group.repoURL).
+			Str("current_status", group.status).
+			Int("tests", len(group.indexes)).
+			Logger()
+		l.Info().Msg("Processing batch of test case status changes")
+
+		switch group.status {
+		case trunk.TestCaseStatusFlaky, trunk.TestCaseStatusBroken:
+			w.quarantineGroup(ctx, l, group, outcomes)
+		case trunk.TestCaseStatusHealthy:
+			w.unquarantineGroup(ctx, l, group, outcomes)
+		default:
+			for _, index := range group.indexes {
+				outcomes[index].Outcome = OutcomeFailed
+				outcomes[index].Err = fmt.Errorf("unknown test status %q", group.status)
+			}
+		}
+	}
+	return outcomes, nil
+}
+
+// quarantineGroup gets or creates the Jira tickets of a group of flaky or broken tests,
+// and quarantines the tests with a single PR.
+func (w *WebhookProcessor) quarantineGroup(
+	ctx context.Context,
+	l zerolog.Logger,
+	group *statusChangeGroup,
+	outcomes []StatusChangeOutcome,
+) {
+	var (
+		targets []golang.QuarantineTarget
+		indexes []int // Indexes of the status changes that are quarantined
+	)
+	for _, index := range group.indexes {
+		statusChange := outcomes[index].StatusChange
+		testCase := statusChange.TestCase
+		w.metrics.IncFlakyTestDetected(ctx, testCase.Name, testCase.TestSuite)
+
+		issue, err := w.flakyTestIssue(l.With().Str("name", testCase.Name).Logger(), statusChange)
+		if err != nil {
+			outcomes[index].Outcome, outcomes[index].Err = OutcomeFailed, err
+			continue
+		}
+		outcomes[index].JiraTicket = issue.Key
+		targets = addTarget(targets, testCase.TestSuite, golang.TestToQuarantine{
+			Name:       testCase.Name,
+			JiraTicket: issue.Key,
+			Variant:    testCase.Variant,
+		})
+		indexes = append(indexes, index)
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	results, err := w.updateTests(ctx, l, group.repoURL, targets, false)
+	setOutcomes(outcomes, indexes, results, false, err)
+}
+
+// unquarantineGroup un-quarantines a group of healthy tests with a single PR, and closes their Jira tickets.
+func (w *WebhookProcessor) unquarantineGroup(
+	ctx context.Context,
+	l zerolog.Logger,
+	group *statusChangeGroup,
+	outcomes []StatusChangeOutcome,
+) {
+	var targets []golang.QuarantineTarget
+	for _, index := range group.indexes {
+		testCase := outcomes[index].StatusChange.TestCase
+		targets = addTarget(targets, testCase.TestSuite, golang.TestToQuarantine{Name: testCase.Name})
+	}
+
+	results, err := w.updateTests(ctx, l, group.repoURL, targets, true)
+	setOutcomes(outcomes, group.indexes, results, true, err)
+	// Close the tickets even if the tests failed to be un-quarantined, the tests are healthy either way
+	for _, index := range group.indexes {
+		statusChange := outcomes[index].StatusChange
+		if statusChange.StatusChange.PreviousStatus == trunk.TestCaseStatusFlaky {
+			w.metrics.IncTestRecovered(ctx)
+		}
+		testLogger := l.With().Str("name", statusChange.TestCase.Name).Logger()
+		if err := w.closeFlakyTestIssue(testLogger, statusChange); err != nil {
+			outcomes[index].Outcome = OutcomeFailed
+			outcomes[index].Err = errors.Join(outcomes[index].Err, err)
+		}
+	}
+}
+
+// addTarget adds the test to the target of its package, keeping the targets in the order their packages came in.
+func addTarget(targets []golang.QuarantineTarget, pkg string, test golang.TestToQuarantine) []golang.QuarantineTarget {
+	index := slices.IndexFunc(targets, func(target golang.QuarantineTarget) bool {
+		return target.Package == pkg
+	})
+	if index < 0 {
+		return append(targets, golang.QuarantineTarget{Package: pkg, Tests: []golang.TestToQuarantine{test}})
+	}
+	targets[index].Tests = append(targets[index].Tests, test)
+	return targets
+}
+
+// setOutcomes sets the outcomes of the status changes at the indexes from the results of quarantining or
+// un-quarantining their tests, or the error that prevented it.
+func setOutcomes(
+	outcomes []StatusChangeOutcome,
+	indexes []int,
+	results golang.QuarantineResults,
+	unquarantine bool,
+	err error,
+) {
+	for _, index := range indexes {
+		if err != nil {
+			outcomes[index].Outcome, outcomes[index].Err = OutcomeFailed, err
+			continue
+		}
+		testCase := outcomes[index].StatusChange.TestCase
+		outcomes[index].Outcome = testOutcome(results, testCase.TestSuite, testCase.Name, unquarantine)
+	}
+}
+
+// testOutcome returns what happened to a test, according to the results of quarantining or un-quarantining it.
+func testOutcome(results golang.QuarantineResults, pkg, testName string, unquarantine bool) string {
+	result, _ := results.Get(pkg)
+	for _, file := range result.Successes {
+		for _, test := range file.Tests {
+			switch {
+			case test.Name != testName:
+				continue
+			case unquarantine:
+				return OutcomeUnquarantined
+			case test.AlreadyQuarantined:
+				return OutcomeAlreadyQuarantined
+			default:
+				return OutcomeQuarantined
+			}
+		}
+	}
+	if unquarantine {
+		return OutcomeNotQuarantined
+	}
+	return OutcomeNotFound
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/rs/zerolog"
 
+	"github.com/smartcontractkit/branch-out/config"
 	"github.com/smartcontractkit/branch-out/golang"
 	"github.com/smartcontractkit/branch-out/jira"
 	"github.com/smartcontractkit/branch-out/telemetry"
@@ -22,6 +23,29 @@ type WebhookProcessor struct {
 	trunkClient  TrunkClient
 	githubClient GithubClient
 	metrics      *telemetry.Metrics
+
+	quarantineExpiry time.Duration // How long quarantines last before they expire, 0 for quarantines that never expire
+	// Version of the quarantine module that go.mod files are updated to require, empty for the default
+	quarantineModuleVersion string
+	// Generator of the code that quarantines tests, see golang.ParseGenerator, empty for the default
+	quarantineGenerator string
+}
+
+// WebhookProcessorOption configures a WebhookProcessor.
+type WebhookProcessorOption func(*WebhookProcessor)
+
+// WithQuarantineConfig sets how the processor quarantines tests from the config: how long quarantines last,
+// see config.Config.QuarantineExpiryDays, the version of the quarantine module that go.mod files are updated
+// to require, see config.Config.QuarantineModuleVersion, and the code that quarantines tests,
+// see config.Config.QuarantineGenerator.
+// Without it, quarantines never expire, go.mod files require the default version of the quarantine module,
+// and tests are quarantined with quarantine.Flaky.
+func WithQuarantineConfig(cfg config.Config) WebhookProcessorOption {
+	return func(w *WebhookProcessor) {
+		w.quarantineExpiry = time.Duration(cfg.QuarantineExpiryDays) * 24 * time.Hour
+		w.quarantineModuleVersion = cfg.QuarantineModuleVersion
+		w.quarantineGenerator = cfg.QuarantineGenerator
+	}
 }
 
 // NewWebhookProcessor creates a new WebhookProcessor instance with the provided clients and configuration.
@@ -31,14 +55,19 @@ func NewWebhookProcessor(
 	trunkClient TrunkClient,
 	githubClient GithubClient,
 	metrics *telemetry.Metrics,
+	options ...WebhookProcessorOption,
 ) *WebhookProcessor {
-	return &WebhookProcessor{
+	w := &WebhookProcessor{
 		logger:       logger.With().Str("component", "webhook_processor").Logger(),
 		jiraClient:   jiraClient,
 		trunkClient:  trunkClient,
 		githubClient: githubClient,
 		metrics:      metrics,
 	}
+	for _, opt := range options {
+		opt(w)
+	}
+	return w
 }
 
 // ProcessWebhookPayload processes a webhook payload that came from SQS.
@@ -120,23 +149,9 @@ func (w *WebhookProcessor) handleFlakyTest(l zerolog.Logger, statusChange trunk.
 	// Record flaky test detection
 	w.metrics.IncFlakyTestDetected(context.Background(), testCase.Name, testCase.TestSuite)
 
-	// Create a Jira ticket for the flaky test
-	issue, err := w.createJiraIssueForFlakyTest(l, statusChange)
+	issue, err := w.flakyTestIssue(l, statusChange)
 	if err != nil {
-		return fmt.Errorf("failed to create Jira ticket: %w", err)
-	}
-
-	// Add a comment with the current status details (for both new and existing tickets)
-	err = w.jiraClient.AddCommentToFlakyTestIssue(issue, statusChange)
-	if err != nil {
-		l.Warn().
-			Err(err).
-			Str("jira_issue_key", issue.Key).
-			Msg("Failed to add comment to Jira ticket (non-blocking)")
-	} else {
-		l.Debug().
-			Str("jira_issue_key", issue.Key).
-			Msg("Successfully added status comment to Jira ticket")
+		return err
 	}
 
 	// Quarantine the test in GitHub
@@ -147,7 +162,9 @@ func (w *WebhookProcessor) handleFlakyTest(l zerolog.Logger, statusChange trunk.
 		[]golang.QuarantineTarget{
 			{
 				Package: testCase.TestSuite,
-				Tests:   []golang.TestToQuarantine{{Name: testCase.Name, JiraTicket: issue.Key}},
+				Tests: []golang.TestToQuarantine{
+					{Name: testCase.Name, JiraTicket: issue.Key, Variant: testCase.Variant},
+				},
 			},
 		},
 	)
@@ -161,6 +178,32 @@ func (w *WebhookProcessor) handleFlakyTest(l zerolog.Logger, statusChange trunk.
 	return nil
 }
 
+// flakyTestIssue gets or creates the Jira ticket for a flaky test, and comments the current status details on it.
+func (w *WebhookProcessor) flakyTestIssue(
+	l zerolog.Logger,
+	statusChange trunk.TestCaseStatusChange,
+) (jira.FlakyTestIssue, error) {
+	// Create a Jira ticket for the flaky test
+	issue, err := w.createJiraIssueForFlakyTest(l, statusChange)
+	if err != nil {
+		return jira.FlakyTestIssue{}, fmt.Errorf("failed to create Jira ticket: %w", err)
+	}
+
+	// Add a comment with the current status details (for both new and existing tickets)
+	err = w.jiraClient.AddCommentToFlakyTestIssue(issue, statusChange)
+	if err != nil {
+		l.Warn().
+			Err(err).
+			Str("jira_issue_key", issue.Key).
+			Msg("Failed to add comment to Jira ticket (non-blocking)")
+	} else {
+		l.Debug().
+			Str("jira_issue_key", issue.Key).
+			Msg("Successfully added status comment to Jira ticket")
+	}
+	return issue, nil
+}
+
 // handleBrokenTest handles the case where a test is marked as broken.
 func (w *WebhookProcessor) handleBrokenTest(l zerolog.Logger, statusChange trunk.TestCaseStatusChange) error {
 	return w.handleFlakyTest(l, statusChange)
@@ -176,6 +219,30 @@ func (w *WebhookProcessor) handleHealthyTest(l zerolog.Logger, statusChange trun
 		l.Info().Msg("Test recovered from flaky status")
 	}
 
+	// Un-quarantine the test in GitHub so that it runs again
+	err := w.UnquarantineTests(
+		context.Background(),
+		l,
+		testCase.Repository.HTMLURL,
+		[]golang.QuarantineTarget{
+			{
+				Package: testCase.TestSuite,
+				Tests:   []golang.TestToQuarantine{{Name: testCase.Name}},
+			},
+		},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed to un-quarantine test: %w", err)
+	}
+
+	// Close the ticket even if the test failed to be un-quarantined, the test is healthy either way
+	return errors.Join(err, w.closeFlakyTestIssue(l, statusChange))
+}
+
+// closeFlakyTestIssue closes the open Jira ticket of a test that is healthy again, if it has one.
+func (w *WebhookProcessor) closeFlakyTestIssue(l zerolog.Logger, statusChange trunk.TestCaseStatusChange) error {
+	testCase := statusChange.TestCase
+
 	// Look for an existing open ticket for this test
 	issue, err := w.jiraClient.GetOpenFlakyTestIssue(testCase.TestSuite, testCase.Name)
 	if errors.Is(err, jira.ErrNoOpenFlakyTestIssueFound) {
@@ -0,0 +1,107 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	go_jira "github.com/andygrunwald/go-jira"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/branch-out/golang"
+	"github.com/smartcontractkit/branch-out/internal/testhelpers"
+	"github.com/smartcontractkit/branch-out/jira"
+	"github.com/smartcontractkit/branch-out/trunk"
+)
+
+func TestHandleHealthyTest_UnquarantineFailure(t *testing.T) {
+	t.Parallel()
+
+	statusChange := trunk.TestCaseStatusChange{
+		TestCase: trunk.TestCase{
+			Name:       "TestHealthy",
+			TestSuite:  "github.com/test/repo/pkg",
+			Repository: trunk.Repository{HTMLURL: "https://github.com/test/repo"},
+		},
+	}
+	issue := jira.FlakyTestIssue{Issue: &go_jira.Issue{Key: "TEST-1"}}
+
+	jiraClient := NewMockJiraClient(t)
+	jiraClient.EXPECT().GetOpenFlakyTestIssue(statusChange.TestCase.TestSuite, statusChange.TestCase.Name).
+		Return(issue, nil)
+	jiraClient.EXPECT().CloseIssueWithHealthyComment("TEST-1", statusChange).Return(nil)
+	githubClient := NewMockGithubClient(t)
+	githubClient.EXPECT().GetBranchNames(context.Background(), "test", "repo", true).
+		Return("", "", errors.New("github is down"))
+
+	webhookProcessor := NewWebhookProcessor(
+		testhelpers.Logger(t),
+		jiraClient,
+		NewMockTrunkClient(t),
+		githubClient,
+		nil,
+	)
+	err := webhookProcessor.handleHealthyTest(testhelpers.Logger(t), statusChange)
+	require.ErrorContains(t, err, "github is down")
+}
+
+func TestProcessStatusChanges_UnquarantineFailure(t *testing.T) {
+	t.Parallel()
+
+	statusChange := trunk.TestCaseStatusChange{
+		TestCase: trunk.TestCase{
+			Name:       "TestHealthy",
+			TestSuite:  "github.com/test/repo/pkg",
+			Repository: trunk.Repository{HTMLURL: "https://github.com/test/repo"},
+		},
+		StatusChange: trunk.StatusChange{CurrentStatus: trunk.Status{Value: trunk.TestCaseStatusHealthy}},
+	}
+
+	jiraClient := NewMockJiraClient(t)
+	jiraClient.EXPECT().GetOpenFlakyTestIssue(statusChange.TestCase.TestSuite, statusChange.TestCase.Name).
+		Return(jira.FlakyTestIssue{}, errors.New("jira is down"))
+	githubClient := NewMockGithubClient(t)
+	githubClient.EXPECT().GetBranchNames(context.Background(), "test", "repo", true).
+		Return("", "", errors.New("github is down"))
+
+	outcomes, err := ProcessStatusChanges(
+		context.Background(),
+		testhelpers.Logger(t),
+		jiraClient,
+		NewMockTrunkClient(t),
+		githubClient,
+		[]trunk.TestCaseStatusChange{statusChange},
+	)
+	require.NoError(t, err)
+	require.Len(t, outcomes, 1)
+	assert.Equal(t, OutcomeFailed, outcomes[0].Outcome)
+	require.ErrorContains(t, outcomes[0].Err, "github is down")
+	require.ErrorContains(t, outcomes[0].Err, "jira is down")
+}
+
+func TestTestOutcome(t *testing.T) {
+	t.Parallel()
+
+	results := golang.QuarantineResults{
+		"github.com/test/repo/pkg": {
+			Package: "github.com/test/repo/pkg",
+			Successes: []golang.QuarantinedFile{{
+				Package: "github.com/test/repo/pkg",
+				File:    "pkg/pkg_test.go",
+				Tests: []golang.QuarantinedTest{
+					{Name: "TestNew", JiraTicket: "JIRA-1"},
+					{Name: "TestOld", JiraTicket: "JIRA-2", AlreadyQuarantined: true},
+				},
+			}},
+		},
+	}
+
+	assert.Equal(t, OutcomeQuarantined, testOutcome(results, "github.com/test/repo/pkg", "TestNew", false))
+	assert.Equal(t, OutcomeAlreadyQuarantined, testOutcome(results, "github.com/test/repo/pkg", "TestOld", false))
+	assert.Equal(t, OutcomeQuarantined, testOutcome(results, "github.com/test/repo/pkg_test", "TestNew", false),
+		"external test packages should get the outcome of the package they test")
+	assert.Equal(t, OutcomeUnquarantined, testOutcome(results, "github.com/test/repo/pkg_test", "TestNew", true))
+	assert.Equal(t, OutcomeNotFound, testOutcome(results, "github.com/test/repo/other", "TestNew", false))
+	assert.Equal(t, OutcomeNotQuarantined, testOutcome(results, "github.com/test/repo/pkg", "TestMissing", true))
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/rs/zerolog"
 
 	"github.com/smartcontractkit/branch-out/golang"
@@ -15,6 +16,7 @@ import (
 // QuarantineTestsOptions describes the options for the QuarantineTests function.
 type quarantineTestsOptions struct {
 	buildFlags []string // Any build flags to pass to the go command (e.g. ["-tags", "integration"])
+	manifest   bool     // Quarantine tests in the repository's quarantine manifest rather than in their source
 }
 
 // QuarantineOption is a function that can be used to configure the QuarantineTests function.
@@ -27,6 +29,15 @@ func WithBuildFlags(buildFlags []string) QuarantineOption {
 	}
 }
 
+// WithManifest quarantines tests by listing them in the repository's quarantine manifest, rather than by editing
+// their source, see golang.QuarantineManifest.
+// Repositories that have a manifest checked in are always handled this way.
+func WithManifest() QuarantineOption {
+	return func(options *quarantineTestsOptions) {
+		options.manifest = true
+	}
+}
+
 // QuarantineTests quarantines multiple Go tests by adding t.Skip() to the test functions and making a PR to the default branch.
 func (w *WebhookProcessor) QuarantineTests(
 	ctx context.Context,
@@ -35,6 +46,35 @@ func (w *WebhookProcessor) QuarantineTests(
 	targets []golang.QuarantineTarget,
 	options ...QuarantineOption,
 ) error {
+	_, err := w.updateTests(ctx, l, repoURL, targets, false, options...)
+	return err
+}
+
+// UnquarantineTests removes the quarantine from multiple Go tests and makes a PR to the default branch.
+// If none of the tests are quarantined in the code, no PR is made.
+func (w *WebhookProcessor) UnquarantineTests(
+	ctx context.Context,
+	l zerolog.Logger,
+	repoURL string,
+	targets []golang.QuarantineTarget,
+	options ...QuarantineOption,
+) error {
+	_, err := w.updateTests(ctx, l, repoURL, targets, true, options...)
+	return err
+}
+
+// updateTests clones the repository, quarantines or un-quarantines the targeted tests, and makes a PR with the changes.
+// New quarantines are generated, expire and require the quarantine module as configured with WithQuarantineConfig,
+// other than in the repository's quarantine manifest.
+// It returns the results of quarantining or un-quarantining the tests, even if no PR was needed.
+func (w *WebhookProcessor) updateTests(
+	ctx context.Context,
+	l zerolog.Logger,
+	repoURL string,
+	targets []golang.QuarantineTarget,
+	unquarantine bool,
+	options ...QuarantineOption,
+) (golang.QuarantineResults, error) {
 	opts := &quarantineTestsOptions{}
 	for _, opt := range options {
 		opt(opts)
@@ -42,11 +82,20 @@ func (w *WebhookProcessor) QuarantineTests(
 
 	host, owner, repo, err := trunk.ParseRepoURL(repoURL)
 	if err != nil {
-		return fmt.Errorf("failed to parse repo URL: %w", err)
+		return nil, fmt.Errorf("failed to parse repo URL: %w", err)
+	}
+	generator, err := golang.ParseGenerator(w.quarantineGenerator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse quarantine generator: %w", err)
 	}
 
 	start := time.Now()
-	l = l.With().Str("host", host).Str("owner", owner).Str("repo", repo).Logger()
+	l = l.With().
+		Str("host", host).
+		Str("owner", owner).
+		Str("repo", repo).
+		Bool("unquarantine", unquarantine).
+		Logger()
 
 	// Record quarantine operation start
 	var packageNames []string
@@ -56,11 +105,11 @@ func (w *WebhookProcessor) QuarantineTests(
 
 	// 1. Get branch names
 	apiStart := time.Now()
-	defaultBranch, prBranch, err := w.githubClient.GetBranchNames(ctx, owner, repo)
+	defaultBranch, prBranch, err := w.githubClient.GetBranchNames(ctx, owner, repo, unquarantine)
 	if err != nil {
 		w.metrics.RecordGitHubAPILatency(ctx, "get_default_branch", time.Since(apiStart))
 		l.Error().Err(err).Msg("Failed to get default and/or PR branch names")
-		return fmt.Errorf("failed to get default and/or PR branch names: %w", err)
+		return nil, fmt.Errorf("failed to get default and/or PR branch names: %w", err)
 	}
 	w.metrics.RecordGitHubAPILatency(ctx, "get_default_branch", time.Since(apiStart))
 	l.Debug().Str("default_branch", defaultBranch).Str("pr_branch", prBranch).Msg("Got branches")
@@ -69,7 +118,7 @@ func (w *WebhookProcessor) QuarantineTests(
 	// 2. Clone the repository to a temporary directory
 	repository, repoPath, err := w.githubClient.GitCloneRepo(owner, repo)
 	if err != nil {
-		return fmt.Errorf("failed to clone repository: %w", err)
+		return nil, fmt.Errorf("failed to clone repository: %w", err)
 	}
 	defer func() {
 		if err := os.RemoveAll(repoPath); err != nil {
@@ -79,31 +128,85 @@ func (w *WebhookProcessor) QuarantineTests(
 	l = l.With().Str("repo_path", repoPath).Logger()
 	l.Debug().Msg("Cloned repository")
 
-	// 3. Get or create the PR branch
-	branchHeadSHA, err := w.githubClient.GetOrCreateRemoteBranch(ctx, owner, repo, prBranch)
-	if err != nil {
-		l.Error().Err(err).Msg("Failed to get/create branch")
-		return fmt.Errorf("failed to get/create branch: %w", err)
+	// 3. Check out the PR branch if it already exists, for the changes to build on the ones it already has.
+	// New branches are only created once there are changes to commit to them.
+	_, err = repository.Reference(plumbing.NewRemoteReferenceName("origin", prBranch), true)
+	prBranchExists := err == nil
+	if prBranchExists {
+		err = w.githubClient.GitCheckoutBranch(repository, prBranch)
+		if err != nil {
+			l.Error().Err(err).Msg("Failed to checkout branch")
+			return nil, fmt.Errorf("failed to checkout branch: %w", err)
+		}
 	}
-
-	// 4. Checkout the branch locally
-	err = w.githubClient.GitCheckoutBranch(repository, prBranch)
-	if err != nil {
-		l.Error().Err(err).Msg("Failed to checkout branch")
-		return fmt.Errorf("failed to checkout branch: %w", err)
+	l = l.With().Bool("pr_branch_exists", prBranchExists).Logger()
+
+	// 4. Quarantine or un-quarantine tests in the local repository, in the manifest if the repository opted in to it
+	var (
+		results      golang.QuarantineResults
+		manifestMode = opts.manifest || golang.HasManifest(repoPath)
+	)
+	l = l.With().Bool("manifest", manifestMode).Logger()
+	if unquarantine {
+		if manifestMode {
+			results, err = golang.UnquarantineManifest(l, repoPath, targets)
+		} else {
+			results, err = golang.UnquarantineTests(
+				l,
+				repoPath,
+				targets,
+				golang.WithBuildFlags(opts.buildFlags),
+				golang.WithGenerator(generator),
+			)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to un-quarantine tests: %w", err)
+		}
+		if results.SuccessfulTestsCount() == 0 {
+			l.Info().Msg("No quarantined tests found to un-quarantine, not creating a pull request")
+			return results, nil
+		}
+	} else {
+		if manifestMode {
+			results, err = golang.QuarantineManifest(l, repoPath, targets)
+		} else {
+			results, err = golang.QuarantineTests(
+				l,
+				repoPath,
+				targets,
+				golang.WithBuildFlags(opts.buildFlags),
+				golang.WithGenerator(generator),
+				golang.WithExpiry(w.quarantineExpiry),
+				golang.WithQuarantineModuleVersion(w.quarantineModuleVersion),
+				// Trunk.io reports package-level failures, e.g. a panic in TestMain, as failures of TestMain
+				golang.WithPackageQuarantine(true),
+			)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to quarantine tests: %w", err)
+		}
+		// Re-deliveries of tests we've already quarantined shouldn't create churn PRs.
+		// Failures still get a PR so that someone can intervene manually.
+		if results.SuccessfulTestsCount() == 0 && results.FailedTestsCount() == 0 {
+			l.Info().
+				Int("already_quarantined", results.AlreadyQuarantinedTestsCount()).
+				Msg("All tests were already quarantined, not creating a pull request")
+			return results, nil
+		}
 	}
 
-	// 5. Quarantine tests - writing to the local repository
-	results, err := golang.QuarantineTests(l, repoPath, targets, golang.WithBuildFlags(opts.buildFlags))
+	// 5. Get or create the PR branch
+	branchHeadSHA, err := w.githubClient.GetOrCreateRemoteBranch(ctx, owner, repo, prBranch)
 	if err != nil {
-		return fmt.Errorf("failed to quarantine tests: %w", err)
+		l.Error().Err(err).Msg("Failed to get/create branch")
+		return nil, fmt.Errorf("failed to get/create branch: %w", err)
 	}
 
-	// 6. Create a commit with the quarantined tests
+	// 6. Create a commit with the modified tests
 	sha, err := w.githubClient.GenerateCommitAndPush(ctx, owner, repo, prBranch, branchHeadSHA, &results)
 	if err != nil {
 		l.Error().Err(err).Msg("Failed to create commit")
-		return fmt.Errorf("failed to create commit: %w", err)
+		return nil, fmt.Errorf("failed to create commit: %w", err)
 	}
 	l = l.With().Str("commit_sha", sha).Logger()
 
@@ -113,7 +216,7 @@ func (w *WebhookProcessor) QuarantineTests(
 	if err != nil {
 		w.metrics.RecordGitHubAPILatency(ctx, "create_update_pr", time.Since(prStart))
 		l.Error().Err(err).Msg("Failed to create or update pull request")
-		return fmt.Errorf("failed to create or update pull request: %w", err)
+		return nil, fmt.Errorf("failed to create or update pull request: %w", err)
 	}
 	w.metrics.RecordGitHubAPILatency(ctx, "create_update_pr", time.Since(prStart))
 	// Record final success metrics
@@ -129,5 +232,5 @@ func (w *WebhookProcessor) QuarantineTests(
 		Dur("duration", time.Since(start)).
 		Msg("Created or updated pull request")
 
-	return nil
+	return results, nil
 }
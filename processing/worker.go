@@ -3,8 +3,6 @@ package processing
 
 import (
 	"context"
-	"fmt"
-	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
@@ -23,12 +21,7 @@ type Worker struct {
 	// Configuration
 	pollInterval time.Duration
 
-	// State management
-	ctx     context.Context
-	cancel  context.CancelFunc
-	wg      sync.WaitGroup
-	running bool
-	mu      sync.RWMutex
+	*background
 }
 
 // Config holds configuration for the worker.
@@ -36,7 +29,8 @@ type Config struct {
 	PollInterval time.Duration
 }
 
-// NewWorker creates a new background worker for processing SQS messages.
+// NewWorker creates a new background worker for processing SQS messages, whose webhook processor is configured with
+// the options.
 func NewWorker(
 	logger zerolog.Logger,
 	awsClient AWSClient,
@@ -45,9 +39,8 @@ func NewWorker(
 	githubClient GithubClient, // for webhook_processor
 	metrics *telemetry.Metrics,
 	config Config,
+	options ...WebhookProcessorOption,
 ) *Worker {
-	ctx, cancel := context.WithCancel(context.Background())
-
 	if config.PollInterval == 0 {
 		config.PollInterval = 5 * time.Second // Default poll interval
 	}
@@ -58,73 +51,34 @@ func NewWorker(
 		trunkClient,
 		githubClient,
 		metrics,
+		options...,
 	)
 
+	logger = logger.With().Str("component", "sqs_worker").Logger()
 	return &Worker{
-		logger:           logger.With().Str("component", "sqs_worker").Logger(),
+		logger:           logger,
 		awsClient:        awsClient,
 		webhookProcessor: *webhookProcessor,
 		metrics:          metrics,
 		pollInterval:     config.PollInterval,
-		ctx:              ctx,
-		cancel:           cancel,
+		background:       newBackground(logger, "SQS worker"),
 	}
 }
 
 // Start begins the background worker process.
 func (w *Worker) Start() error {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	if w.running {
-		return fmt.Errorf("worker is already running")
+	if err := w.start(w.run); err != nil {
+		return err
 	}
 
 	w.logger.Info().
 		Str("poll_interval", w.pollInterval.String()).
-		Msg("Starting SQS worker")
-
-	w.running = true
-	w.wg.Add(1)
-
-	go w.run()
-
-	return nil
-}
-
-// Stop gracefully stops the worker and waits for current processing to complete.
-func (w *Worker) Stop() error {
-	w.mu.Lock()
-	if !w.running {
-		w.mu.Unlock()
-		return nil
-	}
-	w.running = false
-	w.mu.Unlock()
-
-	w.logger.Info().Msg("Stopping SQS worker")
-
-	// Cancel the context to signal shutdown
-	w.cancel()
-
-	// Wait for the worker goroutine to finish
-	w.wg.Wait()
-
-	w.logger.Info().Msg("SQS worker stopped")
+		Msg("Started SQS worker")
 	return nil
 }
 
-// IsRunning returns whether the worker is currently running.
-func (w *Worker) IsRunning() bool {
-	w.mu.RLock()
-	defer w.mu.RUnlock()
-	return w.running
-}
-
 // run is the main worker loop that polls SQS for messages.
 func (w *Worker) run() {
-	defer w.wg.Done()
-
 	ticker := time.NewTicker(w.pollInterval)
 	defer ticker.Stop()
 
@@ -237,6 +191,7 @@ func ProcessWebhookPayload(
 	trunkClient TrunkClient,
 	githubClient GithubClient,
 	payload string,
+	options ...WebhookProcessorOption,
 ) error {
 	webhookProcessor := NewWebhookProcessor(
 		logger,
@@ -244,6 +199,7 @@ func ProcessWebhookPayload(
 		trunkClient,
 		githubClient,
 		nil,
+		options...,
 	)
 
 	// Create a temporary worker-like struct to reuse the existing methods
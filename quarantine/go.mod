@@ -0,0 +1,3 @@
+module github.com/smartcontractkit/branch-out/quarantine
+
+go 1.25.0
@@ -0,0 +1,107 @@
+package quarantine_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The quarantine module has no dependencies outside of the standard library, not even in its tests,
+// so these helpers stand in for the parts of testify that the other tests of the repository use.
+
+// message formats the optional message of a failed check, like testify's msgAndArgs.
+func message(msgAndArgs []any) string {
+	if len(msgAndArgs) == 0 {
+		return ""
+	}
+	format, ok := msgAndArgs[0].(string)
+	if !ok {
+		return ": " + fmt.Sprint(msgAndArgs...)
+	}
+	return ": " + fmt.Sprintf(format, msgAndArgs[1:]...)
+}
+
+func assertTrue(tb testing.TB, value bool, msgAndArgs ...any) bool {
+	tb.Helper()
+	if !value {
+		tb.Errorf("expected true%s", message(msgAndArgs))
+	}
+	return value
+}
+
+func assertFalse(tb testing.TB, value bool, msgAndArgs ...any) bool {
+	tb.Helper()
+	if value {
+		tb.Errorf("expected false%s", message(msgAndArgs))
+	}
+	return !value
+}
+
+func assertEqual(tb testing.TB, expected, actual any, msgAndArgs ...any) bool {
+	tb.Helper()
+	if !reflect.DeepEqual(expected, actual) {
+		tb.Errorf("not equal%s\nexpected: %#v\nactual:   %#v", message(msgAndArgs), expected, actual)
+		return false
+	}
+	return true
+}
+
+func assertContains(tb testing.TB, s, substr string, msgAndArgs ...any) bool {
+	tb.Helper()
+	if !strings.Contains(s, substr) {
+		tb.Errorf("%q does not contain %q%s", s, substr, message(msgAndArgs))
+		return false
+	}
+	return true
+}
+
+func requireTrue(tb testing.TB, value bool, msgAndArgs ...any) {
+	tb.Helper()
+	if !assertTrue(tb, value, msgAndArgs...) {
+		tb.FailNow()
+	}
+}
+
+func requireFalse(tb testing.TB, value bool, msgAndArgs ...any) {
+	tb.Helper()
+	if !assertFalse(tb, value, msgAndArgs...) {
+		tb.FailNow()
+	}
+}
+
+func requireEqual(tb testing.TB, expected, actual any, msgAndArgs ...any) {
+	tb.Helper()
+	if !assertEqual(tb, expected, actual, msgAndArgs...) {
+		tb.FailNow()
+	}
+}
+
+func requireLen(tb testing.TB, value any, length int, msgAndArgs ...any) {
+	tb.Helper()
+	if actual := reflect.ValueOf(value).Len(); actual != length {
+		tb.Fatalf("expected length %d, got %d%s", length, actual, message(msgAndArgs))
+	}
+}
+
+func requireNoError(tb testing.TB, err error, msgAndArgs ...any) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatalf("unexpected error: %v%s", err, message(msgAndArgs))
+	}
+}
+
+func requireErrorIs(tb testing.TB, err, target error, msgAndArgs ...any) {
+	tb.Helper()
+	if !errors.Is(err, target) {
+		tb.Fatalf("expected error %v, got %v%s", target, err, message(msgAndArgs))
+	}
+}
+
+func requireErrorContains(tb testing.TB, err error, contains string, msgAndArgs ...any) {
+	tb.Helper()
+	if err == nil || !strings.Contains(err.Error(), contains) {
+		tb.Fatalf("expected error containing %q, got %v%s", contains, err, message(msgAndArgs))
+	}
+}
@@ -0,0 +1,347 @@
+package quarantine
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ManifestPath is the path of the manifest listing quarantined tests, relative to the root of the repository.
+const ManifestPath = ".branch-out/quarantine.yaml"
+
+// packageTestName is the test name a manifest entry uses to quarantine every test of its package, see Main.
+const packageTestName = "TestMain"
+
+// ErrInvalidManifest is returned when a manifest can't be parsed.
+var ErrInvalidManifest = errors.New("invalid quarantine manifest")
+
+// ManifestEntry is a test quarantined by the manifest.
+type ManifestEntry struct {
+	Package string // Import path of the test's package, e.g. "github.com/org/repo/pkg"
+	Test    string // Full name of the test, e.g. "TestFoo" or "TestFoo/subtest_1". "TestMain" for the whole package
+	Ticket  string // Ticket tracking the flaky test, e.g. "JIRA-123"
+	Variant string // Variant the test is flaky on, e.g. "linux" or "linux/amd64", see Variant. Empty for every variant
+}
+
+// Manifest lists quarantined tests, so that they can be quarantined without editing their source.
+// Tests opt in once with Check or Main, and are then quarantined or released by changing the manifest.
+//
+// The manifest is a small subset of YAML, so that it can be read without any dependencies:
+//
+//	tests:
+//	  - package: github.com/org/repo/pkg
+//	    test: TestFoo
+//	    ticket: JIRA-123
+//	    variant: linux
+type Manifest struct {
+	Tests []ManifestEntry
+}
+
+// ParseManifest parses a manifest written by Manifest.Bytes, or by hand in the same form.
+func ParseManifest(data []byte) (*Manifest, error) {
+	var (
+		manifest = &Manifest{}
+		inTests  bool
+		entry    *ManifestEntry
+		scanner  = bufio.NewScanner(bytes.NewReader(data))
+		lineNum  int
+	)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-") {
+			key, value, err := parseManifestField(trimmed)
+			if err != nil || key != "tests" || (value != "" && value != "[]") {
+				return nil, fmt.Errorf("%w: line %d: expected 'tests:'", ErrInvalidManifest, lineNum)
+			}
+			inTests = true
+			continue
+		}
+		if !inTests {
+			return nil, fmt.Errorf("%w: line %d: entries must be listed under 'tests:'", ErrInvalidManifest, lineNum)
+		}
+
+		if rest, ok := strings.CutPrefix(trimmed, "- "); ok {
+			manifest.Tests = append(manifest.Tests, ManifestEntry{})
+			entry = &manifest.Tests[len(manifest.Tests)-1]
+			trimmed = strings.TrimSpace(rest)
+		} else if entry == nil {
+			return nil, fmt.Errorf("%w: line %d: expected '- ' to start an entry", ErrInvalidManifest, lineNum)
+		}
+
+		key, value, err := parseManifestField(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidManifest, lineNum, err)
+		}
+		switch key {
+		case "package":
+			entry.Package = value
+		case "test":
+			entry.Test = value
+		case "ticket":
+			entry.Ticket = value
+		case "variant":
+			entry.Variant = value
+		default:
+			return nil, fmt.Errorf("%w: line %d: unknown field %q", ErrInvalidManifest, lineNum, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidManifest, err)
+	}
+
+	for index, entry := range manifest.Tests {
+		if entry.Package == "" || entry.Test == "" {
+			return nil, fmt.Errorf("%w: entry %d needs a package and a test", ErrInvalidManifest, index+1)
+		}
+	}
+	return manifest, nil
+}
+
+// parseManifestField parses a "key: value" line of the manifest.
+// Values can be plain, or quoted with double or single quotes.
+func parseManifestField(line string) (key, value string, err error) {
+	key, value, found := strings.Cut(line, ":")
+	if !found {
+		return "", "", fmt.Errorf("expected 'key: value', got %q", line)
+	}
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	switch {
+	case strings.HasPrefix(value, `"`):
+		if value, err = strconv.Unquote(value); err != nil {
+			return "", "", fmt.Errorf("invalid quoted value of %s: %w", key, err)
+		}
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", "", fmt.Errorf("invalid quoted value of %s", key)
+		}
+		value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	default:
+		// Plain values end at a comment
+		if comment := strings.Index(value, " #"); comment >= 0 {
+			value = strings.TrimSpace(value[:comment])
+		}
+	}
+	return key, value, nil
+}
+
+// Bytes returns the manifest in the form ParseManifest reads, with the entries sorted by package and test,
+// so that changes to the manifest make small diffs.
+func (m *Manifest) Bytes() []byte {
+	tests := slices.Clone(m.Tests)
+	slices.SortStableFunc(tests, func(a, b ManifestEntry) int {
+		if c := strings.Compare(a.Package, b.Package); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Test, b.Test); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Variant, b.Variant)
+	})
+
+	var b bytes.Buffer
+	b.WriteString("# Tests quarantined by branch-out (https://github.com/smartcontractkit/branch-out).\n")
+	b.WriteString("# Tests opt in with quarantine.Check(t) or quarantine.Main(m).\n")
+	if len(tests) == 0 {
+		b.WriteString("tests: []\n")
+		return b.Bytes()
+	}
+	b.WriteString("tests:\n")
+	for _, entry := range tests {
+		fmt.Fprintf(&b, "  - package: %s\n", manifestValue(entry.Package))
+		fmt.Fprintf(&b, "    test: %s\n", manifestValue(entry.Test))
+		fmt.Fprintf(&b, "    ticket: %s\n", manifestValue(entry.Ticket))
+		if entry.Variant != "" {
+			fmt.Fprintf(&b, "    variant: %s\n", manifestValue(entry.Variant))
+		}
+	}
+	return b.Bytes()
+}
+
+// plainManifestValue matches values that can be written without quotes.
+var plainManifestValue = regexp.MustCompile(`^[A-Za-z0-9_./-][A-Za-z0-9_./()*+,=@-]*$`)
+
+// manifestValue returns the value as written in the manifest, quoted if needed.
+func manifestValue(value string) string {
+	if plainManifestValue.MatchString(value) {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// Find returns the entry quarantining the test of the package on the current variant, if there is one.
+// Entries for the whole package are returned for any of its tests.
+func (m *Manifest) Find(pkg, test string) (ManifestEntry, bool) {
+	for _, entry := range m.Tests {
+		if entry.Package == pkg && (entry.Test == test || entry.Test == packageTestName) &&
+			variantMatches(entry.Variant) {
+			return entry, true
+		}
+	}
+	return ManifestEntry{}, false
+}
+
+// Check quarantines the test if the manifest lists it, see Flaky. It does nothing if the test isn't listed,
+// or there is no manifest in the current directory or any of its parents.
+// It fails the test if the manifest can't be read.
+//
+// Example:
+//
+//	func TestFlaky(t *testing.T) {
+//		quarantine.Check(t)
+//	}
+func Check(tb testing.TB) {
+	tb.Helper()
+
+	manifest, err := loadManifest()
+	if err != nil {
+		tb.Fatalf("Failed to load the quarantine manifest: %s", err)
+	}
+	if entry, ok := manifest.Find(callerPackage(2), tb.Name()); ok {
+		Flaky(tb, entry.Ticket)
+	}
+}
+
+// Main runs the tests of the package and exits, skipping the tests the manifest lists, for use as TestMain.
+// If the manifest lists the whole package, no tests are run, see FlakyPackage.
+// Like Flaky, quarantined tests are only run if the RUN_QUARANTINED_TESTS environment variable selects them,
+// see ParseSelection.
+//
+// Example:
+//
+//	func TestMain(m *testing.M) {
+//		quarantine.Main(m)
+//	}
+func Main(m *testing.M) {
+	manifest, err := loadManifest()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load the quarantine manifest: %s\n", err)
+		os.Exit(1)
+	}
+
+	selection, err := envSelection()
+	if err != nil {
+		fmt.Printf("Skipping all quarantined tests. %v\n", err)
+	}
+
+	pkg := callerPackage(2)
+	if entry, ok := manifest.Find(pkg, packageTestName); ok && FlakyPackage(entry.Ticket) {
+		os.Exit(0)
+	}
+
+	var skips []string
+	for _, entry := range manifest.Tests {
+		if entry.Package != pkg || entry.Test == packageTestName || !variantMatches(entry.Variant) {
+			continue
+		}
+		if selection.Runs(entry.Ticket, pkg, entry.Test) {
+			fmt.Printf("Running %s. Known flaky test. Ticket %s.\n", entry.Test, entry.Ticket)
+			continue
+		}
+		skips = append(skips, testNamePattern(entry.Test))
+		fmt.Printf("Quarantined %s. Known flaky test. Ticket %s.\n", entry.Test, entry.Ticket)
+		reportPackageSkipped(pkg, entry.Test, entry.Ticket)
+	}
+	if len(skips) > 0 {
+		if !flag.Parsed() {
+			flag.Parse()
+		}
+		if existing := flag.Lookup("test.skip"); existing != nil && existing.Value.String() != "" {
+			skips = append(skips, existing.Value.String())
+		}
+		if err := flag.Set("test.skip", strings.Join(skips, "|")); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to skip quarantined tests: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(runHint)
+	}
+	os.Exit(m.Run())
+}
+
+// testNamePattern returns the -test.skip pattern matching exactly the test, e.g. ^TestFoo$/^sub$.
+func testNamePattern(test string) string {
+	elements := strings.Split(test, "/")
+	for i, element := range elements {
+		elements[i] = "^" + regexp.QuoteMeta(element) + "$"
+	}
+	return strings.Join(elements, "/")
+}
+
+var (
+	manifestOnce   sync.Once
+	loadedManifest *Manifest
+	manifestErr    error
+)
+
+// loadManifest reads the manifest from the current directory or the closest of its parents that has one,
+// once per test binary. Tests run in the directory of their package, so this finds the repository's manifest.
+// Returns an empty manifest if there is none.
+func loadManifest() (*Manifest, error) {
+	manifestOnce.Do(func() {
+		loadedManifest = &Manifest{}
+		dir, err := os.Getwd()
+		if err != nil {
+			manifestErr = err
+			return
+		}
+		for {
+			data, err := os.ReadFile(filepath.Join(dir, ManifestPath))
+			if err == nil {
+				loadedManifest, manifestErr = ParseManifest(data)
+				return
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				manifestErr = err
+				return
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return
+			}
+			dir = parent
+		}
+	})
+	return loadedManifest, manifestErr
+}
+
+// callerPackage returns the import path of the package of the function skip frames up the stack,
+// e.g. the test calling Check. External test packages, e.g. pkg_test, are reported as the package they test.
+func callerPackage(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return ""
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+	return strings.TrimSuffix(funcPackage(fn.Name()), "_test")
+}
+
+// funcPackage returns the import path of the package of a function, from its full name.
+// Function names are the import path followed by the name, e.g. example.com/pkg.TestFoo or
+// example.com/pkg.(*Suite).TestFoo. Dots in the last element of the import path are escaped.
+func funcPackage(name string) string {
+	lastSlash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[lastSlash+1:], ".")
+	if dot < 0 {
+		return ""
+	}
+	return strings.ReplaceAll(name[:lastSlash+1+dot], "%2e", ".")
+}
@@ -0,0 +1,136 @@
+package quarantine_test
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestParseManifest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		manifest         string
+		expectedManifest *quarantine.Manifest
+		expectedErr      error
+	}{
+		{
+			name:             "empty",
+			manifest:         "tests: []\n",
+			expectedManifest: &quarantine.Manifest{},
+		},
+		{
+			name: "entries",
+			manifest: `# Quarantined tests
+tests:
+  - package: example.com/pkg
+    test: TestA/sub_1 # comment
+    ticket: "JIRA-1"
+    variant: linux
+- package: 'example.com/other'
+  test: TestMain
+  ticket: JIRA-2
+`,
+			expectedManifest: &quarantine.Manifest{Tests: []quarantine.ManifestEntry{
+				{Package: "example.com/pkg", Test: "TestA/sub_1", Ticket: "JIRA-1", Variant: "linux"},
+				{Package: "example.com/other", Test: "TestMain", Ticket: "JIRA-2"},
+			}},
+		},
+		{
+			name:        "unknown field",
+			manifest:    "tests:\n  - package: example.com/pkg\n    name: TestA\n",
+			expectedErr: quarantine.ErrInvalidManifest,
+		},
+		{
+			name:        "missing test",
+			manifest:    "tests:\n  - package: example.com/pkg\n    ticket: JIRA-1\n",
+			expectedErr: quarantine.ErrInvalidManifest,
+		},
+		{
+			name:        "not a list of tests",
+			manifest:    "quarantined:\n  - package: example.com/pkg\n",
+			expectedErr: quarantine.ErrInvalidManifest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			manifest, err := quarantine.ParseManifest([]byte(test.manifest))
+			if test.expectedErr != nil {
+				requireErrorIs(t, err, test.expectedErr)
+				return
+			}
+			requireNoError(t, err)
+			assertEqual(t, test.expectedManifest, manifest)
+
+			// Writing the manifest and reading it back is lossless
+			written, err := quarantine.ParseManifest(manifest.Bytes())
+			requireNoError(t, err)
+			requireLen(t, written.Tests, len(manifest.Tests))
+			for _, entry := range manifest.Tests {
+				assertTrue(t, slices.Contains(written.Tests, entry), "%+v should be written", entry)
+			}
+		})
+	}
+}
+
+func TestManifest_Bytes(t *testing.T) {
+	t.Parallel()
+
+	manifest := &quarantine.Manifest{Tests: []quarantine.ManifestEntry{
+		{Package: "example.com/pkg", Test: "TestB", Ticket: "JIRA-2"},
+		{Package: "example.com/pkg", Test: "TestA/with: colon", Ticket: "JIRA-1", Variant: "linux"},
+	}}
+	expected := `# Tests quarantined by branch-out (https://github.com/smartcontractkit/branch-out).
+# Tests opt in with quarantine.Check(t) or quarantine.Main(m).
+tests:
+  - package: example.com/pkg
+    test: "TestA/with: colon"
+    ticket: JIRA-1
+    variant: linux
+  - package: example.com/pkg
+    test: TestB
+    ticket: JIRA-2
+`
+	assertEqual(t, expected, string(manifest.Bytes()))
+}
+
+func TestManifest_Find(t *testing.T) {
+	t.Parallel()
+
+	manifest := &quarantine.Manifest{Tests: []quarantine.ManifestEntry{
+		{Package: "example.com/pkg", Test: "TestA", Ticket: "JIRA-1"},
+		{Package: "example.com/pkg", Test: "TestOtherOS", Ticket: "JIRA-2", Variant: "not-" + runtime.GOOS},
+		{Package: "example.com/pkg", Test: "TestThisOS", Ticket: "JIRA-3", Variant: runtime.GOOS + "/" + runtime.GOARCH},
+		{Package: "example.com/whole", Test: "TestMain", Ticket: "JIRA-4"},
+	}}
+
+	entry, ok := manifest.Find("example.com/pkg", "TestA")
+	requireTrue(t, ok)
+	assertEqual(t, "JIRA-1", entry.Ticket)
+
+	_, ok = manifest.Find("example.com/pkg", "TestB")
+	assertFalse(t, ok, "unlisted test should not be found")
+	_, ok = manifest.Find("example.com/pkg", "TestOtherOS")
+	assertFalse(t, ok, "test flaky on another variant should not be found")
+	_, ok = manifest.Find("example.com/pkg", "TestThisOS")
+	assertTrue(t, ok, "test flaky on this variant should be found")
+
+	entry, ok = manifest.Find("example.com/whole", "TestAnything")
+	requireTrue(t, ok, "every test of a quarantined package should be found")
+	assertEqual(t, "JIRA-4", entry.Ticket)
+}
+
+func TestCheck_NoManifest(t *testing.T) {
+	t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+	quarantine.Check(t)
+
+	t.Cleanup(func() {
+		requireFalse(t, t.Skipped(), "tests should run when there is no manifest")
+	})
+}
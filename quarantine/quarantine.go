@@ -3,40 +3,135 @@ package quarantine
 
 import (
 	"fmt"
-	"os"
 	"testing"
+	"time"
 )
 
-// RunQuarantinedTestsEnvVar is the environment variable that controls whether to run quarantined tests.
+// RunQuarantinedTestsEnvVar is the environment variable that controls whether to run quarantined tests,
+// see ParseSelection.
 const RunQuarantinedTestsEnvVar = "RUN_QUARANTINED_TESTS"
 
+// UntilLayout is the layout of the dates passed to Until, see time.Parse.
+const UntilLayout = "2006-01-02"
+
+// Option configures how a test is quarantined.
+type Option func(*options)
+
+// options describes the options for quarantining a test.
+type options struct {
+	name    string
+	until   string
+	variant string
+}
+
+// newOptions applies the options.
+func newOptions(opts ...Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// IfNamed only quarantines the test if its full name, as returned by tb.Name(), matches name.
+// This is how subtests whose names are only known at runtime are quarantined, e.g. table tests with generated names.
+//
+// Example:
+//
+//	t.Run(fmt.Sprintf("subtest %d", i), func(t *testing.T) {
+//		quarantine.Flaky(t, "TEST-123", quarantine.IfNamed("TestTable/subtest_1"))
+//	})
+func IfNamed(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// Until only quarantines the test until the end of the given date (UTC), in the form "2006-01-02".
+// After that, the quarantine has expired and the test runs normally, as if it wasn't quarantined.
+// If it still fails, the failure is reported as an expired quarantine, so that flaky tests can't be forgotten about.
+// Tests with an invalid date fail.
+//
+// Example:
+//
+//	quarantine.Flaky(t, "TEST-123", quarantine.Until("2026-12-01"))
+func Until(date string) Option {
+	return func(o *options) {
+		o.until = date
+	}
+}
+
+// expired checks if a quarantine lasting until the date has expired, see Until.
+// An empty date never expires.
+func expired(date string) (bool, error) {
+	if date == "" {
+		return false, nil
+	}
+	until, err := time.Parse(UntilLayout, date)
+	if err != nil {
+		return false, fmt.Errorf("invalid quarantine expiry date %q, expected the form %s: %w", date, UntilLayout, err)
+	}
+	return !time.Now().Before(until.AddDate(0, 0, 1)), nil
+}
+
 // Flaky marks a test as flaky.
-// To run tests marked as flaky, set the RUN_FLAKY_TESTS environment variable to true.
-// To skip tests marked as flaky, set the RUN_FLAKY_TESTS environment variable to false (or don't set it at all).
+// To run tests marked as flaky, set the RUN_QUARANTINED_TESTS environment variable to true,
+// or to a list selecting some of them, see ParseSelection.
+// To skip tests marked as flaky, set the RUN_QUARANTINED_TESTS environment variable to false (or don't set it at all).
+// The test is skipped if the environment variable can't be parsed.
 //
 // Example:
 //
 //	func TestFlaky(t *testing.T) {
 //		quarantine.Flaky(t, "TEST-123")
 //	}
-func Flaky(tb testing.TB, ticket string) {
+func Flaky(tb testing.TB, ticket string, opts ...Option) {
 	tb.Helper()
 
+	o := newOptions(opts...)
+	if (o.name != "" && tb.Name() != o.name) || !variantMatches(o.variant) {
+		return
+	}
+
 	explanationStr := fmt.Sprintf(
 		"Known flaky test. Ticket %s.\nClassified by branch-out (https://github.com/smartcontractkit/branch-out)",
 		ticket,
 	)
 	tb.Attr("flaky_test", ticket)
-	//nolint:forbidigo // Config doesn't make sense here
-	if os.Getenv(RunQuarantinedTestsEnvVar) != "true" {
-		tb.Skipf(
-			"Skipping %s. To run quarantined tests, set the %s environment variable to true.",
-			explanationStr,
-			RunQuarantinedTestsEnvVar,
-		)
+	record := ReportRecord{Package: reportPackage(), Test: tb.Name(), Ticket: ticket}
+	isExpired, err := expired(o.until)
+	if err != nil {
+		tb.Fatalf("%s. %v", explanationStr, err)
+	}
+	if isExpired {
+		tb.Logf("Running %s\nThe quarantine expired on %s, so the test runs normally.", explanationStr, o.until)
+		record.Expired = true
+		tb.Cleanup(func() {
+			reportTest(tb, record.withResult(tb))
+			if tb.Failed() {
+				tb.Errorf(
+					"Quarantine expired on %s, but the test still fails. %s. Fix the test, or quarantine it again.",
+					o.until,
+					explanationStr,
+				)
+			}
+		})
+		return
+	}
+	runs, err := runsQuarantined(ticket, tb.Name())
+	if err != nil {
+		record.Status = ReportStatusSkipped
+		reportTest(tb, record)
+		tb.Skipf("Skipping %s. %v", explanationStr, err)
+	}
+	if !runs {
+		record.Status = ReportStatusSkipped
+		reportTest(tb, record)
+		tb.Skipf("Skipping %s. %s", explanationStr, runHint)
 	} else {
 		tb.Logf("Running %s", explanationStr)
 		tb.Cleanup(func() {
+			reportTest(tb, record.withResult(tb))
 			tb.Logf(
 				"Test is marked as quarantined, but still ran. %s. To skip quarantined tests, ensure the %s environment variable is set to false.",
 				explanationStr,
@@ -45,3 +140,70 @@ func Flaky(tb testing.TB, ticket string) {
 		})
 	}
 }
+
+// FlakyPackage marks every test of a package as flaky, for packages that fail as a whole, e.g. a panic in TestMain.
+// It returns true if the package's tests should be skipped, in which case TestMain should return without calling
+// m.Run. Like Flaky, the tests are only run if the RUN_QUARANTINED_TESTS environment variable selects the package,
+// by its ticket or import path, or once the quarantine has expired, see Until. The package is only quarantined on
+// its variant, see Variant. IfNamed is ignored.
+//
+// Example:
+//
+//	func TestMain(m *testing.M) {
+//		if quarantine.FlakyPackage("TEST-123") {
+//			return
+//		}
+//		m.Run()
+//	}
+func FlakyPackage(ticket string, opts ...Option) bool {
+	o := newOptions(opts...)
+	if !variantMatches(o.variant) {
+		return false
+	}
+	explanationStr := fmt.Sprintf(
+		"Known flaky package. Ticket %s.\nClassified by branch-out (https://github.com/smartcontractkit/branch-out)",
+		ticket,
+	)
+	isExpired, err := expired(o.until)
+	if err != nil {
+		fmt.Printf("Running all tests. %s. %v\n", explanationStr, err)
+		return false
+	}
+	if isExpired {
+		fmt.Printf("Running %s\nThe quarantine expired on %s, so the tests run normally.\n", explanationStr, o.until)
+		return false
+	}
+	runs, err := runsQuarantined(ticket, "")
+	if err != nil {
+		fmt.Printf("Skipping all tests. %s. %v\n", explanationStr, err)
+		reportPackageSkipped(reportPackage(), packageTestName, ticket)
+		return true
+	}
+	if !runs {
+		fmt.Printf("Skipping all tests. %s. %s\n", explanationStr, runHint)
+		reportPackageSkipped(reportPackage(), packageTestName, ticket)
+		return true
+	}
+	fmt.Printf("Running %s\n", explanationStr)
+	return false
+}
+
+// Suite is a test suite that provides access to the *testing.T of the currently running test,
+// like suites built with github.com/stretchr/testify/suite.
+type Suite interface {
+	T() *testing.T
+}
+
+// FlakySuite marks a test suite method as flaky, see Flaky.
+//
+// Example:
+//
+//	func (s *MySuite) TestFlaky() {
+//		quarantine.FlakySuite(s, "TEST-123")
+//	}
+func FlakySuite(s Suite, ticket string, opts ...Option) {
+	t := s.T()
+	t.Helper()
+
+	Flaky(t, ticket, opts...)
+}
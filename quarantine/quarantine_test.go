@@ -1,10 +1,10 @@
 package quarantine_test
 
 import (
+	"fmt"
+	"runtime"
 	"testing"
 
-	"github.com/stretchr/testify/require"
-
 	"github.com/smartcontractkit/branch-out/quarantine"
 )
 
@@ -14,7 +14,7 @@ func TestFlaky(t *testing.T) {
 		quarantine.Flaky(t, "TEST-123")
 
 		t.Cleanup(func() {
-			require.True(t, t.Skipped(), "quarantined test should be skipped when RUN_FLAKY_TESTS is false")
+			requireTrue(t, t.Skipped(), "quarantined test should be skipped when RUN_FLAKY_TESTS is false")
 		})
 	})
 
@@ -23,8 +23,177 @@ func TestFlaky(t *testing.T) {
 		quarantine.Flaky(t, "TEST-123")
 
 		t.Cleanup(func() {
-			require.False(t, t.Skipped(), "quarantined test should not be skipped when RUN_FLAKY_TESTS is true")
+			requireFalse(t, t.Skipped(), "quarantined test should not be skipped when RUN_FLAKY_TESTS is true")
 			t.Log("This test is intentionally skipped! Skipping = Passing!")
 		})
 	})
 }
+
+func TestFlakyIfNamed(t *testing.T) {
+	t.Run("matching name", func(t *testing.T) {
+		t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+		quarantine.Flaky(t, "TEST-123", quarantine.IfNamed("TestFlakyIfNamed/matching_name"))
+
+		t.Cleanup(func() {
+			requireTrue(t, t.Skipped(), "quarantined test should be skipped when its name matches")
+		})
+	})
+
+	t.Run("other name", func(t *testing.T) {
+		t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+		quarantine.Flaky(t, "TEST-123", quarantine.IfNamed("TestFlakyIfNamed/matching_name"))
+
+		t.Cleanup(func() {
+			requireFalse(t, t.Skipped(), "quarantined test should not be skipped when its name doesn't match")
+		})
+	})
+}
+
+// failingTB records the errors of a test that fails, to check what is reported without failing the real test.
+// Fatal errors don't stop the test.
+type failingTB struct {
+	*testing.T
+	errors []string
+}
+
+func (f *failingTB) Failed() bool {
+	return true
+}
+
+func (f *failingTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *failingTB) Fatalf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestFlakyUntil(t *testing.T) {
+	t.Run("not expired", func(t *testing.T) {
+		t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+		quarantine.Flaky(t, "TEST-123", quarantine.Until("2999-12-31"))
+
+		t.Cleanup(func() {
+			requireTrue(t, t.Skipped(), "quarantined test should be skipped until its quarantine expires")
+		})
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+		quarantine.Flaky(t, "TEST-123", quarantine.Until("2000-01-01"))
+
+		t.Cleanup(func() {
+			requireFalse(t, t.Skipped(), "test should run once its quarantine expired")
+		})
+	})
+
+	t.Run("expired and failing", func(t *testing.T) {
+		t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+		failing := &failingTB{}
+		t.Run("flaky", func(t *testing.T) {
+			failing.T = t
+			quarantine.Flaky(failing, "TEST-123", quarantine.Until("2000-01-01"))
+		})
+
+		requireLen(t, failing.errors, 1, "a failing test with an expired quarantine should be reported")
+		assertContains(t, failing.errors[0], "Quarantine expired on 2000-01-01")
+		assertContains(t, failing.errors[0], "TEST-123")
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+		failing := &failingTB{}
+		t.Run("flaky", func(t *testing.T) {
+			failing.T = t
+			quarantine.Flaky(failing, "TEST-123", quarantine.Until("01/12/2026"))
+		})
+
+		requireLen(t, failing.errors, 1, "a quarantine with an invalid expiry should fail the test")
+		assertContains(t, failing.errors[0], "invalid quarantine expiry date")
+	})
+}
+
+func TestFlakyVariant(t *testing.T) {
+	tests := []struct {
+		name            string
+		variant         string
+		expectedSkipped bool
+	}{
+		{name: "every variant", variant: "", expectedSkipped: true},
+		{name: "this os", variant: runtime.GOOS, expectedSkipped: true},
+		{name: "this arch", variant: runtime.GOARCH, expectedSkipped: true},
+		{name: "this os and arch", variant: runtime.GOOS + "/" + runtime.GOARCH, expectedSkipped: true},
+		{name: "other os", variant: "not-" + runtime.GOOS, expectedSkipped: false},
+		{name: "env variant", variant: "ci-job", expectedSkipped: true},
+		{name: "env variant with plus", variant: "ci-job+extra", expectedSkipped: false},
+		{name: "all parts match", variant: runtime.GOOS + "+ci-job", expectedSkipped: true},
+		{name: "some parts match", variant: "not-" + runtime.GOOS + "+ci-job", expectedSkipped: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+			t.Setenv(quarantine.VariantEnvVar, "ci-job")
+			quarantine.Flaky(t, "TEST-123", quarantine.Variant(test.variant))
+
+			t.Cleanup(func() {
+				requireEqual(t, test.expectedSkipped, t.Skipped(), "variant %q", test.variant)
+			})
+		})
+	}
+
+	t.Run("exact env variant", func(t *testing.T) {
+		t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+		t.Setenv(quarantine.VariantEnvVar, "linux+gpu")
+		quarantine.Flaky(t, "TEST-123", quarantine.Variant("linux+gpu"))
+
+		t.Cleanup(func() {
+			requireTrue(t, t.Skipped(), "variant equal to the env variant should match as a whole")
+		})
+	})
+}
+
+func TestFlakyPackage(t *testing.T) {
+	t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+	requireTrue(t, quarantine.FlakyPackage("TEST-123"), "package should be skipped when quarantined tests don't run")
+
+	t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "true")
+	requireFalse(t, quarantine.FlakyPackage("TEST-123"), "package should run when quarantined tests run")
+
+	t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+	requireTrue(
+		t,
+		quarantine.FlakyPackage("TEST-123", quarantine.Until("2999-12-31")),
+		"package should be skipped until its quarantine expires",
+	)
+	requireFalse(
+		t,
+		quarantine.FlakyPackage("TEST-123", quarantine.Until("2000-01-01")),
+		"package should run once its quarantine expired",
+	)
+	requireFalse(
+		t,
+		quarantine.FlakyPackage("TEST-123", quarantine.Variant("not-"+runtime.GOOS)),
+		"package should run on other variants",
+	)
+}
+
+// testSuite mimics a testify suite without depending on testify's suite package.
+type testSuite struct {
+	t *testing.T
+}
+
+func (s *testSuite) T() *testing.T {
+	return s.t
+}
+
+func TestFlakySuite(t *testing.T) {
+	t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+	t.Run("suite method", func(t *testing.T) {
+		quarantine.FlakySuite(&testSuite{t: t}, "TEST-123")
+
+		t.Cleanup(func() {
+			requireTrue(t, t.Skipped(), "quarantined suite method should be skipped")
+		})
+	})
+}
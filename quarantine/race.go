@@ -0,0 +1,6 @@
+//go:build race
+
+package quarantine
+
+// raceEnabled is true if the tests were built with the race detector, e.g. go test -race.
+const raceEnabled = true
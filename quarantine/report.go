@@ -0,0 +1,224 @@
+package quarantine
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ReportPathEnvVar is the environment variable with the path of the file a record of every quarantined test is
+// appended to, as JSON lines, see ReportRecord. Nothing is reported if it isn't set.
+// Tests run in the directory of their package, so the path should be absolute to collect every package's records.
+const ReportPathEnvVar = "QUARANTINE_REPORT_PATH"
+
+// ReportStatus is what happened to a quarantined test.
+type ReportStatus string
+
+const (
+	// ReportStatusSkipped is reported for quarantined tests that didn't run.
+	ReportStatusSkipped ReportStatus = "skipped"
+	// ReportStatusPassed is reported for quarantined tests that ran and passed.
+	ReportStatusPassed ReportStatus = "passed"
+	// ReportStatusFailed is reported for quarantined tests that ran and failed.
+	ReportStatusFailed ReportStatus = "failed"
+)
+
+// ReportRecord is a line of the report of quarantined tests, see ReportPathEnvVar.
+//
+// Tests quarantined with Flaky or Check report if they passed or failed when they run. Tests and packages skipped by
+// FlakyPackage or Main are reported as skipped, but not when they run, as their results aren't known.
+type ReportRecord struct {
+	Time    time.Time    `json:"time"`
+	Package string       `json:"package"`           // Import path of the test's package
+	Test    string       `json:"test"`              // Full name of the test, "TestMain" for whole packages
+	Ticket  string       `json:"ticket"`            // Ticket the test is quarantined for
+	Status  ReportStatus `json:"status"`            // Whether the test was skipped, or passed or failed if it ran
+	Expired bool         `json:"expired,omitempty"` // True if the test ran because its quarantine expired, see Until
+}
+
+// Ran returns true if the test ran, rather than being skipped.
+func (r ReportRecord) Ran() bool {
+	return r.Status != ReportStatusSkipped
+}
+
+// reportMu serializes the writes of the tests of a test binary to the report.
+var reportMu sync.Mutex
+
+// reportPath returns the path of the report, empty if quarantined tests aren't reported.
+func reportPath() string {
+	//nolint:forbidigo // Config doesn't make sense here
+	return os.Getenv(ReportPathEnvVar)
+}
+
+// reportPackage returns the package of the test calling into this package for its records, see testPackage.
+// It's only looked up if quarantined tests are reported.
+func reportPackage() string {
+	if reportPath() == "" {
+		return ""
+	}
+	return testPackage()
+}
+
+// writeReport appends the record to the report, if quarantined tests are reported.
+func writeReport(record ReportRecord) error {
+	path := reportPath()
+	if path == "" {
+		return nil
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal quarantine report record: %w", err)
+	}
+
+	reportMu.Lock()
+	defer reportMu.Unlock()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open quarantine report: %w", err)
+	}
+	// A single write per record, so that test binaries running in parallel don't interleave their records
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write quarantine report: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close quarantine report: %w", err)
+	}
+	return nil
+}
+
+// reportTest appends a record of the test to the report, logging instead of failing the test if it can't.
+func reportTest(tb testing.TB, record ReportRecord) {
+	if err := writeReport(record); err != nil {
+		tb.Logf("%s", err)
+	}
+}
+
+// withResult returns the record with the result of the test, once it has finished, e.g. in tb.Cleanup.
+func (r ReportRecord) withResult(tb testing.TB) ReportRecord {
+	switch {
+	case tb.Skipped():
+		r.Status = ReportStatusSkipped
+	case tb.Failed():
+		r.Status = ReportStatusFailed
+	default:
+		r.Status = ReportStatusPassed
+	}
+	return r
+}
+
+// reportPackageSkipped appends a record of a test skipped outside of it, e.g. in TestMain, to the report,
+// printing instead of failing if it can't.
+func reportPackageSkipped(pkg, test, ticket string) {
+	err := writeReport(ReportRecord{Package: pkg, Test: test, Ticket: ticket, Status: ReportStatusSkipped})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// ParseReport reads the records of a report of quarantined tests, see ReportPathEnvVar. Blank lines are ignored.
+func ParseReport(r io.Reader) ([]ReportRecord, error) {
+	var (
+		records []ReportRecord
+		scanner = bufio.NewScanner(r)
+		line    = 0
+	)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record ReportRecord
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, fmt.Errorf("failed to parse quarantine report line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read quarantine report: %w", err)
+	}
+	return records, nil
+}
+
+// ReportSummary aggregates the records of a quarantined test, see SummarizeReport.
+type ReportSummary struct {
+	Package  string
+	Test     string
+	Ticket   string // Ticket of the latest record
+	Passes   int
+	Failures int
+	Skips    int
+	// Passes since the test last failed, the evidence that a test is no longer flaky, see Stable.
+	ConsecutivePasses int
+	LastRun           time.Time // Time the test last ran, zero if it never did
+}
+
+// Runs returns how many times the test ran.
+func (s ReportSummary) Runs() int {
+	return s.Passes + s.Failures
+}
+
+// Stable checks if the test passed at least minPasses times in a row since it last failed,
+// so that it can be un-quarantined.
+func (s ReportSummary) Stable(minPasses int) bool {
+	return s.ConsecutivePasses >= minPasses && s.Runs() > 0
+}
+
+// SummarizeReport aggregates the records of reports by test, sorted by package and test name.
+// Records are taken in the order of their time, so reports of multiple runs can be combined in any order.
+func SummarizeReport(records []ReportRecord) []ReportSummary {
+	sorted := slices.Clone(records)
+	slices.SortStableFunc(sorted, func(a, b ReportRecord) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	var (
+		summaries []ReportSummary
+		indexes   = make(map[[2]string]int)
+	)
+	for _, record := range sorted {
+		key := [2]string{record.Package, record.Test}
+		index, ok := indexes[key]
+		if !ok {
+			index = len(summaries)
+			indexes[key] = index
+			summaries = append(summaries, ReportSummary{Package: record.Package, Test: record.Test})
+		}
+
+		summary := &summaries[index]
+		summary.Ticket = record.Ticket
+		switch record.Status {
+		case ReportStatusSkipped:
+			summary.Skips++
+			continue
+		case ReportStatusPassed:
+			summary.Passes++
+			summary.ConsecutivePasses++
+		case ReportStatusFailed:
+			summary.Failures++
+			summary.ConsecutivePasses = 0
+		default:
+			continue
+		}
+		summary.LastRun = record.Time
+	}
+
+	slices.SortFunc(summaries, func(a, b ReportSummary) int {
+		if c := strings.Compare(a.Package, b.Package); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Test, b.Test)
+	})
+	return summaries
+}
@@ -0,0 +1,111 @@
+package quarantine_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestReport(t *testing.T) {
+	reportPath := filepath.Join(t.TempDir(), "report.jsonl")
+	t.Setenv(quarantine.ReportPathEnvVar, reportPath)
+
+	t.Run("skipped", func(t *testing.T) {
+		t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "false")
+		quarantine.Flaky(t, "TEST-1")
+	})
+	t.Run("passed", func(t *testing.T) {
+		t.Setenv(quarantine.RunQuarantinedTestsEnvVar, "true")
+		quarantine.Flaky(t, "TEST-2")
+	})
+	t.Run("expired", func(t *testing.T) {
+		quarantine.Flaky(t, "TEST-3", quarantine.Until("2000-01-01"))
+	})
+
+	file, err := os.Open(reportPath)
+	requireNoError(t, err)
+	t.Cleanup(func() { _ = file.Close() })
+	records, err := quarantine.ParseReport(file)
+	requireNoError(t, err)
+	requireLen(t, records, 3)
+
+	const pkg = "github.com/smartcontractkit/branch-out/quarantine"
+	expected := []quarantine.ReportRecord{
+		{Package: pkg, Test: "TestReport/skipped", Ticket: "TEST-1", Status: quarantine.ReportStatusSkipped},
+		{Package: pkg, Test: "TestReport/passed", Ticket: "TEST-2", Status: quarantine.ReportStatusPassed},
+		{Package: pkg, Test: "TestReport/expired", Ticket: "TEST-3", Status: quarantine.ReportStatusPassed, Expired: true},
+	}
+	for i, record := range records {
+		assertFalse(t, record.Time.IsZero(), "record should have a time")
+		record.Time = time.Time{}
+		assertEqual(t, expected[i], record)
+	}
+	assertFalse(t, records[0].Ran())
+	assertTrue(t, records[1].Ran())
+}
+
+func TestParseReport_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := quarantine.ParseReport(strings.NewReader("{\"test\": \"TestA\"}\n\nnot json\n"))
+	requireErrorContains(t, err, "line 3")
+}
+
+func TestSummarizeReport(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	record := func(pkg, test string, status quarantine.ReportStatus, hours int) quarantine.ReportRecord {
+		return quarantine.ReportRecord{
+			Time:    start.Add(time.Duration(hours) * time.Hour),
+			Package: pkg,
+			Test:    test,
+			Ticket:  "TEST-1",
+			Status:  status,
+		}
+	}
+	records := []quarantine.ReportRecord{
+		// Out of order, as if reports of multiple runs were concatenated
+		record("example.com/b", "TestB", quarantine.ReportStatusPassed, 3),
+		record("example.com/a", "TestA", quarantine.ReportStatusPassed, 4),
+		record("example.com/a", "TestA", quarantine.ReportStatusFailed, 2),
+		record("example.com/a", "TestA", quarantine.ReportStatusPassed, 1),
+		record("example.com/a", "TestA", quarantine.ReportStatusSkipped, 5),
+		record("example.com/a", "TestA", quarantine.ReportStatusPassed, 3),
+		record("example.com/b", "TestB", quarantine.ReportStatusSkipped, 1),
+		record("example.com/b", "TestSkipped", quarantine.ReportStatusSkipped, 1),
+	}
+
+	summaries := quarantine.SummarizeReport(records)
+	assertEqual(t, []quarantine.ReportSummary{
+		{
+			Package:           "example.com/a",
+			Test:              "TestA",
+			Ticket:            "TEST-1",
+			Passes:            3,
+			Failures:          1,
+			Skips:             1,
+			ConsecutivePasses: 2,
+			LastRun:           start.Add(4 * time.Hour),
+		},
+		{
+			Package:           "example.com/b",
+			Test:              "TestB",
+			Ticket:            "TEST-1",
+			Passes:            1,
+			Skips:             1,
+			ConsecutivePasses: 1,
+			LastRun:           start.Add(3 * time.Hour),
+		},
+		{Package: "example.com/b", Test: "TestSkipped", Ticket: "TEST-1", Skips: 1},
+	}, summaries)
+
+	assertTrue(t, summaries[0].Stable(2), "test passed twice since it last failed")
+	assertFalse(t, summaries[0].Stable(3), "test only passed twice since it last failed")
+	assertEqual(t, 4, summaries[0].Runs())
+	assertFalse(t, summaries[2].Stable(0), "test that never ran isn't stable")
+}
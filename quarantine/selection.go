@@ -0,0 +1,191 @@
+package quarantine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// ErrInvalidSelection is returned when the RUN_QUARANTINED_TESTS environment variable can't be parsed.
+var ErrInvalidSelection = errors.New("invalid selection of quarantined tests")
+
+// Prefixes of the items of a Selection, see ParseSelection.
+const (
+	ticketSelectionPrefix  = "ticket:"
+	testSelectionPrefix    = "test:"
+	packageSelectionPrefix = "package:"
+)
+
+// runHint tells how to run quarantined tests, for the messages of skipped tests.
+const runHint = "To run quarantined tests, set the " + RunQuarantinedTestsEnvVar +
+	" environment variable to true, or to a comma-separated list of tickets, test:<regex> and package:<glob> items."
+
+// TicketPattern is the regex of ticket keys, e.g. JIRA-123, which can be selected without a prefix, see ParseSelection.
+const TicketPattern = `^[A-Z][A-Z0-9_]*-[0-9]+$`
+
+// ticketPattern is the compiled TicketPattern.
+var ticketPattern = regexp.MustCompile(TicketPattern)
+
+// Selection selects which quarantined tests run, as set by the RUN_QUARANTINED_TESTS environment variable.
+// The zero value selects no tests.
+type Selection struct {
+	all      bool
+	tickets  []string
+	tests    []*regexp.Regexp
+	packages []string
+}
+
+// ParseSelection parses the value of the RUN_QUARANTINED_TESTS environment variable.
+//
+//   - "true" runs every quarantined test.
+//   - A comma-separated list of items runs the quarantined tests matching any of them:
+//   - "ticket:JIRA-123", or just "JIRA-123", runs the tests quarantined for the ticket.
+//   - "test:TestFoo/.*" runs the tests whose full names match the regex. Like go test's -run flag,
+//     the regex isn't anchored.
+//   - "package:github.com/org/repo/*" runs the tests of the packages whose import paths match the glob,
+//     see path.Match.
+//   - Any other value, e.g. "" or "false", runs no quarantined tests, the default.
+//
+// A list with an invalid item, e.g. an item without a prefix that isn't a ticket, returns ErrInvalidSelection.
+//
+// Example:
+//
+//	RUN_QUARANTINED_TESTS="JIRA-123,test:^TestChaos,package:github.com/org/repo/integration/*" go test ./...
+func ParseSelection(value string) (Selection, error) {
+	value = strings.TrimSpace(value)
+	if value == "true" {
+		return Selection{all: true}, nil
+	}
+	if !isSelectionList(value) {
+		return Selection{}, nil
+	}
+
+	var selection Selection
+	for item := range strings.SplitSeq(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if ticket, ok := strings.CutPrefix(item, ticketSelectionPrefix); ok {
+			if ticket == "" {
+				return Selection{}, fmt.Errorf("%w: empty ticket in %q", ErrInvalidSelection, item)
+			}
+			selection.tickets = append(selection.tickets, ticket)
+			continue
+		}
+		if glob, ok := strings.CutPrefix(item, packageSelectionPrefix); ok {
+			if _, err := path.Match(glob, ""); err != nil || glob == "" {
+				return Selection{}, fmt.Errorf("%w: invalid package glob %q", ErrInvalidSelection, glob)
+			}
+			selection.packages = append(selection.packages, glob)
+			continue
+		}
+		if ticketPattern.MatchString(item) {
+			selection.tickets = append(selection.tickets, item)
+			continue
+		}
+		pattern, ok := strings.CutPrefix(item, testSelectionPrefix)
+		if !ok {
+			return Selection{}, fmt.Errorf(
+				"%w: %q is neither a ticket nor prefixed with %q, %q or %q",
+				ErrInvalidSelection, item, ticketSelectionPrefix, testSelectionPrefix, packageSelectionPrefix,
+			)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil || pattern == "" {
+			return Selection{}, fmt.Errorf("%w: invalid test name regex %q", ErrInvalidSelection, pattern)
+		}
+		selection.tests = append(selection.tests, re)
+	}
+	return selection, nil
+}
+
+// isSelectionList checks if the value uses the list syntax of ParseSelection, i.e. any of its items is a ticket or
+// has a prefix. Other values, e.g. "1" or "yes", select no tests, as they always have.
+func isSelectionList(value string) bool {
+	for item := range strings.SplitSeq(value, ",") {
+		item = strings.TrimSpace(item)
+		if ticketPattern.MatchString(item) || strings.HasPrefix(item, ticketSelectionPrefix) ||
+			strings.HasPrefix(item, testSelectionPrefix) || strings.HasPrefix(item, packageSelectionPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Runs checks if a quarantined test should run. pkg is the import path of the test's package,
+// and test its full name, e.g. "TestFoo/subtest_1". test is empty for whole packages, see FlakyPackage,
+// which only run if selected by their ticket or package.
+func (s Selection) Runs(ticket, pkg, test string) bool {
+	if s.all {
+		return true
+	}
+	for _, selected := range s.tickets {
+		if strings.EqualFold(selected, ticket) {
+			return true
+		}
+	}
+	for _, glob := range s.packages {
+		if matched, _ := path.Match(glob, pkg); matched {
+			return true
+		}
+	}
+	if test == "" {
+		return false
+	}
+	for _, re := range s.tests {
+		if re.MatchString(test) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsPackage checks if the selection depends on the package of tests, which is costly to look up.
+func (s Selection) needsPackage() bool {
+	return !s.all && len(s.packages) > 0
+}
+
+// envSelection parses the RUN_QUARANTINED_TESTS environment variable, see ParseSelection.
+func envSelection() (Selection, error) {
+	//nolint:forbidigo // Config doesn't make sense here
+	return ParseSelection(os.Getenv(RunQuarantinedTestsEnvVar))
+}
+
+// runsQuarantined checks if the environment selects the quarantined test to run, see ParseSelection.
+// The package of the test is only looked up if the selection needs it.
+// If the selection is invalid, the test doesn't run, and the error tells why.
+func runsQuarantined(ticket, test string) (bool, error) {
+	selection, err := envSelection()
+	if err != nil {
+		return false, err
+	}
+	pkg := ""
+	if selection.needsPackage() {
+		pkg = testPackage()
+	}
+	return selection.Runs(ticket, pkg, test), nil
+}
+
+// testPackage returns the import path of the package of the test that called into this package,
+// the first function up the stack outside of it. Like callerPackage, external test packages are reported as the
+// package they test.
+func testPackage() string {
+	ownPackage := funcPackage(runtime.FuncForPC(reflect.ValueOf(Flaky).Pointer()).Name())
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if pkg := funcPackage(frame.Function); pkg != ownPackage && pkg != "" {
+			return strings.TrimSuffix(pkg, "_test")
+		}
+		if !more {
+			return ""
+		}
+	}
+}
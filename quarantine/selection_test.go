@@ -0,0 +1,113 @@
+package quarantine_test
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/branch-out/quarantine"
+)
+
+func TestParseSelection(t *testing.T) {
+	t.Parallel()
+
+	type quarantinedTest struct {
+		ticket, pkg, test string
+	}
+	var (
+		chaosTest  = quarantinedTest{ticket: "JIRA-1", pkg: "example.com/repo/chaos", test: "TestChaos/sub"}
+		otherTest  = quarantinedTest{ticket: "OTHER-22", pkg: "example.com/repo/other", test: "TestOther"}
+		chaosPkg   = quarantinedTest{ticket: "JIRA-3", pkg: "example.com/repo/chaos"}
+		unrelated  = quarantinedTest{ticket: "JIRA-4", pkg: "example.org/unrelated", test: "TestUnrelated"}
+		allTests   = []quarantinedTest{chaosTest, otherTest, chaosPkg, unrelated}
+		noTests    = []quarantinedTest{}
+		chaosTests = []quarantinedTest{chaosTest, chaosPkg}
+		bothTests  = []quarantinedTest{chaosTest, otherTest}
+		repoTests  = []quarantinedTest{chaosTest, otherTest, chaosPkg}
+	)
+
+	tests := []struct {
+		name        string
+		value       string
+		expectedRun []quarantinedTest
+		expectedErr error
+	}{
+		{name: "unset", value: "", expectedRun: noTests},
+		{name: "false", value: "false", expectedRun: noTests},
+		{name: "true", value: "true", expectedRun: allTests},
+		{name: "true with spaces", value: " true ", expectedRun: allTests},
+		{name: "ticket", value: "JIRA-1", expectedRun: []quarantinedTest{chaosTest}},
+		{name: "ticket with prefix", value: "ticket:OTHER-22", expectedRun: []quarantinedTest{otherTest}},
+		{name: "ticket ignores case", value: "ticket:jira-1,ticket:other-22", expectedRun: bothTests},
+		{name: "legacy value", value: "1", expectedRun: noTests},
+		{name: "legacy word", value: "yes", expectedRun: noTests},
+		{name: "legacy regex", value: "^TestChaos/", expectedRun: noTests},
+		{name: "test regex", value: "test:^TestChaos/", expectedRun: []quarantinedTest{chaosTest}},
+		{name: "test regex with suffix", value: "test:Other$", expectedRun: []quarantinedTest{otherTest}},
+		{name: "test regex isn't anchored", value: "test:haos", expectedRun: []quarantinedTest{chaosTest}},
+		{name: "package glob", value: "package:example.com/repo/chaos", expectedRun: chaosTests},
+		{name: "package glob with wildcard", value: "package:example.com/*/*", expectedRun: repoTests},
+		{name: "package glob doesn't cross slashes", value: "package:example.com/*", expectedRun: noTests},
+		{
+			name:        "list",
+			value:       "OTHER-22, package:example.org/*,,test:^TestChaos",
+			expectedRun: []quarantinedTest{chaosTest, otherTest, unrelated},
+		},
+		{name: "empty ticket", value: "ticket:", expectedErr: quarantine.ErrInvalidSelection},
+		{name: "item without prefix", value: "JIRA-1,TestChaos", expectedErr: quarantine.ErrInvalidSelection},
+		{name: "invalid regex", value: "test:Test(", expectedErr: quarantine.ErrInvalidSelection},
+		{name: "empty regex", value: "JIRA-1,test:", expectedErr: quarantine.ErrInvalidSelection},
+		{name: "invalid glob", value: "package:example.com/[", expectedErr: quarantine.ErrInvalidSelection},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			selection, err := quarantine.ParseSelection(test.value)
+			if test.expectedErr != nil {
+				requireErrorIs(t, err, test.expectedErr)
+				return
+			}
+			requireNoError(t, err)
+
+			for _, quarantined := range allTests {
+				expected := false
+				for _, run := range test.expectedRun {
+					expected = expected || run == quarantined
+				}
+				assertEqual(
+					t,
+					expected,
+					selection.Runs(quarantined.ticket, quarantined.pkg, quarantined.test),
+					"%q should select %+v: %t", test.value, quarantined, expected,
+				)
+			}
+		})
+	}
+}
+
+func TestFlakySelection(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expectedRun bool
+	}{
+		{name: "ticket", value: "TEST-1,TEST-123", expectedRun: true},
+		{name: "other ticket", value: "TEST-1", expectedRun: false},
+		{name: "test name", value: "test:^TestFlakySelection/test_name$", expectedRun: true},
+		{name: "package", value: "package:github.com/smartcontractkit/branch-out/*", expectedRun: true},
+		{name: "other package", value: "package:example.com/*", expectedRun: false},
+		{name: "legacy value", value: "1", expectedRun: false},
+		{name: "invalid selection", value: "TEST-123,test:Test(", expectedRun: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(quarantine.RunQuarantinedTestsEnvVar, test.value)
+			quarantine.Flaky(t, "TEST-123")
+
+			t.Cleanup(func() {
+				requireEqual(t, !test.expectedRun, t.Skipped(), "%q should select the test: %t", test.value, test.expectedRun)
+			})
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package quarantine
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// VariantEnvVar is the environment variable naming the variant the tests run on, e.g. the name of a CI job,
+// for variants that can't be told apart otherwise, see Variant.
+const VariantEnvVar = "BRANCH_OUT_VARIANT"
+
+// raceVariant is the variant of tests built with the race detector.
+const raceVariant = "race"
+
+// Variant only quarantines the test when it runs on the given variant, so that tests that are only flaky in some
+// environments keep running everywhere else.
+//
+// A variant is one or more parts joined by "+", and matches if every part does. Parts are:
+//   - GOOS, GOARCH or GOOS/GOARCH, e.g. "linux", "arm64" or "darwin/arm64".
+//   - "race", for tests built with the race detector.
+//   - The value of the BRANCH_OUT_VARIANT environment variable, e.g. the name of a CI job.
+//
+// A variant that is exactly the value of the BRANCH_OUT_VARIANT environment variable matches as well,
+// even if it contains "+".
+//
+// Example:
+//
+//	quarantine.Flaky(t, "TEST-123", quarantine.Variant("linux+race"))
+func Variant(variant string) Option {
+	return func(o *options) {
+		o.variant = variant
+	}
+}
+
+// variantMatches checks if the tests are running on the variant, see Variant. Empty variants match any.
+func variantMatches(variant string) bool {
+	if variant == "" {
+		return true
+	}
+	//nolint:forbidigo // Config doesn't make sense here
+	envVariant := os.Getenv(VariantEnvVar)
+	if envVariant != "" && variant == envVariant {
+		return true
+	}
+	for part := range strings.SplitSeq(variant, "+") {
+		if !variantPartMatches(part, envVariant) {
+			return false
+		}
+	}
+	return true
+}
+
+// variantPartMatches checks if a single part of a variant matches the tests' environment, see Variant.
+func variantPartMatches(part, envVariant string) bool {
+	switch part {
+	case runtime.GOOS, runtime.GOARCH, runtime.GOOS + "/" + runtime.GOARCH:
+		return true
+	case raceVariant:
+		return raceEnabled
+	default:
+		return envVariant != "" && part == envVariant
+	}
+}